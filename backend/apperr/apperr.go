@@ -0,0 +1,87 @@
+// Package apperr defines the kinds of errors services return for failures a
+// client can act on, and maps them to HTTP responses.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrForbidden  = errors.New("forbidden")
+	ErrValidation = errors.New("validation failed")
+)
+
+// Error is a failure of one of the kinds above with a message for the client.
+// errors.Is matches it against its kind.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func NotFound(format string, args ...any) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func Conflict(format string, args ...any) error {
+	return &Error{Kind: ErrConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+func Forbidden(format string, args ...any) error {
+	return &Error{Kind: ErrForbidden, Message: fmt.Sprintf(format, args...)}
+}
+
+// ValidationError rejects a request because of problems with its fields.
+type ValidationError struct {
+	// Fields maps the JSON name of each rejected field to what is wrong with
+	// it, such as "is required"
+	Fields map[string][]string
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	problems := make([]string, 0, len(fields))
+	for _, field := range fields {
+		for _, message := range e.Fields[field] {
+			problems = append(problems, field+" "+message)
+		}
+	}
+	return "validation failed: " + strings.Join(problems, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Validation rejects a single field. Use the validation package to check
+// several at once.
+func Validation(field, message string) error {
+	return &ValidationError{Fields: map[string][]string{field: {message}}}
+}
+
+// Fields returns the field problems of a validation error, or nil for any
+// other error.
+func Fields(err error) map[string][]string {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Fields
+	}
+	return nil
+}
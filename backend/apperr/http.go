@@ -0,0 +1,72 @@
+package apperr
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/bpalazzi512/easy-ballot/backend/types"
+)
+
+// Codes identify the kind of an error in API responses.
+const (
+	CodeNotFound   = "not_found"
+	CodeConflict   = "conflict"
+	CodeForbidden  = "forbidden"
+	CodeValidation = "validation_failed"
+	CodeInternal   = "internal_error"
+)
+
+// StatusCode maps an error to the HTTP status handlers should respond with.
+// Errors of no known kind are internal errors.
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrValidation):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func Code(err error) string {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return CodeNotFound
+	case errors.Is(err, ErrConflict):
+		return CodeConflict
+	case errors.Is(err, ErrForbidden):
+		return CodeForbidden
+	case errors.Is(err, ErrValidation):
+		return CodeValidation
+	default:
+		return CodeInternal
+	}
+}
+
+// WriteError responds with the status and code of err. Internal errors are
+// logged and replaced by a generic message so storage details don't reach
+// clients.
+func WriteError(w http.ResponseWriter, err error) {
+	status := StatusCode(err)
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		log.Printf("internal error: %v", err)
+		message = "internal server error"
+	}
+
+	response := types.APIResponse{
+		Success: false,
+		Code:    Code(err),
+		Message: message,
+		Errors:  Fields(err),
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
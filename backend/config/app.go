@@ -0,0 +1,31 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+type AppConfig struct {
+	// BaseURL is where the frontend is served, used to build links sent to users.
+	BaseURL string
+	// AutoMigrate applies pending database migrations at startup. Turn it off
+	// to run "migrate" separately, for example before a deploy.
+	AutoMigrate bool
+}
+
+func GetAppConfig() *AppConfig {
+	return &AppConfig{
+		BaseURL:     strings.TrimRight(getEnvOrDefault("APP_BASE_URL", "http://localhost:3000"), "/"),
+		AutoMigrate: getEnvBoolOrDefault("AUTO_MIGRATE", true),
+	}
+}
+
+func getEnvBoolOrDefault(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
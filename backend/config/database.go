@@ -7,22 +7,41 @@ import (
 	"os"
 	"time"
 
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const (
+	StorageBackendMongoDB  = "mongodb"
+	StorageBackendMemory   = "memory"
+	StorageBackendPostgres = "postgres"
+	StorageBackendSQLite   = "sqlite"
+)
+
 type DatabaseConfig struct {
+	// Backend selects where data is stored: mongodb, postgres, sqlite (a
+	// single file, for small deployments), or memory (lost on exit, for tests
+	// and trying the server without external services).
+	Backend  string
 	URI      string
 	Database string
-	Timeout  time.Duration
+	// PostgresURL is used instead of URI and Database by the postgres backend.
+	PostgresURL string
+	// SQLitePath is the database file of the sqlite backend.
+	SQLitePath string
+	Timeout    time.Duration
 }
 
 func GetDatabaseConfig() *DatabaseConfig {
 	return &DatabaseConfig{
-		URI:      getEnvOrDefault("MONGODB_URI", "mongodb://localhost:27017"),
-		Database: getEnvOrDefault("MONGODB_DATABASE", "easy_ballot"),
-		Timeout:  10 * time.Second,
+		Backend:     getEnvOrDefault("STORAGE_BACKEND", StorageBackendMongoDB),
+		URI:         getEnvOrDefault("MONGODB_URI", "mongodb://localhost:27017"),
+		Database:    getEnvOrDefault("MONGODB_DATABASE", "easy_ballot"),
+		PostgresURL: getEnvOrDefault("POSTGRES_URL", "postgres://localhost:5432/easy_ballot?sslmode=disable"),
+		SQLitePath:  getEnvOrDefault("SQLITE_PATH", "easy_ballot.db"),
+		Timeout:     10 * time.Second,
 	}
 }
 
@@ -67,6 +86,29 @@ func CloseMongoDB(client *mongo.Client) error {
 	return client.Disconnect(ctx)
 }
 
+// ConnectSQL opens the database of the postgres or sqlite backend.
+func ConnectSQL(config *DatabaseConfig) (*sqldb.DB, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+
+	switch config.Backend {
+	case StorageBackendPostgres:
+		db, err := sqldb.Open(ctx, sqldb.Postgres, config.PostgresURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+		}
+		return db, nil
+	case StorageBackendSQLite:
+		db, err := sqldb.Open(ctx, sqldb.SQLite, config.SQLitePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+		}
+		return db, nil
+	default:
+		return nil, fmt.Errorf("storage backend %s is not a SQL database", config.Backend)
+	}
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
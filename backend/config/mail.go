@@ -0,0 +1,31 @@
+package config
+
+const (
+	MailDriverSMTP   = "smtp"
+	MailDriverFile   = "file"
+	MailDriverMemory = "memory"
+)
+
+type MailConfig struct {
+	// Driver selects how email is delivered: smtp, file (written to
+	// Directory for local development) or memory (discarded, for tests).
+	Driver       string
+	From         string
+	Directory    string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
+func GetMailConfig() *MailConfig {
+	return &MailConfig{
+		Driver:       getEnvOrDefault("MAIL_DRIVER", MailDriverFile),
+		From:         getEnvOrDefault("MAIL_FROM", "Easy Ballot <no-reply@localhost>"),
+		Directory:    getEnvOrDefault("MAIL_DIRECTORY", "mail"),
+		SMTPHost:     getEnvOrDefault("SMTP_HOST", "localhost"),
+		SMTPPort:     getEnvIntOrDefault("SMTP_PORT", 587),
+		SMTPUsername: getEnvOrDefault("SMTP_USERNAME", ""),
+		SMTPPassword: getEnvOrDefault("SMTP_PASSWORD", ""),
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type SecurityConfig struct {
+	PasswordHashCost   int
+	TokenSecret        []byte
+	AccessTokenTTL     time.Duration
+	RefreshTokenTTL    time.Duration
+	InvitationTTL      time.Duration
+	PasswordResetTTL   time.Duration
+	VerificationTTL    time.Duration
+	AccountEmailLimit  int
+	AccountEmailWindow time.Duration
+}
+
+func GetSecurityConfig() *SecurityConfig {
+	return &SecurityConfig{
+		PasswordHashCost:   getEnvIntOrDefault("PASSWORD_HASH_COST", bcrypt.DefaultCost),
+		TokenSecret:        getTokenSecret(),
+		AccessTokenTTL:     getEnvDurationOrDefault("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:    getEnvDurationOrDefault("REFRESH_TOKEN_TTL", 7*24*time.Hour),
+		InvitationTTL:      getEnvDurationOrDefault("INVITATION_TTL", 7*24*time.Hour),
+		PasswordResetTTL:   getEnvDurationOrDefault("PASSWORD_RESET_TTL", time.Hour),
+		VerificationTTL:    getEnvDurationOrDefault("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+		AccountEmailLimit:  getEnvIntOrDefault("ACCOUNT_EMAIL_LIMIT", 3),
+		AccountEmailWindow: getEnvDurationOrDefault("ACCOUNT_EMAIL_WINDOW", time.Hour),
+	}
+}
+
+// getTokenSecret returns the signing key for auth tokens. Without TOKEN_SECRET a
+// random key is generated, which invalidates all tokens on every restart.
+func getTokenSecret() []byte {
+	if secret := os.Getenv("TOKEN_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+
+	log.Println("TOKEN_SECRET is not set; generating a temporary signing key")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatal("Failed to generate token secret:", err)
+	}
+	return []byte(hex.EncodeToString(secret))
+}
+
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
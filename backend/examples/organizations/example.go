@@ -7,20 +7,50 @@ import (
 	"time"
 
 	"github.com/bpalazzi512/easy-ballot/backend/config"
+	"github.com/bpalazzi512/easy-ballot/backend/migrations"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/archives"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/invitations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
 	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
 )
 
 func main() {
+	// STORAGE_BACKEND=memory runs the example without a database, and
+	// STORAGE_BACKEND=postgres or sqlite against a SQL database
+	var organizationRepository organizations.OrganizationRepository
+	var deletionRepository organizations.DeletionRepository
 	dbConfig := config.GetDatabaseConfig()
-	client, database, err := config.ConnectMongoDB(dbConfig)
-	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
-	}
-	defer config.CloseMongoDB(client)
+	switch dbConfig.Backend {
+	case config.StorageBackendMemory:
+		organizationRepository = organizations.NewMemoryOrganizationRepository()
+		deletionRepository = archives.NewMemoryArchiveRepository(organizationRepository, memberships.NewMemoryMembershipRepository(),
+			invitations.NewMemoryInvitationRepository(), ballots.NewMemoryBallotRepository())
+	case config.StorageBackendPostgres, config.StorageBackendSQLite:
+		db, err := config.ConnectSQL(dbConfig)
+		if err != nil {
+			log.Fatal("Failed to connect to database:", err)
+		}
+		defer db.Close()
+
+		if err := migrations.RunSQL(context.Background(), db); err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+		organizationRepository = organizations.NewSQLOrganizationRepository(db)
+		deletionRepository = archives.NewSQLArchiveRepository(db)
+	default:
+		client, database, err := config.ConnectMongoDB(dbConfig)
+		if err != nil {
+			log.Fatal("Failed to connect to database:", err)
+		}
+		defer config.CloseMongoDB(client)
 
-	organizationCollection := database.Collection("organizations")
-	organizationRepository := organizations.NewMongoDBOrganizationRepository(organizationCollection)
-	organizationService := organizations.NewOrganizationService(organizationRepository)
+		organizationCollection := database.Collection("organizations")
+		organizationRepository = organizations.NewMongoDBOrganizationRepository(organizationCollection)
+		deletionRepository = archives.NewMongoDBArchiveRepository(database)
+	}
+	organizationService := organizations.NewOrganizationService(organizationRepository, deletionRepository)
 	ctx := context.Background()
 
 	newOrganization := organizations.CreateOrganizationRequest{
@@ -30,7 +60,7 @@ func main() {
 	}
 
 	fmt.Println("Creating organization...")
-	if err := organizationService.CreateOrganization(ctx, newOrganization); err != nil {
+	if _, err := organizationService.CreateOrganization(ctx, newOrganization); err != nil {
 		log.Printf("Failed to create organization: %v", err)
 	} else {
 		fmt.Println("Organization created successfully!")
@@ -70,12 +100,12 @@ func main() {
 	}
 
 	fmt.Println("\nListing all organizations...")
-	allOrgs, err := organizationService.ListOrganizations(ctx, 10, 0)
+	allOrgs, err := organizationService.ListOrganizations(ctx, organizations.OrganizationFilter{}, pagination.Page{Limit: 10})
 	if err != nil {
 		log.Printf("Failed to list organizations: %v", err)
 	} else {
-		fmt.Printf("Found %d organizations:\n", len(allOrgs))
-		for i, org := range allOrgs {
+		fmt.Printf("Found %d of %d organizations:\n", len(allOrgs.Items), allOrgs.Total)
+		for i, org := range allOrgs.Items {
 			fmt.Printf("  %d. %s (Owner: %s)\n", i+1, org.Name, org.OwnerUserID)
 		}
 	}
@@ -91,10 +121,12 @@ func main() {
 	// Uncomment to test deletion
 	if len(ownerOrgs) > 0 {
 		fmt.Println("\nDeleting organization...")
-		if err := organizationService.DeleteOrganization(ctx, ownerOrgs[0].ID); err != nil {
+		report, err := organizationService.DeleteOrganization(ctx, ownerOrgs[0].ID)
+		if err != nil {
 			log.Printf("Failed to delete organization: %v", err)
 		} else {
-			fmt.Println("Organization deleted successfully!")
+			fmt.Printf("Organization deleted successfully! Archived %d members and %d ballots, deleted %d invitations\n",
+				report.MembersArchived, report.BallotsArchived, report.InvitationsDeleted)
 		}
 	}
 }
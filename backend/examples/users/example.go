@@ -4,35 +4,56 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"time"
 
 	"github.com/bpalazzi512/easy-ballot/backend/config"
+	"github.com/bpalazzi512/easy-ballot/backend/migrations"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
 	"github.com/bpalazzi512/easy-ballot/backend/services/users"
 )
 
 func main() {
+	// STORAGE_BACKEND=memory runs the example without a database, and
+	// STORAGE_BACKEND=postgres or sqlite against a SQL database
+	var userRepository users.UserRepository
 	dbConfig := config.GetDatabaseConfig()
-	client, database, err := config.ConnectMongoDB(dbConfig)
-	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
-	}
-	defer config.CloseMongoDB(client)
+	switch dbConfig.Backend {
+	case config.StorageBackendMemory:
+		userRepository = users.NewMemoryUserRepository(memberships.NewMemoryMembershipRepository())
+	case config.StorageBackendPostgres, config.StorageBackendSQLite:
+		db, err := config.ConnectSQL(dbConfig)
+		if err != nil {
+			log.Fatal("Failed to connect to database:", err)
+		}
+		defer db.Close()
+
+		if err := migrations.RunSQL(context.Background(), db); err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+		userRepository = users.NewSQLUserRepository(db)
+	default:
+		client, database, err := config.ConnectMongoDB(dbConfig)
+		if err != nil {
+			log.Fatal("Failed to connect to database:", err)
+		}
+		defer config.CloseMongoDB(client)
 
-	userCollection := database.Collection("users")
-	userRepository := users.NewMongoDBUserRepository(userCollection)
-	userService := users.NewUserService(userRepository)
+		userCollection := database.Collection("users")
+		userRepository = users.NewMongoDBUserRepository(userCollection)
+	}
+	securityConfig := config.GetSecurityConfig()
+	userService := users.NewUserService(userRepository, users.NewPasswordHasher(securityConfig.PasswordHashCost))
 	ctx := context.Background()
 
 	newUser := users.CreateUserRequest{
-		FirstName:      "John",
-		LastName:       "Doe",
-		Email:          "john.doe@example.com",
-		Password:       "securepassword123",
-		OrganizationID: "org-123",
+		FirstName: "John",
+		LastName:  "Doe",
+		Email:     "john.doe@example.com",
+		Password:  "securepassword123",
 	}
 
 	fmt.Println("Creating user...")
-	if err := userService.CreateUser(ctx, newUser); err != nil {
+	if _, err := userService.CreateUser(ctx, newUser); err != nil {
 		log.Printf("Failed to create user: %v", err)
 	} else {
 		fmt.Println("User created successfully!")
@@ -48,10 +69,14 @@ func main() {
 
 	if user != nil {
 		fmt.Println("\nUpdating user...")
-		user.FirstName = "Jane"
-		user.UpdatedAt = time.Now()
+		update := users.UpdateUserRequest{
+			FirstName:      "Jane",
+			LastName:       user.LastName,
+			Email:          user.Email,
+			ProfilePicture: user.ProfilePicture,
+		}
 
-		if err := userService.UpdateUser(ctx, user.ID, *user); err != nil {
+		if err := userService.UpdateUser(ctx, user.ID, update); err != nil {
 			log.Printf("Failed to update user: %v", err)
 		} else {
 			fmt.Println("User updated successfully!")
@@ -59,22 +84,22 @@ func main() {
 	}
 
 	fmt.Println("\nListing users...")
-	userList, err := userService.ListUsers(ctx, "org-123", 10, 0)
+	userList, err := userService.ListUsers(ctx, users.UserFilter{}, pagination.Page{Limit: 10})
 	if err != nil {
 		log.Printf("Failed to list users: %v", err)
 	} else {
-		fmt.Printf("Found %d users:\n", len(userList))
-		for i, u := range userList {
+		fmt.Printf("Found %d of %d users:\n", len(userList.Items), userList.Total)
+		for i, u := range userList.Items {
 			fmt.Printf("  %d. %s %s (%s)\n", i+1, u.FirstName, u.LastName, u.Email)
 		}
 	}
 
 	fmt.Println("\nCounting users...")
-	count, err := userService.CountUsers(ctx, "org-123")
+	count, err := userService.CountUsers(ctx)
 	if err != nil {
 		log.Printf("Failed to count users: %v", err)
 	} else {
-		fmt.Printf("Total users in organization: %d\n", count)
+		fmt.Printf("Total users: %d\n", count)
 	}
 
 	// Uncomment to test deletion
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/services/accounts"
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/types"
+)
+
+type Handler struct {
+	authService    *auth.AuthService
+	accountService *accounts.AccountService
+}
+
+func NewHandler(authService *auth.AuthService, accountService *accounts.AccountService) *Handler {
+	return &Handler{
+		authService:    authService,
+		accountService: accountService,
+	}
+}
+
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var request auth.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.authService.Login(r.Context(), request)
+	if err != nil {
+		response := types.APIResponse{
+			Success: false,
+			Message: err.Error(),
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Logged in successfully",
+		Data:    tokens,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var request auth.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.authService.Refresh(r.Context(), request)
+	if err != nil {
+		response := types.APIResponse{
+			Success: false,
+			Message: err.Error(),
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Data:    tokens,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		response := types.APIResponse{
+			Success: false,
+			Message: "authentication required",
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Data:    user,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var request accounts.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.accountService.ForgotPassword(r.Context(), request); err != nil {
+		response := types.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Errors:  apperr.Fields(err),
+		}
+		w.WriteHeader(accountStatusCode(err))
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "If an account exists for this email, a password reset link has been sent",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var request accounts.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.accountService.ResetPassword(r.Context(), request); err != nil {
+		response := types.APIResponse{
+			Success: false,
+			Message: err.Error(),
+			Errors:  apperr.Fields(err),
+		}
+		w.WriteHeader(accountStatusCode(err))
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Password reset successfully",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var request accounts.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(r.Context(), request); err != nil {
+		response := types.APIResponse{
+			Success: false,
+			Message: err.Error(),
+		}
+		w.WriteHeader(accountStatusCode(err))
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Email verified successfully",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// ResendVerification emails the authenticated user a new verification link.
+func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		response := types.APIResponse{
+			Success: false,
+			Message: "authentication required",
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	if err := h.accountService.SendVerification(r.Context(), *user); err != nil {
+		response := types.APIResponse{
+			Success: false,
+			Message: err.Error(),
+		}
+		w.WriteHeader(accountStatusCode(err))
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Verification email sent",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func accountStatusCode(err error) int {
+	switch {
+	case errors.Is(err, accounts.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, accounts.ErrAlreadyVerified):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
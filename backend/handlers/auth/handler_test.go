@@ -0,0 +1,95 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	authHandlers "github.com/bpalazzi512/easy-ballot/backend/handlers/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/routes"
+	"github.com/bpalazzi512/easy-ballot/backend/services/accounts"
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/services/notifications"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newRouter serves the auth routes from in-memory storage holding one
+// unverified user, ada@example.com, who may request one email an hour.
+func newRouter(t *testing.T) (*mux.Router, *users.User) {
+	t.Helper()
+
+	templates, err := notifications.LoadTemplates()
+	if err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+
+	userRepository := users.NewMemoryUserRepository(nil)
+	userService := users.NewUserService(userRepository, users.NewPasswordHasher(bcrypt.MinCost))
+	notifier := notifications.NewNotifier(notifications.NewMemoryMailer(), templates, organizations.NewMemoryOrganizationRepository(), userRepository, "http://localhost")
+	accountService := accounts.NewAccountService(accounts.NewMemoryTokenRepository(), userService, notifier, accounts.NewRateLimiter(1, time.Hour), time.Hour, time.Hour, "http://localhost")
+
+	user, err := userService.CreateUser(context.Background(), users.CreateUserRequest{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Password: "correct horse battery"})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	handler := authHandlers.NewHandler(auth.NewAuthService(userService, []byte("secret"), time.Minute, time.Hour), accountService)
+	router := mux.NewRouter()
+	routes.RegisterAuthRoutes(router, router, handler)
+	return router, user
+}
+
+func TestAuthRoutes(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		method        string
+		path          string
+		authenticated bool
+		// bodies are sent in order, and status is expected for the last one
+		bodies []string
+		status int
+	}{
+		{name: "Login", method: http.MethodPost, path: "/auth/login", bodies: []string{`{"email":"ada@example.com","password":"correct horse battery"}`}, status: http.StatusOK},
+		{name: "LoginWrongPassword", method: http.MethodPost, path: "/auth/login", bodies: []string{`{"email":"ada@example.com","password":"incorrect"}`}, status: http.StatusUnauthorized},
+		{name: "LoginInvalidJSON", method: http.MethodPost, path: "/auth/login", bodies: []string{"{"}, status: http.StatusBadRequest},
+		{name: "RefreshInvalidToken", method: http.MethodPost, path: "/auth/refresh", bodies: []string{`{"refresh_token":"invalid"}`}, status: http.StatusUnauthorized},
+		{name: "Me", method: http.MethodGet, path: "/auth/me", authenticated: true, status: http.StatusOK},
+		{name: "MeUnauthenticated", method: http.MethodGet, path: "/auth/me", status: http.StatusUnauthorized},
+		{name: "Forgot", method: http.MethodPost, path: "/auth/forgot", bodies: []string{`{"email":"ada@example.com"}`}, status: http.StatusOK},
+		// Unknown addresses look the same as known ones
+		{name: "ForgotUnknownEmail", method: http.MethodPost, path: "/auth/forgot", bodies: []string{`{"email":"grace@example.com"}`}, status: http.StatusOK},
+		{name: "ForgotRateLimited", method: http.MethodPost, path: "/auth/forgot", bodies: []string{`{"email":"ada@example.com"}`, `{"email":"ada@example.com"}`}, status: http.StatusTooManyRequests},
+		{name: "ResetInvalidToken", method: http.MethodPost, path: "/auth/reset", bodies: []string{`{"token":"invalid","password":"new horse battery"}`}, status: http.StatusBadRequest},
+		{name: "VerifyInvalidToken", method: http.MethodPost, path: "/auth/verify", bodies: []string{`{"token":"invalid"}`}, status: http.StatusBadRequest},
+		{name: "ResendVerification", method: http.MethodPost, path: "/auth/verify/resend", authenticated: true, status: http.StatusOK},
+		{name: "ResendVerificationUnauthenticated", method: http.MethodPost, path: "/auth/verify/resend", status: http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router, user := newRouter(t)
+
+			bodies := tc.bodies
+			if len(bodies) == 0 {
+				bodies = []string{""}
+			}
+			var w *httptest.ResponseRecorder
+			for _, body := range bodies {
+				r := httptest.NewRequest(tc.method, tc.path, strings.NewReader(body))
+				if tc.authenticated {
+					r = r.WithContext(auth.WithUser(r.Context(), user))
+				}
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, r)
+			}
+
+			if w.Code != tc.status {
+				t.Errorf("%s %s responded with %d, want %d: %s", tc.method, tc.path, w.Code, tc.status, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package ballots
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/authz"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/notifications"
+	"github.com/bpalazzi512/easy-ballot/backend/services/rolls"
+	"github.com/bpalazzi512/easy-ballot/backend/types"
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	ballotService *ballots.BallotService
+	rollService   *rolls.RollService
+	notifier      *notifications.Notifier
+	authorizer    *authz.Authorizer
+}
+
+func NewHandler(ballotService *ballots.BallotService, rollService *rolls.RollService, notifier *notifications.Notifier, authorizer *authz.Authorizer) *Handler {
+	return &Handler{
+		ballotService: ballotService,
+		rollService:   rollService,
+		notifier:      notifier,
+		authorizer:    authorizer,
+	}
+}
+
+func (h *Handler) CreateBallot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var ballot ballots.CreateBallotRequest
+	if err := json.NewDecoder(r.Body).Decode(&ballot); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.authorizer.AuthorizeRequest(r, ballot.OrganizationID, authz.ActionManageBallots); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	createdBallot, err := h.ballotService.CreateBallot(r.Context(), ballot)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Ballot created successfully",
+		Data:    createdBallot,
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) GetBallot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	ballotID := vars["id"]
+
+	ballot, err := h.ballotService.GetBallotByID(r.Context(), ballotID)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	if _, err := h.authorizer.AuthorizeRequest(r, ballot.OrganizationID, authz.ActionViewBallots); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Data:    ballot,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) UpdateBallot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	ballotID := vars["id"]
+
+	if !h.authorizeBallot(w, r, ballotID, authz.ActionManageBallots) {
+		return
+	}
+
+	var ballot ballots.Ballot
+	if err := json.NewDecoder(r.Body).Decode(&ballot); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.ballotService.UpdateBallot(r.Context(), ballotID, ballot); err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Ballot updated successfully",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) OpenBallot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	ballotID := vars["id"]
+
+	if !h.authorizeBallot(w, r, ballotID, authz.ActionRunElections) {
+		return
+	}
+
+	// Freeze the voter roll as soon as voting starts
+	ballot, err := h.ballotService.OpenBallot(r.Context(), ballotID, h.rollService.Snapshot)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	h.announce(*ballot, h.notifier.AnnounceBallotOpened)
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Ballot opened successfully",
+		Data:    ballot,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) CloseBallot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	ballotID := vars["id"]
+
+	if !h.authorizeBallot(w, r, ballotID, authz.ActionRunElections) {
+		return
+	}
+
+	ballot, err := h.ballotService.CloseBallot(r.Context(), ballotID)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	h.announce(*ballot, h.notifier.AnnounceResults)
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Ballot closed successfully",
+		Data:    ballot,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) SetEligibility(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	ballotID := vars["id"]
+
+	if !h.authorizeBallot(w, r, ballotID, authz.ActionManageBallots) {
+		return
+	}
+
+	var eligibility ballots.Eligibility
+	if err := json.NewDecoder(r.Body).Decode(&eligibility); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	ballot, err := h.ballotService.SetEligibility(r.Context(), ballotID, eligibility)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Eligibility updated successfully",
+		Data:    ballot,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) GetRoll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	ballotID := vars["id"]
+
+	ballot, err := h.ballotService.GetBallotByID(r.Context(), ballotID)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	if _, err := h.authorizer.AuthorizeRequest(r, ballot.OrganizationID, authz.ActionViewTurnout); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	entries, err := h.rollService.ListEntries(r.Context(), ballot)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Data:    entries,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) DeleteBallot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	ballotID := vars["id"]
+
+	if !h.authorizeBallot(w, r, ballotID, authz.ActionManageBallots) {
+		return
+	}
+
+	if err := h.ballotService.DeleteBallot(r.Context(), ballotID); err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Ballot deleted successfully",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) ListBallots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get query parameters
+	organizationID := r.URL.Query().Get("organization_id")
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		response := types.APIResponse{
+			Success: false,
+			Message: err.Error(),
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	// Users can belong to several organizations, so the one to list is explicit
+	if organizationID == "" {
+		response := types.APIResponse{
+			Success: false,
+			Message: "organization_id is required",
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	if _, err := h.authorizer.AuthorizeRequest(r, organizationID, authz.ActionViewBallots); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	ballotList, err := h.ballotService.ListBallots(r.Context(), organizationID, page)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success:    true,
+		Data:       ballotList.Items,
+		NextCursor: ballotList.NextCursor,
+		Total:      &ballotList.Total,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// authorizeBallot checks the action against the organization that owns the
+// ballot, writing the error response and returning false when it is denied.
+func (h *Handler) authorizeBallot(w http.ResponseWriter, r *http.Request, ballotID string, action authz.Action) bool {
+	ballot, err := h.ballotService.GetBallotByID(r.Context(), ballotID)
+	if err == nil {
+		_, err = h.authorizer.AuthorizeRequest(r, ballot.OrganizationID, action)
+	}
+	if err != nil {
+		authz.WriteError(w, err)
+		return false
+	}
+	return true
+}
+
+// announce emails everyone on the ballot's voter roll in the background, so a
+// large roll or a slow mail server does not hold up the response.
+func (h *Handler) announce(ballot ballots.Ballot, send func(context.Context, ballots.Ballot, []string) error) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		entries, err := h.rollService.ListEntries(ctx, &ballot)
+		if err != nil {
+			log.Printf("failed to load voter roll for ballot %s: %v", ballot.ID, err)
+			return
+		}
+
+		userIDs := make([]string, len(entries))
+		for i, entry := range entries {
+			userIDs[i] = entry.UserID
+		}
+
+		if err := send(ctx, ballot, userIDs); err != nil {
+			log.Printf("failed to email voters of ballot %s: %v", ballot.ID, err)
+		}
+	}()
+}
@@ -0,0 +1,136 @@
+package ballots_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ballotHandlers "github.com/bpalazzi512/easy-ballot/backend/handlers/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/routes"
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/services/authz"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/notifications"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/rolls"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/gorilla/mux"
+)
+
+// voteCounts counts votes from a map of ballot IDs to counts.
+type voteCounts map[string]int64
+
+func (c voteCounts) CountVotes(ctx context.Context, ballotID string) (int64, error) {
+	return c[ballotID], nil
+}
+
+// newRouter serves the ballot routes from in-memory storage holding one
+// organization owned by "owner", with "officer" and "voter" as members, and a
+// ballot scheduled to open in an hour. "outsider" belongs to no organization.
+func newRouter(t *testing.T) (*mux.Router, *strings.Replacer) {
+	t.Helper()
+	ctx := context.Background()
+
+	templates, err := notifications.LoadTemplates()
+	if err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+
+	organizationRepository := organizations.NewMemoryOrganizationRepository()
+	membershipRepository := memberships.NewMemoryMembershipRepository()
+	userRepository := users.NewMemoryUserRepository(membershipRepository)
+	ballotRepository := ballots.NewMemoryBallotRepository()
+
+	organization, err := organizationRepository.CreateOrganization(ctx, organizations.Organization{Name: "Acme Corporation", OwnerUserID: "owner"})
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	for userID, role := range map[string]users.UserRole{"owner": users.RoleOwner, "officer": users.RoleOfficer, "voter": users.RoleVoter} {
+		if _, err := membershipRepository.CreateMembership(ctx, memberships.Membership{OrganizationID: organization.ID, UserID: userID, Role: role}); err != nil {
+			t.Fatalf("CreateMembership: %v", err)
+		}
+	}
+
+	opensAt := time.Now().Add(time.Hour)
+	ballot, err := ballotRepository.CreateBallot(ctx, ballots.Ballot{
+		OrganizationID: organization.ID,
+		Title:          "Committee chair",
+		OpensAt:        opensAt,
+		ClosesAt:       opensAt.Add(time.Hour),
+		Questions:      []ballots.Question{{ID: "q1", Prompt: "Who should chair the committee?", Options: []ballots.Option{{ID: "a", Label: "Ada"}, {ID: "b", Label: "Grace"}}}},
+		Eligibility:    ballots.Eligibility{Mode: ballots.EligibilityAllMembers},
+	})
+	if err != nil {
+		t.Fatalf("CreateBallot: %v", err)
+	}
+
+	handler := ballotHandlers.NewHandler(
+		ballots.NewBallotService(ballotRepository, voteCounts{}),
+		rolls.NewRollService(rolls.NewMemoryRollRepository(), ballotRepository, membershipRepository),
+		notifications.NewNotifier(notifications.NewMemoryMailer(), templates, organizationRepository, userRepository, "http://localhost"),
+		authz.NewAuthorizer(organizationRepository, membershipRepository),
+	)
+	router := mux.NewRouter()
+	routes.RegisterBallotRoutes(router, handler)
+	return router, strings.NewReplacer("{organization}", organization.ID, "{ballot}", ballot.ID)
+}
+
+func TestBallotRoutes(t *testing.T) {
+	ballot := `{"organization_id":"{organization}","title":"Treasurer","opens_at":"2100-01-01T00:00:00Z","closes_at":"2100-01-02T00:00:00Z",` +
+		`"questions":[{"prompt":"Who should be treasurer?","options":[{"id":"a","label":"Ada"},{"id":"b","label":"Grace"}]}]}`
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		// path and body may refer to {organization} and {ballot}
+		path   string
+		actor  string
+		body   string
+		status int
+	}{
+		{name: "Create", method: http.MethodPost, path: "/ballots", actor: "officer", body: ballot, status: http.StatusCreated},
+		{name: "CreateAsVoter", method: http.MethodPost, path: "/ballots", actor: "voter", body: ballot, status: http.StatusForbidden},
+		{name: "CreateAsOutsider", method: http.MethodPost, path: "/ballots", actor: "outsider", body: ballot, status: http.StatusForbidden},
+		{name: "CreateUnauthenticated", method: http.MethodPost, path: "/ballots", body: ballot, status: http.StatusUnauthorized},
+		{name: "CreateInvalid", method: http.MethodPost, path: "/ballots", actor: "officer", body: `{"organization_id":"{organization}"}`, status: http.StatusUnprocessableEntity},
+		{name: "Get", method: http.MethodGet, path: "/ballots/{ballot}", actor: "voter", status: http.StatusOK},
+		{name: "GetAsOutsider", method: http.MethodGet, path: "/ballots/{ballot}", actor: "outsider", status: http.StatusForbidden},
+		{name: "GetUnknown", method: http.MethodGet, path: "/ballots/missing", actor: "voter", status: http.StatusNotFound},
+		{name: "List", method: http.MethodGet, path: "/ballots?organization_id={organization}", actor: "voter", status: http.StatusOK},
+		{name: "ListWithoutOrganization", method: http.MethodGet, path: "/ballots", actor: "voter", status: http.StatusBadRequest},
+		{name: "ListAsOutsider", method: http.MethodGet, path: "/ballots?organization_id={organization}", actor: "outsider", status: http.StatusForbidden},
+		{name: "Update", method: http.MethodPut, path: "/ballots/{ballot}", actor: "officer", body: ballot, status: http.StatusOK},
+		{name: "UpdateAsVoter", method: http.MethodPut, path: "/ballots/{ballot}", actor: "voter", body: ballot, status: http.StatusForbidden},
+		{name: "Open", method: http.MethodPost, path: "/ballots/{ballot}/open", actor: "officer", status: http.StatusOK},
+		// Only election officers run elections, not even the owner
+		{name: "OpenAsOwner", method: http.MethodPost, path: "/ballots/{ballot}/open", actor: "owner", status: http.StatusForbidden},
+		{name: "OpenUnknown", method: http.MethodPost, path: "/ballots/missing/open", actor: "officer", status: http.StatusNotFound},
+		{name: "CloseScheduled", method: http.MethodPost, path: "/ballots/{ballot}/close", actor: "officer", status: http.StatusConflict},
+		{name: "SetEligibility", method: http.MethodPut, path: "/ballots/{ballot}/eligibility", actor: "officer", body: `{"mode":"roles","roles":["voter"]}`, status: http.StatusOK},
+		{name: "SetEligibilityAsVoter", method: http.MethodPut, path: "/ballots/{ballot}/eligibility", actor: "voter", body: `{"mode":"roles","roles":["voter"]}`, status: http.StatusForbidden},
+		{name: "SetInvalidEligibility", method: http.MethodPut, path: "/ballots/{ballot}/eligibility", actor: "officer", body: `{"mode":"roles"}`, status: http.StatusUnprocessableEntity},
+		{name: "Roll", method: http.MethodGet, path: "/ballots/{ballot}/roll", actor: "officer", status: http.StatusOK},
+		{name: "RollAsVoter", method: http.MethodGet, path: "/ballots/{ballot}/roll", actor: "voter", status: http.StatusForbidden},
+		{name: "Delete", method: http.MethodDelete, path: "/ballots/{ballot}", actor: "officer", status: http.StatusOK},
+		{name: "DeleteAsVoter", method: http.MethodDelete, path: "/ballots/{ballot}", actor: "voter", status: http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router, replacer := newRouter(t)
+
+			r := httptest.NewRequest(tc.method, replacer.Replace(tc.path), strings.NewReader(replacer.Replace(tc.body)))
+			if tc.actor != "" {
+				r = r.WithContext(auth.WithUser(r.Context(), &users.User{ID: tc.actor}))
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != tc.status {
+				t.Errorf("%s %s as %q responded with %d, want %d: %s", tc.method, tc.path, tc.actor, w.Code, tc.status, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package invitations
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/services/authz"
+	"github.com/bpalazzi512/easy-ballot/backend/services/invitations"
+	"github.com/bpalazzi512/easy-ballot/backend/types"
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	invitationService *invitations.InvitationService
+	authorizer        *authz.Authorizer
+}
+
+func NewHandler(invitationService *invitations.InvitationService, authorizer *authz.Authorizer) *Handler {
+	return &Handler{
+		invitationService: invitationService,
+		authorizer:        authorizer,
+	}
+}
+
+func (h *Handler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	organizationID := vars["id"]
+
+	actor, err := h.authorizer.AuthorizeRequest(r, organizationID, authz.ActionManageMembers)
+	if err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	var request invitations.CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	invitation, err := h.invitationService.CreateInvitation(r.Context(), organizationID, actor.ID, request)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Invitation created successfully",
+		Data:    invitation,
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	organizationID := vars["id"]
+
+	_, err := h.authorizer.AuthorizeRequest(r, organizationID, authz.ActionManageMembers)
+	if err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	invitationList, err := h.invitationService.ListPendingInvitations(r.Context(), organizationID)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Data:    invitationList,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) ResendInvitation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	organizationID := vars["id"]
+
+	if _, err := h.authorizer.AuthorizeRequest(r, organizationID, authz.ActionManageMembers); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	// The invitation must belong to the organization in the path
+	invitation, err := h.invitationService.GetInvitationByID(r.Context(), vars["invitationId"])
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+	if invitation.OrganizationID != organizationID {
+		apperr.WriteError(w, apperr.NotFound("invitation not found"))
+		return
+	}
+
+	invitation, err = h.invitationService.ResendInvitation(r.Context(), invitation.ID)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Invitation resent successfully",
+		Data:    invitation,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	organizationID := vars["id"]
+
+	if _, err := h.authorizer.AuthorizeRequest(r, organizationID, authz.ActionManageMembers); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	// The invitation must belong to the organization in the path
+	invitation, err := h.invitationService.GetInvitationByID(r.Context(), vars["invitationId"])
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+	if invitation.OrganizationID != organizationID {
+		apperr.WriteError(w, apperr.NotFound("invitation not found"))
+		return
+	}
+
+	if err := h.invitationService.RevokeInvitation(r.Context(), invitation.ID); err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Invitation revoked successfully",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// AcceptInvitation is public: the token itself proves the invitation was
+// received.
+func (h *Handler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var request invitations.AcceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.invitationService.AcceptInvitation(r.Context(), request)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Invitation accepted successfully",
+		Data:    member,
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,165 @@
+package invitations_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	invitationHandlers "github.com/bpalazzi512/easy-ballot/backend/handlers/invitations"
+	"github.com/bpalazzi512/easy-ballot/backend/routes"
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/services/authz"
+	"github.com/bpalazzi512/easy-ballot/backend/services/invitations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/notifications"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/gorilla/mux"
+)
+
+// fixture names the organizations and invitations newRouter creates.
+type fixture struct {
+	organizationID      string
+	otherOrganizationID string
+	// invitationID belongs to the organization, otherInvitationID to the
+	// other one
+	invitationID      string
+	otherInvitationID string
+}
+
+// newRouter serves the invitation routes from in-memory storage holding two
+// organizations, each with one pending invitation. "admin" and "voter" hold
+// those roles in the first organization.
+func newRouter(t *testing.T) (*mux.Router, fixture) {
+	t.Helper()
+	ctx := context.Background()
+
+	templates, err := notifications.LoadTemplates()
+	if err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+
+	organizationRepository := organizations.NewMemoryOrganizationRepository()
+	membershipRepository := memberships.NewMemoryMembershipRepository()
+	userRepository := users.NewMemoryUserRepository(membershipRepository)
+	notifier := notifications.NewNotifier(notifications.NewMemoryMailer(), templates, organizationRepository, userRepository, "http://localhost")
+	invitationService := invitations.NewInvitationService(invitations.NewMemoryInvitationRepository(), users.NewUserService(userRepository, users.NewPasswordHasher(0)),
+		membershipRepository, organizationRepository, notifier, time.Hour, "http://localhost")
+
+	var f fixture
+	for _, organizationID := range []*string{&f.organizationID, &f.otherOrganizationID} {
+		organization, err := organizationRepository.CreateOrganization(ctx, organizations.Organization{Name: "Acme Corporation", OwnerUserID: "owner"})
+		if err != nil {
+			t.Fatalf("CreateOrganization: %v", err)
+		}
+		*organizationID = organization.ID
+	}
+	for userID, role := range map[string]users.UserRole{"admin": users.RoleAdmin, "voter": users.RoleVoter} {
+		if _, err := membershipRepository.CreateMembership(ctx, memberships.Membership{OrganizationID: f.organizationID, UserID: userID, Role: role}); err != nil {
+			t.Fatalf("CreateMembership: %v", err)
+		}
+	}
+	for organizationID, invitationID := range map[string]*string{f.organizationID: &f.invitationID, f.otherOrganizationID: &f.otherInvitationID} {
+		invitation, err := invitationService.CreateInvitation(ctx, organizationID, "owner", invitations.CreateInvitationRequest{Email: "ada@example.com", Role: users.RoleVoter})
+		if err != nil {
+			t.Fatalf("CreateInvitation: %v", err)
+		}
+		*invitationID = invitation.ID
+	}
+
+	handler := invitationHandlers.NewHandler(invitationService, authz.NewAuthorizer(organizationRepository, membershipRepository))
+	router := mux.NewRouter()
+	routes.RegisterInvitationRoutes(router, router, handler)
+	return router, f
+}
+
+func TestInvitationRoutes(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		method string
+		// path builds the request path from the fixture
+		path   func(f fixture) string
+		actor  string
+		body   string
+		status int
+	}{
+		{
+			name: "Create", method: http.MethodPost, actor: "admin", body: `{"email":"grace@example.com","role":"voter"}`, status: http.StatusCreated,
+			path: func(f fixture) string { return "/organizations/" + f.organizationID + "/invitations" },
+		},
+		{
+			name: "CreateAsVoter", method: http.MethodPost, actor: "voter", body: `{"email":"grace@example.com","role":"voter"}`, status: http.StatusForbidden,
+			path: func(f fixture) string { return "/organizations/" + f.organizationID + "/invitations" },
+		},
+		{
+			name: "CreateUnauthenticated", method: http.MethodPost, body: `{"email":"grace@example.com","role":"voter"}`, status: http.StatusUnauthorized,
+			path: func(f fixture) string { return "/organizations/" + f.organizationID + "/invitations" },
+		},
+		{
+			name: "CreateDuplicate", method: http.MethodPost, actor: "admin", body: `{"email":"ada@example.com","role":"voter"}`, status: http.StatusConflict,
+			path: func(f fixture) string { return "/organizations/" + f.organizationID + "/invitations" },
+		},
+		{
+			name: "CreateInvalid", method: http.MethodPost, actor: "admin", body: `{"email":"grace","role":"owner"}`, status: http.StatusUnprocessableEntity,
+			path: func(f fixture) string { return "/organizations/" + f.organizationID + "/invitations" },
+		},
+		{
+			name: "List", method: http.MethodGet, actor: "admin", status: http.StatusOK,
+			path: func(f fixture) string { return "/organizations/" + f.organizationID + "/invitations" },
+		},
+		{
+			name: "Resend", method: http.MethodPost, actor: "admin", status: http.StatusOK,
+			path: func(f fixture) string {
+				return "/organizations/" + f.organizationID + "/invitations/" + f.invitationID + "/resend"
+			},
+		},
+		{
+			name: "ResendOtherOrganization", method: http.MethodPost, actor: "admin", status: http.StatusNotFound,
+			path: func(f fixture) string {
+				return "/organizations/" + f.organizationID + "/invitations/" + f.otherInvitationID + "/resend"
+			},
+		},
+		{
+			name: "Revoke", method: http.MethodDelete, actor: "admin", status: http.StatusOK,
+			path: func(f fixture) string {
+				return "/organizations/" + f.organizationID + "/invitations/" + f.invitationID
+			},
+		},
+		{
+			name: "RevokeOtherOrganization", method: http.MethodDelete, actor: "admin", status: http.StatusNotFound,
+			path: func(f fixture) string {
+				return "/organizations/" + f.organizationID + "/invitations/" + f.otherInvitationID
+			},
+		},
+		{
+			name: "RevokeMissing", method: http.MethodDelete, actor: "admin", status: http.StatusNotFound,
+			path: func(f fixture) string { return "/organizations/" + f.organizationID + "/invitations/missing" },
+		},
+		{
+			name: "AcceptUnknownToken", method: http.MethodPost, body: `{"token":"not-a-token"}`, status: http.StatusNotFound,
+			path: func(f fixture) string { return "/invitations/accept" },
+		},
+		{
+			name: "AcceptInvalidJSON", method: http.MethodPost, body: `{`, status: http.StatusBadRequest,
+			path: func(f fixture) string { return "/invitations/accept" },
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router, f := newRouter(t)
+
+			r := httptest.NewRequest(tc.method, tc.path(f), strings.NewReader(tc.body))
+			if tc.actor != "" {
+				r = r.WithContext(auth.WithUser(r.Context(), &users.User{ID: tc.actor}))
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != tc.status {
+				t.Errorf("%s %s as %q responded with %d, want %d: %s", tc.method, r.URL.Path, tc.actor, w.Code, tc.status, w.Body)
+			}
+		})
+	}
+}
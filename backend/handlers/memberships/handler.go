@@ -0,0 +1,183 @@
+package memberships
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/services/authz"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/types"
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	membershipService *memberships.MembershipService
+	authorizer        *authz.Authorizer
+}
+
+func NewHandler(membershipService *memberships.MembershipService, authorizer *authz.Authorizer) *Handler {
+	return &Handler{
+		membershipService: membershipService,
+		authorizer:        authorizer,
+	}
+}
+
+func (h *Handler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	organizationID := vars["id"]
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		response := types.APIResponse{
+			Success: false,
+			Message: err.Error(),
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	if _, err := h.authorizer.AuthorizeRequest(r, organizationID, authz.ActionViewUsers); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	members, err := h.membershipService.ListMembers(r.Context(), organizationID, page)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success:    true,
+		Data:       members.Items,
+		NextCursor: members.NextCursor,
+		Total:      &members.Total,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) AddMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	organizationID := vars["id"]
+
+	if _, err := h.authorizer.AuthorizeRequest(r, organizationID, authz.ActionManageMembers); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	var request memberships.AddMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.membershipService.AddMember(r.Context(), organizationID, request)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Member added successfully",
+		Data:    member,
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	organizationID := vars["id"]
+	userID := vars["userId"]
+
+	actor, err := h.authorizer.AuthorizeRequest(r, organizationID, authz.ActionManageMembers)
+	if err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	// Nobody may change their own role
+	if actor.ID == userID {
+		authz.WriteError(w, authz.ErrForbidden)
+		return
+	}
+
+	var request memberships.UpdateMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.membershipService.UpdateMemberRole(r.Context(), organizationID, userID, request.Role); err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Member updated successfully",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	organizationID := vars["id"]
+	userID := vars["userId"]
+
+	// Members may always leave an organization themselves
+	actor, ok := auth.UserFromContext(r.Context())
+	if !ok || actor.ID != userID {
+		if _, err := h.authorizer.AuthorizeRequest(r, organizationID, authz.ActionManageMembers); err != nil {
+			authz.WriteError(w, err)
+			return
+		}
+	}
+
+	if err := h.membershipService.RemoveMember(r.Context(), organizationID, userID); err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Member removed successfully",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListMyMemberships lists the organizations the authenticated user belongs to.
+func (h *Handler) ListMyMemberships(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		authz.WriteError(w, authz.ErrUnauthenticated)
+		return
+	}
+
+	userMemberships, err := h.membershipService.ListMemberships(r.Context(), user.ID)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Data:    userMemberships,
+	}
+	json.NewEncoder(w).Encode(response)
+}
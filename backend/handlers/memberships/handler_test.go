@@ -0,0 +1,106 @@
+package memberships_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	membershipHandlers "github.com/bpalazzi512/easy-ballot/backend/handlers/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/routes"
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/services/authz"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/gorilla/mux"
+)
+
+// newRouter serves the membership routes from in-memory storage holding one
+// organization owned by "owner", with "admin" and "voter" as members.
+// "outsider" has an account but no membership.
+func newRouter(t *testing.T) (*mux.Router, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	organizationRepository := organizations.NewMemoryOrganizationRepository()
+	membershipRepository := memberships.NewMemoryMembershipRepository()
+	userRepository := users.NewMemoryUserRepository(membershipRepository)
+
+	for _, userID := range []string{"owner", "admin", "voter", "outsider"} {
+		if _, err := userRepository.CreateUser(ctx, users.User{ID: userID, FirstName: userID, LastName: "Example", Email: userID + "@example.com"}); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+
+	organization, err := organizationRepository.CreateOrganization(ctx, organizations.Organization{Name: "Acme Corporation", OwnerUserID: "owner"})
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	for userID, role := range map[string]users.UserRole{"owner": users.RoleOwner, "admin": users.RoleAdmin, "voter": users.RoleVoter} {
+		if _, err := membershipRepository.CreateMembership(ctx, memberships.Membership{OrganizationID: organization.ID, UserID: userID, Role: role}); err != nil {
+			t.Fatalf("CreateMembership: %v", err)
+		}
+	}
+
+	handler := membershipHandlers.NewHandler(
+		memberships.NewMembershipService(membershipRepository, userRepository, organizationRepository),
+		authz.NewAuthorizer(organizationRepository, membershipRepository),
+	)
+	router := mux.NewRouter()
+	routes.RegisterMembershipRoutes(router, handler)
+	return router, organization.ID
+}
+
+func TestMembershipRoutes(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		method string
+		// path is relative to the organization, or absolute if it starts
+		// with a slash
+		path   string
+		actor  string
+		body   string
+		status int
+	}{
+		{name: "ListAsVoter", method: http.MethodGet, path: "members", actor: "voter", status: http.StatusOK},
+		{name: "ListAsOutsider", method: http.MethodGet, path: "members", actor: "outsider", status: http.StatusForbidden},
+		{name: "ListUnauthenticated", method: http.MethodGet, path: "members", status: http.StatusUnauthorized},
+		{name: "Add", method: http.MethodPost, path: "members", actor: "admin", body: `{"user_id":"outsider","role":"voter"}`, status: http.StatusCreated},
+		{name: "AddByEmail", method: http.MethodPost, path: "members", actor: "admin", body: `{"email":"outsider@example.com","role":"voter"}`, status: http.StatusCreated},
+		{name: "AddAsVoter", method: http.MethodPost, path: "members", actor: "voter", body: `{"user_id":"outsider","role":"voter"}`, status: http.StatusForbidden},
+		{name: "AddExisting", method: http.MethodPost, path: "members", actor: "admin", body: `{"user_id":"voter","role":"voter"}`, status: http.StatusConflict},
+		{name: "AddUnknownUser", method: http.MethodPost, path: "members", actor: "admin", body: `{"user_id":"missing","role":"voter"}`, status: http.StatusNotFound},
+		{name: "AddOwnerRole", method: http.MethodPost, path: "members", actor: "admin", body: `{"user_id":"outsider","role":"owner"}`, status: http.StatusUnprocessableEntity},
+		{name: "Update", method: http.MethodPut, path: "members/voter", actor: "admin", body: `{"role":"election_officer"}`, status: http.StatusOK},
+		{name: "UpdateSelf", method: http.MethodPut, path: "members/admin", actor: "admin", body: `{"role":"owner"}`, status: http.StatusForbidden},
+		{name: "UpdateOwner", method: http.MethodPut, path: "members/owner", actor: "admin", body: `{"role":"voter"}`, status: http.StatusConflict},
+		{name: "UpdateNonMember", method: http.MethodPut, path: "members/outsider", actor: "admin", body: `{"role":"voter"}`, status: http.StatusNotFound},
+		{name: "Remove", method: http.MethodDelete, path: "members/voter", actor: "admin", status: http.StatusOK},
+		{name: "Leave", method: http.MethodDelete, path: "members/voter", actor: "voter", status: http.StatusOK},
+		{name: "RemoveOther", method: http.MethodDelete, path: "members/admin", actor: "voter", status: http.StatusForbidden},
+		{name: "RemoveOwner", method: http.MethodDelete, path: "members/owner", actor: "admin", status: http.StatusConflict},
+		{name: "ListMine", method: http.MethodGet, path: "/memberships", actor: "voter", status: http.StatusOK},
+		{name: "ListMineUnauthenticated", method: http.MethodGet, path: "/memberships", status: http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router, organizationID := newRouter(t)
+
+			path := tc.path
+			if !strings.HasPrefix(path, "/") {
+				path = "/organizations/" + organizationID + "/" + path
+			}
+			r := httptest.NewRequest(tc.method, path, strings.NewReader(tc.body))
+			if tc.actor != "" {
+				r = r.WithContext(auth.WithUser(r.Context(), &users.User{ID: tc.actor}))
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != tc.status {
+				t.Errorf("%s %s as %q responded with %d, want %d: %s", tc.method, tc.path, tc.actor, w.Code, tc.status, w.Body)
+			}
+		})
+	}
+}
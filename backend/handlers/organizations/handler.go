@@ -2,9 +2,17 @@ package organizations
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
-	"strconv"
+	"net/url"
+	"strings"
 
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/mergepatch"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/services/authz"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
 	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
 	"github.com/bpalazzi512/easy-ballot/backend/types"
 	"github.com/gorilla/mux"
@@ -12,11 +20,15 @@ import (
 
 type Handler struct {
 	organizationService *organizations.OrganizationService
+	membershipService   *memberships.MembershipService
+	authorizer          *authz.Authorizer
 }
 
-func NewHandler(organizationService *organizations.OrganizationService) *Handler {
+func NewHandler(organizationService *organizations.OrganizationService, membershipService *memberships.MembershipService, authorizer *authz.Authorizer) *Handler {
 	return &Handler{
 		organizationService: organizationService,
+		membershipService:   membershipService,
+		authorizer:          authorizer,
 	}
 }
 
@@ -29,20 +41,34 @@ func (h *Handler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.organizationService.CreateOrganization(r.Context(), organization); err != nil {
+	// Organizations are always owned by the user who creates them
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
 		response := types.APIResponse{
 			Success: false,
-			Message: err.Error(),
+			Message: authz.ErrUnauthenticated.Error(),
 		}
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(response)
 		return
 	}
+	organization.OwnerUserID = user.ID
+
+	createdOrganization, err := h.organizationService.CreateOrganization(r.Context(), organization)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	if err := h.membershipService.AddOwner(r.Context(), *createdOrganization); err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
 
 	response := types.APIResponse{
 		Success: true,
 		Message: "Organization created successfully",
-		Data:    organization,
+		Data:    createdOrganization,
 	}
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
@@ -56,12 +82,7 @@ func (h *Handler) GetOrganization(w http.ResponseWriter, r *http.Request) {
 
 	organization, err := h.organizationService.GetOrganizationByID(r.Context(), organizationID)
 	if err != nil {
-		response := types.APIResponse{
-			Success: false,
-			Message: err.Error(),
-		}
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(response)
+		apperr.WriteError(w, err)
 		return
 	}
 
@@ -75,25 +96,30 @@ func (h *Handler) GetOrganization(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetOrganizationsByOwner(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	ownerUserID := r.URL.Query().Get("owner_user_id")
-	if ownerUserID == "" {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
 		response := types.APIResponse{
 			Success: false,
-			Message: "owner_user_id query parameter is required",
+			Message: authz.ErrUnauthenticated.Error(),
 		}
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(response)
 		return
 	}
 
+	// Users may only list the organizations they own
+	ownerUserID := r.URL.Query().Get("owner_user_id")
+	if ownerUserID == "" {
+		ownerUserID = user.ID
+	}
+	if ownerUserID != user.ID {
+		authz.WriteError(w, authz.ErrForbidden)
+		return
+	}
+
 	organizations, err := h.organizationService.GetOrganizationsByOwner(r.Context(), ownerUserID)
 	if err != nil {
-		response := types.APIResponse{
-			Success: false,
-			Message: err.Error(),
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
+		apperr.WriteError(w, err)
 		return
 	}
 
@@ -110,6 +136,11 @@ func (h *Handler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	organizationID := vars["id"]
 
+	if _, err := h.authorizer.AuthorizeRequest(r, organizationID, authz.ActionManageOrganization); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
 	var organization organizations.Organization
 	if err := json.NewDecoder(r.Body).Decode(&organization); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
@@ -117,41 +148,104 @@ func (h *Handler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.organizationService.UpdateOrganization(r.Context(), organizationID, organization); err != nil {
-		response := types.APIResponse{
-			Success: false,
-			Message: err.Error(),
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Organization updated successfully",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// PatchOrganization applies a JSON Merge Patch to an organization. Admins may
+// change its details, but only the owner may transfer it to another member
+// by changing owner_user_id.
+func (h *Handler) PatchOrganization(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	organizationID := vars["id"]
+
+	actor, err := h.authorizer.AuthorizeRequest(r, organizationID, authz.ActionManageOrganization)
+	if err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	var patch organizations.OrganizationPatch
+	if err := mergepatch.Decode(r.Body, &patch); err != nil {
+		if errors.Is(err, apperr.ErrValidation) {
+			apperr.WriteError(w, err)
+			return
 		}
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(response)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	organization, err := h.organizationService.GetOrganizationByID(r.Context(), organizationID)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	transfer := patch.OwnerUserID.Set && patch.OwnerUserID.Value != organization.OwnerUserID
+	if transfer && actor.ID != organization.OwnerUserID {
+		apperr.WriteError(w, apperr.Forbidden("only the owner can transfer the organization"))
+		return
+	}
+
+	if err := organizations.ValidatePatch(patch); err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	if transfer {
+		if err := h.membershipService.TransferOwnership(r.Context(), *organization, patch.OwnerUserID.Value); err != nil {
+			apperr.WriteError(w, err)
+			return
+		}
+	}
+
+	updatedOrganization, err := h.organizationService.PatchOrganization(r.Context(), organizationID, patch)
+	if err != nil {
+		apperr.WriteError(w, err)
 		return
 	}
 
 	response := types.APIResponse{
 		Success: true,
 		Message: "Organization updated successfully",
+		Data:    updatedOrganization,
 	}
 	json.NewEncoder(w).Encode(response)
 }
 
+// DeleteOrganization soft-deletes an organization and responds with what was
+// archived along with it. It fails with 409 Conflict while any of the
+// organization's ballots is open or scheduled.
 func (h *Handler) DeleteOrganization(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	vars := mux.Vars(r)
 	organizationID := vars["id"]
 
-	if err := h.organizationService.DeleteOrganization(r.Context(), organizationID); err != nil {
-		response := types.APIResponse{
-			Success: false,
-			Message: err.Error(),
-		}
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(response)
+	if _, err := h.authorizer.AuthorizeRequest(r, organizationID, authz.ActionManageOrganization); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	report, err := h.organizationService.DeleteOrganization(r.Context(), organizationID)
+	if err != nil {
+		apperr.WriteError(w, err)
 		return
 	}
 
 	response := types.APIResponse{
 		Success: true,
 		Message: "Organization deleted successfully",
+		Data:    report,
 	}
 	json.NewEncoder(w).Encode(response)
 }
@@ -159,36 +253,61 @@ func (h *Handler) DeleteOrganization(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Get query parameters
-	limit := 10
-	offset := 0
+	query := r.URL.Query()
 
-	// Parse pagination parameters
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsedLimit, err := strconv.Atoi(l); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
-	}
-	if o := r.URL.Query().Get("offset"); o != "" {
-		if parsedOffset, err := strconv.Atoi(o); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
+	page, err := pagination.ParseQuery(query, "created_at", "name")
+	if err != nil {
+		response := types.APIResponse{
+			Success: false,
+			Message: err.Error(),
 		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response)
+		return
 	}
 
-	organizationList, err := h.organizationService.ListOrganizations(r.Context(), limit, offset)
+	filter, err := parseOrganizationFilter(query)
 	if err != nil {
 		response := types.APIResponse{
 			Success: false,
 			Message: err.Error(),
 		}
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(response)
 		return
 	}
 
+	organizationList, err := h.organizationService.ListOrganizations(r.Context(), filter, page)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
 	response := types.APIResponse{
-		Success: true,
-		Data:    organizationList,
+		Success:    true,
+		Data:       organizationList.Items,
+		NextCursor: organizationList.NextCursor,
+		Total:      &organizationList.Total,
 	}
 	json.NewEncoder(w).Encode(response)
 }
+
+// parseOrganizationFilter reads the search parameters of ListOrganizations: q
+// (text search over names), name (a prefix), and created_after and
+// created_before (RFC 3339 timestamps).
+func parseOrganizationFilter(query url.Values) (organizations.OrganizationFilter, error) {
+	filter := organizations.OrganizationFilter{
+		Search:     strings.TrimSpace(query.Get("q")),
+		NamePrefix: strings.TrimSpace(query.Get("name")),
+	}
+
+	var err error
+	if filter.CreatedAfter, err = pagination.ParseTime(query, "created_after"); err != nil {
+		return organizations.OrganizationFilter{}, err
+	}
+	if filter.CreatedBefore, err = pagination.ParseTime(query, "created_before"); err != nil {
+		return organizations.OrganizationFilter{}, err
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,133 @@
+package organizations_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	organizationHandlers "github.com/bpalazzi512/easy-ballot/backend/handlers/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/routes"
+	"github.com/bpalazzi512/easy-ballot/backend/services/archives"
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/services/authz"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/invitations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/gorilla/mux"
+)
+
+type stores struct {
+	organizations *organizations.MemoryOrganizationRepository
+	memberships   *memberships.MemoryMembershipRepository
+}
+
+// newRouter serves the organization routes from in-memory storage holding
+// one organization owned by "owner", with "admin" and "voter" as members.
+func newRouter(t *testing.T) (*mux.Router, stores, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	s := stores{
+		organizations: organizations.NewMemoryOrganizationRepository(),
+		memberships:   memberships.NewMemoryMembershipRepository(),
+	}
+	userRepository := users.NewMemoryUserRepository(s.memberships)
+	deletions := archives.NewMemoryArchiveRepository(s.organizations, s.memberships, invitations.NewMemoryInvitationRepository(), ballots.NewMemoryBallotRepository())
+
+	organization, err := s.organizations.CreateOrganization(ctx, organizations.Organization{Name: "Acme Corporation", OwnerUserID: "owner"})
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	for userID, role := range map[string]users.UserRole{"owner": users.RoleOwner, "admin": users.RoleAdmin, "voter": users.RoleVoter} {
+		if _, err := s.memberships.CreateMembership(ctx, memberships.Membership{OrganizationID: organization.ID, UserID: userID, Role: role}); err != nil {
+			t.Fatalf("CreateMembership: %v", err)
+		}
+	}
+
+	handler := organizationHandlers.NewHandler(
+		organizations.NewOrganizationService(s.organizations, deletions),
+		memberships.NewMembershipService(s.memberships, userRepository, s.organizations),
+		authz.NewAuthorizer(s.organizations, s.memberships),
+	)
+	router := mux.NewRouter()
+	routes.RegisterOrganizationRoutes(router, handler)
+	return router, s, organization.ID
+}
+
+// serve sends a request as the given user, or unauthenticated without one.
+func serve(router *mux.Router, method, target, userID, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if userID != "" {
+		r = r.WithContext(auth.WithUser(r.Context(), &users.User{ID: userID}))
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestOwnership(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		method string
+		actor  string
+		body   string
+		status int
+		// owner is who owns the organization afterwards
+		owner string
+	}{
+		{"AdminPutsThemselvesAsOwner", http.MethodPut, "admin", `{"name": "Acme", "owner_user_id": "admin"}`, http.StatusUnprocessableEntity, "owner"},
+		{"OwnerPutsNewOwner", http.MethodPut, "owner", `{"name": "Acme", "owner_user_id": "admin"}`, http.StatusUnprocessableEntity, "owner"},
+		{"AdminPutsSameOwner", http.MethodPut, "admin", `{"name": "Acme", "owner_user_id": "owner"}`, http.StatusOK, "owner"},
+		{"AdminPutsWithoutOwner", http.MethodPut, "admin", `{"name": "Acme"}`, http.StatusOK, "owner"},
+		{"VoterPuts", http.MethodPut, "voter", `{"name": "Acme"}`, http.StatusForbidden, "owner"},
+		{"OutsiderPuts", http.MethodPut, "outsider", `{"name": "Acme"}`, http.StatusForbidden, "owner"},
+		{"AnonymousPuts", http.MethodPut, "", `{"name": "Acme"}`, http.StatusUnauthorized, "owner"},
+		{"AdminPatchesThemselvesAsOwner", http.MethodPatch, "admin", `{"owner_user_id": "admin"}`, http.StatusForbidden, "owner"},
+		{"AdminPatchesName", http.MethodPatch, "admin", `{"name": "Acme"}`, http.StatusOK, "owner"},
+		{"OwnerTransfersToNonMember", http.MethodPatch, "owner", `{"owner_user_id": "outsider"}`, http.StatusUnprocessableEntity, "owner"},
+		{"OwnerTransfers", http.MethodPatch, "owner", `{"owner_user_id": "admin"}`, http.StatusOK, "admin"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router, s, organizationID := newRouter(t)
+			ctx := context.Background()
+
+			w := serve(router, tc.method, "/organizations/"+organizationID, tc.actor, tc.body)
+			if w.Code != tc.status {
+				t.Fatalf("got status %d with body %s, want %d", w.Code, w.Body, tc.status)
+			}
+
+			organization, err := s.organizations.GetOrganizationByID(ctx, organizationID)
+			if err != nil {
+				t.Fatalf("GetOrganizationByID: %v", err)
+			}
+			if organization.OwnerUserID != tc.owner {
+				t.Errorf("got owner %s, want %s", organization.OwnerUserID, tc.owner)
+			}
+
+			// The owner's membership always matches owner_user_id
+			roles := map[string]users.UserRole{"owner": users.RoleOwner, "admin": users.RoleAdmin}
+			if tc.owner == "admin" {
+				roles = map[string]users.UserRole{"owner": users.RoleAdmin, "admin": users.RoleOwner}
+			}
+			for userID, role := range roles {
+				membership, err := s.memberships.GetMembership(ctx, organizationID, userID)
+				if err != nil || membership.Role != role {
+					t.Errorf("membership of %s has role %v and error %v, want %s", userID, membership, err, role)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateOrganizationNotFound(t *testing.T) {
+	router, _, _ := newRouter(t)
+
+	w := serve(router, http.MethodPut, "/organizations/missing", "admin", `{"name": "Acme"}`)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("got status %d with body %s, want %d", w.Code, w.Body, http.StatusNotFound)
+	}
+}
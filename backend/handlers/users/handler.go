@@ -2,21 +2,38 @@ package users
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
-	"strconv"
+	"net/url"
+	"strings"
 
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/mergepatch"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/accounts"
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/services/authz"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
 	"github.com/bpalazzi512/easy-ballot/backend/services/users"
 	"github.com/bpalazzi512/easy-ballot/backend/types"
 	"github.com/gorilla/mux"
 )
 
 type Handler struct {
-	userService *users.UserService
+	userService       *users.UserService
+	accountService    *accounts.AccountService
+	membershipService *memberships.MembershipService
+	authorizer        *authz.Authorizer
 }
 
-func NewHandler(userService *users.UserService) *Handler {
+func NewHandler(userService *users.UserService, accountService *accounts.AccountService, membershipService *memberships.MembershipService, authorizer *authz.Authorizer) *Handler {
 	return &Handler{
-		userService: userService,
+		userService:       userService,
+		accountService:    accountService,
+		membershipService: membershipService,
+		authorizer:        authorizer,
 	}
 }
 
@@ -29,20 +46,21 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.userService.CreateUser(r.Context(), user); err != nil {
-		response := types.APIResponse{
-			Success: false,
-			Message: err.Error(),
-		}
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(response)
+	createdUser, err := h.userService.CreateUser(r.Context(), user)
+	if err != nil {
+		apperr.WriteError(w, err)
 		return
 	}
 
+	// The account is usable right away; a failed email can be resent later
+	if err := h.accountService.SendVerification(r.Context(), *createdUser); err != nil {
+		log.Printf("failed to send verification email to user %s: %v", createdUser.ID, err)
+	}
+
 	response := types.APIResponse{
 		Success: true,
 		Message: "User created successfully",
-		Data:    user,
+		Data:    createdUser,
 	}
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
@@ -54,14 +72,19 @@ func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userID := vars["id"]
 
+	// Users the actor may not view are reported as missing, so the response
+	// does not reveal which IDs exist
+	if err := h.authorizeUser(r, userID, authz.ActionViewUsers); err != nil {
+		if errors.Is(err, authz.ErrForbidden) {
+			err = apperr.NotFound("user not found")
+		}
+		authz.WriteError(w, err)
+		return
+	}
+
 	user, err := h.userService.GetUserByID(r.Context(), userID)
 	if err != nil {
-		response := types.APIResponse{
-			Success: false,
-			Message: err.Error(),
-		}
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(response)
+		apperr.WriteError(w, err)
 		return
 	}
 
@@ -78,25 +101,62 @@ func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userID := vars["id"]
 
-	var user users.User
+	if err := authorizeSelf(r, userID); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	var user users.UpdateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
 	if err := h.userService.UpdateUser(r.Context(), userID, user); err != nil {
-		response := types.APIResponse{
-			Success: false,
-			Message: err.Error(),
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "User updated successfully",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// PatchUser applies a JSON Merge Patch to the user's own account, changing
+// only the fields it contains.
+func (h *Handler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	userID := vars["id"]
+
+	if err := authorizeSelf(r, userID); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	var patch users.UserPatch
+	if err := mergepatch.Decode(r.Body, &patch); err != nil {
+		if errors.Is(err, apperr.ErrValidation) {
+			apperr.WriteError(w, err)
+			return
 		}
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(response)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	updatedUser, err := h.userService.PatchUser(r.Context(), userID, patch)
+	if err != nil {
+		apperr.WriteError(w, err)
 		return
 	}
 
 	response := types.APIResponse{
 		Success: true,
 		Message: "User updated successfully",
+		Data:    updatedUser,
 	}
 	json.NewEncoder(w).Encode(response)
 }
@@ -107,13 +167,13 @@ func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userID := vars["id"]
 
+	if err := authorizeSelf(r, userID); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
 	if err := h.userService.DeleteUser(r.Context(), userID); err != nil {
-		response := types.APIResponse{
-			Success: false,
-			Message: err.Error(),
-		}
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(response)
+		apperr.WriteError(w, err)
 		return
 	}
 
@@ -128,36 +188,133 @@ func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	// Get query parameters
-	organizationID := r.URL.Query().Get("organization_id")
-	limit := 10
-	offset := 0
-
-	// Parse pagination parameters (basic implementation)
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsedLimit, err := strconv.Atoi(l); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
-	}
-	if o := r.URL.Query().Get("offset"); o != "" {
-		if parsedOffset, err := strconv.Atoi(o); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
+	query := r.URL.Query()
+	organizationID := query.Get("organization_id")
+	role := users.UserRole(query.Get("role"))
+
+	page, err := pagination.ParseQuery(query, "created_at", "first_name", "last_name", "email")
+	if err != nil {
+		response := types.APIResponse{
+			Success: false,
+			Message: err.Error(),
 		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response)
+		return
 	}
 
-	userList, err := h.userService.ListUsers(r.Context(), organizationID, limit, offset)
+	filter, err := parseUserFilter(query)
 	if err != nil {
 		response := types.APIResponse{
 			Success: false,
 			Message: err.Error(),
 		}
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(response)
 		return
 	}
 
+	// Users can only list members of an organization they belong to
+	if organizationID == "" {
+		response := types.APIResponse{
+			Success: false,
+			Message: "organization_id is required",
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	if role != "" && !role.IsValid() {
+		response := types.APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("invalid role %s", role),
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	if _, err := h.authorizer.AuthorizeRequest(r, organizationID, authz.ActionViewUsers); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	filter.OrganizationID = organizationID
+	filter.Role = role
+
+	userList, err := h.userService.ListUsers(r.Context(), filter, page)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
 	response := types.APIResponse{
-		Success: true,
-		Data:    userList,
+		Success:    true,
+		Data:       userList.Items,
+		NextCursor: userList.NextCursor,
+		Total:      &userList.Total,
 	}
 	json.NewEncoder(w).Encode(response)
 }
+
+// parseUserFilter reads the search parameters of ListUsers: q (text search
+// over names), name and email (prefixes), and created_after and
+// created_before (RFC 3339 timestamps).
+func parseUserFilter(query url.Values) (users.UserFilter, error) {
+	filter := users.UserFilter{
+		Search:      strings.TrimSpace(query.Get("q")),
+		NamePrefix:  strings.TrimSpace(query.Get("name")),
+		EmailPrefix: strings.TrimSpace(query.Get("email")),
+	}
+
+	var err error
+	if filter.CreatedAfter, err = pagination.ParseTime(query, "created_after"); err != nil {
+		return users.UserFilter{}, err
+	}
+	if filter.CreatedBefore, err = pagination.ParseTime(query, "created_before"); err != nil {
+		return users.UserFilter{}, err
+	}
+
+	return filter, nil
+}
+
+// authorizeUser allows users to view their own account and otherwise requires
+// the action in an organization the target user belongs to.
+func (h *Handler) authorizeUser(r *http.Request, targetID string, action authz.Action) error {
+	actor, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		return authz.ErrUnauthenticated
+	}
+	if actor.ID == targetID {
+		return nil
+	}
+
+	targetMemberships, err := h.membershipService.ListMemberships(r.Context(), targetID)
+	if err != nil {
+		return err
+	}
+	for _, membership := range targetMemberships {
+		if err := h.authorizer.Authorize(r.Context(), actor, membership.OrganizationID, action); err == nil {
+			return nil
+		}
+	}
+
+	return authz.ErrForbidden
+}
+
+// authorizeSelf restricts changes to an account to its own user. Accounts can
+// be shared by several organizations, so no single organization's admins may
+// edit or delete them; they manage memberships instead. It checks only the ID,
+// so it can run before the account is looked up.
+func authorizeSelf(r *http.Request, targetID string) error {
+	actor, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		return authz.ErrUnauthenticated
+	}
+	if actor.ID != targetID {
+		return authz.ErrForbidden
+	}
+
+	return nil
+}
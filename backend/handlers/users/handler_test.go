@@ -0,0 +1,105 @@
+package users_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	userHandlers "github.com/bpalazzi512/easy-ballot/backend/handlers/users"
+	"github.com/bpalazzi512/easy-ballot/backend/routes"
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/services/authz"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/gorilla/mux"
+)
+
+// newRouter serves the user routes from in-memory storage holding the users
+// "admin" and "voter", members of one organization, and "stranger", who
+// belongs to none. It returns the router and the users' IDs by name.
+func newRouter(t *testing.T) (*mux.Router, map[string]string) {
+	t.Helper()
+	ctx := context.Background()
+
+	organizationRepository := organizations.NewMemoryOrganizationRepository()
+	membershipRepository := memberships.NewMemoryMembershipRepository()
+	userRepository := users.NewMemoryUserRepository(membershipRepository)
+
+	ids := make(map[string]string)
+	for _, name := range []string{"admin", "voter", "stranger"} {
+		user, err := userRepository.CreateUser(ctx, users.User{FirstName: name, LastName: "Example", Email: name + "@example.com"})
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		ids[name] = user.ID
+	}
+
+	organization, err := organizationRepository.CreateOrganization(ctx, organizations.Organization{Name: "Acme Corporation", OwnerUserID: ids["admin"]})
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	for name, role := range map[string]users.UserRole{"admin": users.RoleOwner, "voter": users.RoleVoter} {
+		if _, err := membershipRepository.CreateMembership(ctx, memberships.Membership{OrganizationID: organization.ID, UserID: ids[name], Role: role}); err != nil {
+			t.Fatalf("CreateMembership: %v", err)
+		}
+	}
+
+	handler := userHandlers.NewHandler(
+		users.NewUserService(userRepository, users.NewPasswordHasher(0)),
+		nil,
+		memberships.NewMembershipService(membershipRepository, userRepository, organizationRepository),
+		authz.NewAuthorizer(organizationRepository, membershipRepository),
+	)
+	router := mux.NewRouter()
+	routes.RegisterUserRoutes(router, router, handler)
+	return router, ids
+}
+
+func TestUserAccess(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		method string
+		actor  string
+		// target names a user, or is used as the ID itself when it names none
+		target string
+		body   string
+		status int
+	}{
+		{name: "GetSelf", method: http.MethodGet, actor: "stranger", target: "stranger", status: http.StatusOK},
+		{name: "GetFellowMember", method: http.MethodGet, actor: "voter", target: "admin", status: http.StatusOK},
+		{name: "GetOutsider", method: http.MethodGet, actor: "admin", target: "stranger", status: http.StatusNotFound},
+		{name: "GetMissing", method: http.MethodGet, actor: "admin", target: "missing", status: http.StatusNotFound},
+		{name: "GetUnauthenticated", method: http.MethodGet, target: "admin", status: http.StatusUnauthorized},
+		{name: "PatchSelf", method: http.MethodPatch, actor: "voter", target: "voter", body: `{"first_name":"Vera"}`, status: http.StatusOK},
+		{name: "PatchOther", method: http.MethodPatch, actor: "admin", target: "voter", body: `{"first_name":"Vera"}`, status: http.StatusForbidden},
+		{name: "PatchMissing", method: http.MethodPatch, actor: "admin", target: "missing", body: `{"first_name":"Vera"}`, status: http.StatusForbidden},
+		{name: "PutOther", method: http.MethodPut, actor: "admin", target: "voter", body: `{}`, status: http.StatusForbidden},
+		{name: "PutMissing", method: http.MethodPut, actor: "admin", target: "missing", body: `{}`, status: http.StatusForbidden},
+		{name: "DeleteOther", method: http.MethodDelete, actor: "admin", target: "voter", status: http.StatusForbidden},
+		{name: "DeleteMissing", method: http.MethodDelete, actor: "admin", target: "missing", status: http.StatusForbidden},
+		{name: "DeleteSelf", method: http.MethodDelete, actor: "stranger", target: "stranger", status: http.StatusOK},
+		{name: "DeleteUnauthenticated", method: http.MethodDelete, target: "voter", status: http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router, ids := newRouter(t)
+
+			target, ok := ids[tc.target]
+			if !ok {
+				target = tc.target
+			}
+			r := httptest.NewRequest(tc.method, "/users/"+target, strings.NewReader(tc.body))
+			if tc.actor != "" {
+				r = r.WithContext(auth.WithUser(r.Context(), &users.User{ID: ids[tc.actor]}))
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != tc.status {
+				t.Errorf("%s /users/%s as %q responded with %d, want %d: %s", tc.method, tc.target, tc.actor, w.Code, tc.status, w.Body)
+			}
+		})
+	}
+}
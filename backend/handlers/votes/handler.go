@@ -0,0 +1,186 @@
+package votes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/services/authz"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/votes"
+	"github.com/bpalazzi512/easy-ballot/backend/types"
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	voteService   *votes.VoteService
+	ballotService *ballots.BallotService
+	authorizer    *authz.Authorizer
+}
+
+func NewHandler(voteService *votes.VoteService, ballotService *ballots.BallotService, authorizer *authz.Authorizer) *Handler {
+	return &Handler{
+		voteService:   voteService,
+		ballotService: ballotService,
+		authorizer:    authorizer,
+	}
+}
+
+func (h *Handler) CastVote(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	ballotID := vars["id"]
+
+	ballot, err := h.ballotService.GetBallotByID(r.Context(), ballotID)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	voter, err := h.authorizer.AuthorizeRequest(r, ballot.OrganizationID, authz.ActionVote)
+	if err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	var vote votes.CastVoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&vote); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	createdVote, err := h.voteService.CastVote(r.Context(), ballotID, voter.ID, vote)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Message: "Vote cast successfully",
+		Data:    createdVote,
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) GetResults(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	ballotID := vars["id"]
+
+	ballot, err := h.ballotService.GetBallotByID(r.Context(), ballotID)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	if _, err := h.authorizer.AuthorizeRequest(r, ballot.OrganizationID, authz.ActionViewBallots); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	results, err := h.voteService.Results(r.Context(), ballotID)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Data:    results,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) GetTurnout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	ballotID := vars["id"]
+
+	ballot, err := h.ballotService.GetBallotByID(r.Context(), ballotID)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	if _, err := h.authorizer.AuthorizeRequest(r, ballot.OrganizationID, authz.ActionViewTurnout); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	turnout, err := h.voteService.Turnout(r.Context(), ballotID)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Data:    turnout,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) GetReceipts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	ballotID := vars["id"]
+
+	ballot, err := h.ballotService.GetBallotByID(r.Context(), ballotID)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	if _, err := h.authorizer.AuthorizeRequest(r, ballot.OrganizationID, authz.ActionViewBallots); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	receipts, err := h.voteService.Receipts(r.Context(), ballotID)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Data:    receipts,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) CheckReceipt(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	ballotID := vars["id"]
+
+	ballot, err := h.ballotService.GetBallotByID(r.Context(), ballotID)
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	if _, err := h.authorizer.AuthorizeRequest(r, ballot.OrganizationID, authz.ActionViewBallots); err != nil {
+		authz.WriteError(w, err)
+		return
+	}
+
+	check, err := h.voteService.CheckReceipt(r.Context(), ballotID, vars["code"])
+	if err != nil {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: true,
+		Data:    check,
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,138 @@
+package votes_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	voteHandlers "github.com/bpalazzi512/easy-ballot/backend/handlers/votes"
+	"github.com/bpalazzi512/easy-ballot/backend/routes"
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/services/authz"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/rolls"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/bpalazzi512/easy-ballot/backend/services/votes"
+	"github.com/gorilla/mux"
+)
+
+// newRouter serves the vote routes from in-memory storage holding one
+// organization with "officer", "voter" and "observer" as members, an open
+// ballot every member may vote on and a closed one. "outsider" belongs to no
+// organization.
+func newRouter(t *testing.T) (*mux.Router, *strings.Replacer) {
+	t.Helper()
+	ctx := context.Background()
+
+	organizationRepository := organizations.NewMemoryOrganizationRepository()
+	membershipRepository := memberships.NewMemoryMembershipRepository()
+	ballotRepository := ballots.NewMemoryBallotRepository()
+
+	organization, err := organizationRepository.CreateOrganization(ctx, organizations.Organization{Name: "Acme Corporation", OwnerUserID: "owner"})
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	for userID, role := range map[string]users.UserRole{"officer": users.RoleOfficer, "voter": users.RoleVoter, "observer": users.RoleObserver} {
+		if _, err := membershipRepository.CreateMembership(ctx, memberships.Membership{OrganizationID: organization.ID, UserID: userID, Role: role}); err != nil {
+			t.Fatalf("CreateMembership: %v", err)
+		}
+	}
+	// Members who join after a ballot opens are left off its roll
+	time.Sleep(2 * time.Millisecond)
+
+	now := time.Now()
+	ballotIDs := make(map[string]string)
+	for name, opensAt := range map[string]time.Time{"open": now, "closed": now.Add(-2 * time.Hour)} {
+		ballot, err := ballotRepository.CreateBallot(ctx, ballots.Ballot{
+			OrganizationID: organization.ID,
+			Title:          "Committee chair",
+			OpensAt:        opensAt,
+			ClosesAt:       opensAt.Add(time.Hour),
+			Questions: []ballots.Question{{
+				ID:            "q1",
+				Prompt:        "Who should chair the committee?",
+				Options:       []ballots.Option{{ID: "a", Label: "Ada"}, {ID: "b", Label: "Grace"}},
+				MaxSelections: 1,
+			}},
+			Eligibility: ballots.Eligibility{Mode: ballots.EligibilityAllMembers},
+		})
+		if err != nil {
+			t.Fatalf("CreateBallot: %v", err)
+		}
+		ballotIDs[name] = ballot.ID
+	}
+
+	voteService := votes.NewVoteService(votes.NewMemoryVoteRepository(), votes.NewMemoryBallotBoxRepository(), ballotRepository,
+		rolls.NewRollService(rolls.NewMemoryRollRepository(), ballotRepository, membershipRepository))
+	handler := voteHandlers.NewHandler(voteService, ballots.NewBallotService(ballotRepository, voteService), authz.NewAuthorizer(organizationRepository, membershipRepository))
+	router := mux.NewRouter()
+	routes.RegisterVoteRoutes(router, handler)
+	return router, strings.NewReplacer("{open}", ballotIDs["open"], "{closed}", ballotIDs["closed"])
+}
+
+func TestVoteRoutes(t *testing.T) {
+	vote := `{"selections":[{"question_id":"q1","option_ids":["a"]}]}`
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		// path may refer to the {open} and {closed} ballots
+		path   string
+		actor  string
+		body   string
+		status int
+	}{
+		{name: "Cast", method: http.MethodPost, path: "/ballots/{open}/votes", actor: "voter", body: vote, status: http.StatusCreated},
+		{name: "CastAsObserver", method: http.MethodPost, path: "/ballots/{open}/votes", actor: "observer", body: vote, status: http.StatusForbidden},
+		{name: "CastAsOutsider", method: http.MethodPost, path: "/ballots/{open}/votes", actor: "outsider", body: vote, status: http.StatusForbidden},
+		{name: "CastUnauthenticated", method: http.MethodPost, path: "/ballots/{open}/votes", body: vote, status: http.StatusUnauthorized},
+		{name: "CastOnClosed", method: http.MethodPost, path: "/ballots/{closed}/votes", actor: "voter", body: vote, status: http.StatusForbidden},
+		{name: "CastOnUnknown", method: http.MethodPost, path: "/ballots/missing/votes", actor: "voter", body: vote, status: http.StatusNotFound},
+		{name: "CastInvalidJSON", method: http.MethodPost, path: "/ballots/{open}/votes", actor: "voter", body: "{", status: http.StatusBadRequest},
+		{name: "CastUnknownOption", method: http.MethodPost, path: "/ballots/{open}/votes", actor: "voter", body: `{"selections":[{"question_id":"q1","option_ids":["c"]}]}`, status: http.StatusUnprocessableEntity},
+		{name: "ResultsWhileOpen", method: http.MethodGet, path: "/ballots/{open}/results", actor: "voter", status: http.StatusForbidden},
+		{name: "Results", method: http.MethodGet, path: "/ballots/{closed}/results", actor: "observer", status: http.StatusOK},
+		{name: "ResultsAsOutsider", method: http.MethodGet, path: "/ballots/{closed}/results", actor: "outsider", status: http.StatusForbidden},
+		{name: "Turnout", method: http.MethodGet, path: "/ballots/{open}/turnout", actor: "officer", status: http.StatusOK},
+		{name: "TurnoutAsVoter", method: http.MethodGet, path: "/ballots/{open}/turnout", actor: "voter", status: http.StatusForbidden},
+		{name: "ReceiptsWhileOpen", method: http.MethodGet, path: "/ballots/{open}/receipts", actor: "voter", status: http.StatusForbidden},
+		{name: "Receipts", method: http.MethodGet, path: "/ballots/{closed}/receipts", actor: "voter", status: http.StatusOK},
+		{name: "CheckReceipt", method: http.MethodGet, path: "/ballots/{closed}/receipts/abc123", actor: "voter", status: http.StatusOK},
+		{name: "CheckReceiptAsOutsider", method: http.MethodGet, path: "/ballots/{closed}/receipts/abc123", actor: "outsider", status: http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router, replacer := newRouter(t)
+
+			r := httptest.NewRequest(tc.method, replacer.Replace(tc.path), strings.NewReader(tc.body))
+			if tc.actor != "" {
+				r = r.WithContext(auth.WithUser(r.Context(), &users.User{ID: tc.actor}))
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != tc.status {
+				t.Errorf("%s %s as %q responded with %d, want %d: %s", tc.method, tc.path, tc.actor, w.Code, tc.status, w.Body)
+			}
+		})
+	}
+}
+
+func TestVoteTwice(t *testing.T) {
+	router, replacer := newRouter(t)
+
+	for _, status := range []int{http.StatusCreated, http.StatusConflict} {
+		r := httptest.NewRequest(http.MethodPost, replacer.Replace("/ballots/{open}/votes"), strings.NewReader(`{"selections":[{"question_id":"q1","option_ids":["b"]}]}`))
+		r = r.WithContext(auth.WithUser(r.Context(), &users.User{ID: "voter"}))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != status {
+			t.Errorf("voting responded with %d, want %d: %s", w.Code, status, w.Body)
+		}
+	}
+}
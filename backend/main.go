@@ -2,48 +2,269 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/bpalazzi512/easy-ballot/backend/config"
+	authHandler "github.com/bpalazzi512/easy-ballot/backend/handlers/auth"
+	ballotHandler "github.com/bpalazzi512/easy-ballot/backend/handlers/ballots"
+	invitationHandler "github.com/bpalazzi512/easy-ballot/backend/handlers/invitations"
+	membershipHandler "github.com/bpalazzi512/easy-ballot/backend/handlers/memberships"
 	organizationHandler "github.com/bpalazzi512/easy-ballot/backend/handlers/organizations"
 	userHandler "github.com/bpalazzi512/easy-ballot/backend/handlers/users"
+	voteHandler "github.com/bpalazzi512/easy-ballot/backend/handlers/votes"
+	"github.com/bpalazzi512/easy-ballot/backend/migrations"
 	"github.com/bpalazzi512/easy-ballot/backend/routes"
+	"github.com/bpalazzi512/easy-ballot/backend/services/accounts"
+	"github.com/bpalazzi512/easy-ballot/backend/services/archives"
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/services/authz"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/invitations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/notifications"
 	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/rolls"
 	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/bpalazzi512/easy-ballot/backend/services/votes"
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func main() {
 	dbConfig := config.GetDatabaseConfig()
+	appConfig := config.GetAppConfig()
 
-	// Connect to MongoDB
-	client, db, err := config.ConnectMongoDB(dbConfig)
+	var repos *repositories
+	switch dbConfig.Backend {
+	case config.StorageBackendMongoDB:
+		// Connect to MongoDB
+		client, db, err := config.ConnectMongoDB(dbConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer client.Disconnect(context.Background())
+
+		// "migrate" applies pending migrations and exits; "migrate status" lists them
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			status := func(ctx context.Context) ([]migrations.State, error) { return migrations.Status(ctx, db) }
+			run := func(ctx context.Context) error { return migrations.Run(ctx, db) }
+			if err := runMigrateCommand(context.Background(), status, run, os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		if appConfig.AutoMigrate {
+			if err := migrations.Run(context.Background(), db); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		repos = newMongoDBRepositories(db)
+	case config.StorageBackendPostgres, config.StorageBackendSQLite:
+		db, err := config.ConnectSQL(dbConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			status := func(ctx context.Context) ([]migrations.State, error) { return migrations.SQLStatus(ctx, db) }
+			run := func(ctx context.Context) error { return migrations.RunSQL(ctx, db) }
+			if err := runMigrateCommand(context.Background(), status, run, os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		if appConfig.AutoMigrate {
+			if err := migrations.RunSQL(context.Background(), db); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		repos = newSQLRepositories(db)
+	case config.StorageBackendMemory:
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			log.Fatal("in-memory storage has no migrations to run")
+		}
+
+		log.Println("Using in-memory storage; all data is lost when the server stops")
+		repos = newMemoryRepositories()
+	default:
+		log.Fatalf("unsupported storage backend %s", dbConfig.Backend)
+	}
+
+	securityConfig := config.GetSecurityConfig()
+	passwordHasher := users.NewPasswordHasher(securityConfig.PasswordHashCost)
+
+	userService := users.NewUserService(repos.users, passwordHasher)
+	authService := auth.NewAuthService(userService, securityConfig.TokenSecret, securityConfig.AccessTokenTTL, securityConfig.RefreshTokenTTL)
+
+	organizationService := organizations.NewOrganizationService(repos.organizations, repos.archives)
+
+	membershipService := memberships.NewMembershipService(repos.memberships, repos.users, repos.organizations)
+
+	mailer, err := newMailer(config.GetMailConfig())
+	if err != nil {
+		log.Fatal(err)
+	}
+	templates, err := notifications.LoadTemplates()
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer client.Disconnect(context.Background())
+	notifier := notifications.NewNotifier(mailer, templates, repos.organizations, repos.users, appConfig.BaseURL)
+
+	accountEmailLimiter := accounts.NewRateLimiter(securityConfig.AccountEmailLimit, securityConfig.AccountEmailWindow)
+	accountService := accounts.NewAccountService(repos.accountTokens, userService, notifier, accountEmailLimiter, securityConfig.PasswordResetTTL, securityConfig.VerificationTTL, appConfig.BaseURL)
+	authHandler := authHandler.NewHandler(authService, accountService)
+
+	authorizer := authz.NewAuthorizer(repos.organizations, repos.memberships)
+	userHandler := userHandler.NewHandler(userService, accountService, membershipService, authorizer)
+	organizationHandler := organizationHandler.NewHandler(organizationService, membershipService, authorizer)
+	membershipHandler := membershipHandler.NewHandler(membershipService, authorizer)
 
-	userCollection := db.Collection("users")
-	userRepo := users.NewMongoDBUserRepository(userCollection)
+	invitationService := invitations.NewInvitationService(repos.invitations, userService, repos.memberships, repos.organizations, notifier, securityConfig.InvitationTTL, appConfig.BaseURL)
+	invitationHandler := invitationHandler.NewHandler(invitationService, authorizer)
 
-	userService := users.NewUserService(userRepo)
-	userHandler := userHandler.NewHandler(userService)
+	rollService := rolls.NewRollService(repos.rolls, repos.ballots, repos.memberships)
+	voteService := votes.NewVoteService(repos.votes, repos.ballotBox, repos.ballots, rollService)
 
-	organizationCollection := db.Collection("organizations")
-	organizationRepo := organizations.NewMongoDBOrganizationRepository(organizationCollection)
+	ballotService := ballots.NewBallotService(repos.ballots, voteService)
+	ballotHandler := ballotHandler.NewHandler(ballotService, rollService, notifier, authorizer)
 
-	organizationService := organizations.NewOrganizationService(organizationRepo)
-	organizationHandler := organizationHandler.NewHandler(organizationService)
+	voteHandler := voteHandler.NewHandler(voteService, ballotService, authorizer)
 
 	// Setup router with middleware
 	router := routes.SetupRouter()
 
+	// Routes registered on the protected subrouter require a bearer token
+	protected := router.NewRoute().Subrouter()
+	protected.Use(routes.AuthMiddleware(authService))
+
 	// Register all route groups
-	routes.RegisterUserRoutes(router, userHandler)
-	routes.RegisterOrganizationRoutes(router, organizationHandler)
+	routes.RegisterAuthRoutes(router, protected, authHandler)
+	routes.RegisterUserRoutes(router, protected, userHandler)
+	routes.RegisterOrganizationRoutes(protected, organizationHandler)
+	routes.RegisterMembershipRoutes(protected, membershipHandler)
+	routes.RegisterInvitationRoutes(router, protected, invitationHandler)
+	routes.RegisterBallotRoutes(protected, ballotHandler)
+	routes.RegisterVoteRoutes(protected, voteHandler)
 
 	// Start server
 	port := ":8080"
 	log.Printf("Server starting on http://localhost%s", port)
 	log.Fatal(http.ListenAndServe(port, router))
 }
+
+// repositories holds the storage behind every service, chosen by
+// STORAGE_BACKEND.
+type repositories struct {
+	users         users.UserRepository
+	organizations organizations.OrganizationRepository
+	memberships   memberships.MembershipRepository
+	// accountTokens holds password reset and email verification links
+	accountTokens accounts.TokenRepository
+	invitations   invitations.InvitationRepository
+	ballots       ballots.BallotRepository
+	rolls         rolls.RollRepository
+	votes         votes.VoteRepository
+	// ballotBox records participation and anonymized votes on secret ballots
+	// separately
+	ballotBox votes.BallotBoxRepository
+	// archives deletes organizations, archiving their members and ballots
+	archives organizations.DeletionRepository
+}
+
+func newMongoDBRepositories(db *mongo.Database) *repositories {
+	return &repositories{
+		users:         users.NewMongoDBUserRepository(db.Collection("users")),
+		organizations: organizations.NewMongoDBOrganizationRepository(db.Collection("organizations")),
+		memberships:   memberships.NewMongoDBMembershipRepository(db.Collection("memberships")),
+		accountTokens: accounts.NewMongoDBTokenRepository(db.Collection("account_tokens")),
+		invitations:   invitations.NewMongoDBInvitationRepository(db.Collection("invitations")),
+		ballots:       ballots.NewMongoDBBallotRepository(db.Collection("ballots")),
+		rolls:         rolls.NewMongoDBRollRepository(db.Collection("voter_rolls")),
+		votes:         votes.NewMongoDBVoteRepository(db.Collection("votes")),
+		ballotBox:     votes.NewMongoDBBallotBoxRepository(db.Collection("participations"), db.Collection("ballot_box")),
+		archives:      archives.NewMongoDBArchiveRepository(db),
+	}
+}
+
+func newSQLRepositories(db *sqldb.DB) *repositories {
+	return &repositories{
+		users:         users.NewSQLUserRepository(db),
+		organizations: organizations.NewSQLOrganizationRepository(db),
+		memberships:   memberships.NewSQLMembershipRepository(db),
+		accountTokens: accounts.NewSQLTokenRepository(db),
+		invitations:   invitations.NewSQLInvitationRepository(db),
+		ballots:       ballots.NewSQLBallotRepository(db),
+		rolls:         rolls.NewSQLRollRepository(db),
+		votes:         votes.NewSQLVoteRepository(db),
+		ballotBox:     votes.NewSQLBallotBoxRepository(db),
+		archives:      archives.NewSQLArchiveRepository(db),
+	}
+}
+
+func newMemoryRepositories() *repositories {
+	membershipRepository := memberships.NewMemoryMembershipRepository()
+	repos := &repositories{
+		users:         users.NewMemoryUserRepository(membershipRepository),
+		organizations: organizations.NewMemoryOrganizationRepository(),
+		memberships:   membershipRepository,
+		accountTokens: accounts.NewMemoryTokenRepository(),
+		invitations:   invitations.NewMemoryInvitationRepository(),
+		ballots:       ballots.NewMemoryBallotRepository(),
+		rolls:         rolls.NewMemoryRollRepository(),
+		votes:         votes.NewMemoryVoteRepository(),
+		ballotBox:     votes.NewMemoryBallotBoxRepository(),
+	}
+	repos.archives = archives.NewMemoryArchiveRepository(repos.organizations, repos.memberships, repos.invitations, repos.ballots)
+	return repos
+}
+
+// newMailer builds the mailer selected by MAIL_DRIVER.
+func newMailer(mailConfig *config.MailConfig) (notifications.Mailer, error) {
+	switch mailConfig.Driver {
+	case config.MailDriverSMTP:
+		return notifications.NewSMTPMailer(mailConfig.SMTPHost, mailConfig.SMTPPort, mailConfig.SMTPUsername, mailConfig.SMTPPassword, mailConfig.From), nil
+	case config.MailDriverFile:
+		return notifications.NewFileMailer(mailConfig.Directory, mailConfig.From)
+	case config.MailDriverMemory:
+		return notifications.NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail driver %s", mailConfig.Driver)
+	}
+}
+
+// runMigrateCommand runs the migrate command against the migrations of the
+// configured storage backend.
+func runMigrateCommand(ctx context.Context, status func(context.Context) ([]migrations.State, error), run func(context.Context) error, args []string) error {
+	if len(args) > 0 && args[0] == "status" {
+		states, err := status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, state := range states {
+			applied := "pending"
+			if state.AppliedAt != nil {
+				applied = "applied " + state.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%3d  %-60s  %s\n", state.Version, state.Description, applied)
+		}
+		return nil
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("unknown migrate command %s; use \"migrate\" or \"migrate status\"", args[0])
+	}
+
+	if err := run(ctx); err != nil {
+		return err
+	}
+	log.Println("Migrations are up to date")
+	return nil
+}
@@ -0,0 +1,76 @@
+// Package mergepatch decodes JSON Merge Patch (RFC 7396) documents, which
+// change only the members they contain and clear the ones set to null.
+package mergepatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/bpalazzi512/easy-ballot/backend/validation"
+)
+
+// Field is a member of a patch. It tells a member that is absent, which
+// leaves a field unchanged, from one that is null, which clears it.
+type Field[T any] struct {
+	// Set reports whether the member was present, even if null
+	Set   bool
+	Null  bool
+	Value T
+}
+
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	if string(data) == "null" {
+		f.Null = true
+		var zero T
+		f.Value = zero
+		return nil
+	}
+	return json.Unmarshal(data, &f.Value)
+}
+
+// Decode reads a patch object into dst, a pointer to a struct of Fields.
+// Members dst has no field for, such as read-only ones like created_at, and
+// members of the wrong type are rejected as validation errors. Malformed JSON
+// and patches that are not objects return other errors.
+func Decode(r io.Reader, dst any) error {
+	var members map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&members); err != nil {
+		return fmt.Errorf("invalid merge patch: %w", err)
+	}
+	if members == nil {
+		return fmt.Errorf("invalid merge patch: must be a JSON object")
+	}
+
+	target := reflect.ValueOf(dst).Elem()
+	fields := fieldIndexes(target.Type())
+
+	var v validation.Validator
+	for name, raw := range members {
+		index, ok := fields[name]
+		if !ok {
+			v.Add(name, "cannot be changed")
+			continue
+		}
+		if err := json.Unmarshal(raw, target.Field(index).Addr().Interface()); err != nil {
+			v.Add(name, "has the wrong type")
+		}
+	}
+
+	return v.Err()
+}
+
+// fieldIndexes maps the JSON names of a struct's fields to their indexes.
+func fieldIndexes(t reflect.Type) map[string]int {
+	indexes := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			indexes[name] = i
+		}
+	}
+	return indexes
+}
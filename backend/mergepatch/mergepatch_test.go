@@ -0,0 +1,118 @@
+package mergepatch_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/mergepatch"
+)
+
+type patch struct {
+	Name        mergepatch.Field[string]   `json:"name"`
+	Description mergepatch.Field[string]   `json:"description,omitempty"`
+	Seats       mergepatch.Field[int]      `json:"seats"`
+	Tags        mergepatch.Field[[]string] `json:"tags"`
+	Internal    mergepatch.Field[string]   `json:"-"`
+}
+
+func TestDecode(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		body string
+		want patch
+	}{
+		{
+			name: "Absent",
+			body: `{}`,
+		},
+		{
+			name: "Null",
+			body: `{"name": null, "seats": null, "tags": null}`,
+			want: patch{
+				Name:  mergepatch.Field[string]{Set: true, Null: true},
+				Seats: mergepatch.Field[int]{Set: true, Null: true},
+				Tags:  mergepatch.Field[[]string]{Set: true, Null: true},
+			},
+		},
+		{
+			name: "Set",
+			body: `{"name": "Board", "description": "", "seats": 0, "tags": ["annual"]}`,
+			want: patch{
+				Name:        mergepatch.Field[string]{Set: true, Value: "Board"},
+				Description: mergepatch.Field[string]{Set: true},
+				Seats:       mergepatch.Field[int]{Set: true},
+				Tags:        mergepatch.Field[[]string]{Set: true, Value: []string{"annual"}},
+			},
+		},
+		{
+			name: "Mixed",
+			body: `{"name": "Board", "description": null}`,
+			want: patch{
+				Name:        mergepatch.Field[string]{Set: true, Value: "Board"},
+				Description: mergepatch.Field[string]{Set: true, Null: true},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got patch
+			if err := mergepatch.Decode(strings.NewReader(tc.body), &got); err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Decode returned %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecodeNullClearsValue(t *testing.T) {
+	got := patch{Name: mergepatch.Field[string]{Value: "Board"}}
+	if err := mergepatch.Decode(strings.NewReader(`{"name": null}`), &got); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if want := (mergepatch.Field[string]{Set: true, Null: true}); got.Name != want {
+		t.Errorf("Decode returned %+v, want %+v", got.Name, want)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		body   string
+		fields map[string][]string
+	}{
+		{
+			name: "UnknownAndWrongTypes",
+			body: `{"name": 42, "seats": "three", "created_at": "2024-03-01T00:00:00Z", "Internal": "x"}`,
+			fields: map[string][]string{
+				"name":       {"has the wrong type"},
+				"seats":      {"has the wrong type"},
+				"created_at": {"cannot be changed"},
+				"Internal":   {"cannot be changed"},
+			},
+		},
+		{name: "MalformedJSON", body: `{"name": `},
+		{name: "Array", body: `[{"name": "Board"}]`},
+		{name: "Null", body: `null`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got patch
+			err := mergepatch.Decode(strings.NewReader(tc.body), &got)
+			if err == nil {
+				t.Fatalf("Decode returned %+v, want an error", got)
+			}
+			if tc.fields == nil {
+				if errors.Is(err, apperr.ErrValidation) {
+					t.Errorf("Decode returned validation error %v, want a malformed patch error", err)
+				}
+				return
+			}
+			if fields := apperr.Fields(err); !reflect.DeepEqual(fields, tc.fields) {
+				t.Errorf("Decode returned fields %v, want %v", fields, tc.fields)
+			}
+		})
+	}
+}
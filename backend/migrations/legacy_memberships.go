@@ -0,0 +1,121 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// legacyUser holds the fields users carried before memberships existed.
+type legacyUser struct {
+	ID             string         `bson:"_id"`
+	OrganizationID string         `bson:"organization_id"`
+	Role           users.UserRole `bson:"role"`
+}
+
+type legacyOrganization struct {
+	ID          string `bson:"_id"`
+	OwnerUserID string `bson:"owner_user_id"`
+}
+
+// migrateLegacyMemberships moves each user's organization_id and role into
+// the memberships collection and gives every organization owner an owner
+// membership. Migrated users have both fields removed, so running it again
+// only picks up whatever is left.
+func migrateLegacyMemberships(ctx context.Context, database *mongo.Database) error {
+	membershipCollection := database.Collection("memberships")
+
+	usersCollection := database.Collection("users")
+	cursor, err := usersCollection.Find(ctx, bson.M{"organization_id": bson.M{"$exists": true}})
+	if err != nil {
+		return fmt.Errorf("failed to find users to migrate: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user legacyUser
+		if err := cursor.Decode(&user); err != nil {
+			return fmt.Errorf("failed to decode user: %w", err)
+		}
+
+		if user.OrganizationID != "" {
+			role := user.Role
+			if !role.IsValid() {
+				role = users.RoleVoter
+			}
+			if err := insertMembership(ctx, membershipCollection, user.OrganizationID, user.ID, role); err != nil {
+				return err
+			}
+		}
+
+		_, err := usersCollection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$unset": bson.M{
+			"organization_id": "",
+			"role":            "",
+		}})
+		if err != nil {
+			return fmt.Errorf("failed to migrate user %s: %w", user.ID, err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to migrate users: %w", err)
+	}
+
+	orgCursor, err := database.Collection("organizations").Find(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("failed to find organizations to migrate: %w", err)
+	}
+	defer orgCursor.Close(ctx)
+
+	for orgCursor.Next(ctx) {
+		var organization legacyOrganization
+		if err := orgCursor.Decode(&organization); err != nil {
+			return fmt.Errorf("failed to decode organization: %w", err)
+		}
+		if organization.OwnerUserID == "" {
+			continue
+		}
+
+		_, err := membershipCollection.UpdateOne(ctx,
+			bson.M{"organization_id": organization.ID, "user_id": organization.OwnerUserID},
+			bson.M{
+				"$set": bson.M{"role": users.RoleOwner, "updated_at": time.Now()},
+				"$setOnInsert": bson.M{
+					"_id":        primitive.NewObjectID().Hex(),
+					"created_at": time.Now(),
+				},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to migrate owner of organization %s: %w", organization.ID, err)
+		}
+	}
+	if err := orgCursor.Err(); err != nil {
+		return fmt.Errorf("failed to migrate organizations: %w", err)
+	}
+
+	return nil
+}
+
+func insertMembership(ctx context.Context, collection *mongo.Collection, organizationID, userID string, role users.UserRole) error {
+	now := time.Now()
+	_, err := collection.InsertOne(ctx, memberships.Membership{
+		ID:             primitive.NewObjectID().Hex(),
+		OrganizationID: organizationID,
+		UserID:         userID,
+		Role:           role,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	})
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("failed to migrate membership for user %s: %w", userID, err)
+	}
+	return nil
+}
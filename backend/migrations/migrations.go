@@ -0,0 +1,177 @@
+package migrations
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// lockLease bounds how long a crashed run can hold the lock.
+	lockLease = 15 * time.Minute
+	// lockWait is how long Run waits for another instance to finish.
+	lockWait = 2 * time.Minute
+)
+
+// Migration is one versioned step of the schema. Steps must be safe to run
+// against data that is already partly migrated, since a failed run is retried
+// from the first step that was not recorded.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, database *mongo.Database) error
+}
+
+// Record is stored in the migrations collection for each applied migration.
+type Record struct {
+	Version     int       `json:"version" bson:"_id"`
+	Description string    `json:"description" bson:"description"`
+	AppliedAt   time.Time `json:"applied_at" bson:"applied_at"`
+}
+
+// State reports whether a known migration has been applied.
+type State struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Run applies every migration newer than the recorded schema version, in
+// order. A lock in the migrations_lock collection keeps instances that start
+// together from running the same migration twice.
+func Run(ctx context.Context, database *mongo.Database) error {
+	release, err := acquireLock(ctx, database)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	applied, err := appliedVersions(ctx, database)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range All {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		log.Printf("Applying migration %d: %s", migration.Version, migration.Description)
+		if err := migration.Up(ctx, database); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+		}
+
+		_, err := database.Collection("migrations").InsertOne(ctx, Record{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		}
+	}
+
+	return nil
+}
+
+// Status lists every known migration and when it was applied.
+func Status(ctx context.Context, database *mongo.Database) ([]State, error) {
+	applied, err := appliedVersions(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]State, len(All))
+	for i, migration := range All {
+		states[i].Migration = migration
+		if record, ok := applied[migration.Version]; ok {
+			states[i].AppliedAt = &record.AppliedAt
+		}
+	}
+
+	return states, nil
+}
+
+func appliedVersions(ctx context.Context, database *mongo.Database) (map[int]Record, error) {
+	cursor, err := database.Collection("migrations").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []Record
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode applied migrations: %w", err)
+	}
+
+	applied := make(map[int]Record, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+
+	return applied, nil
+}
+
+// acquireLock takes the migration lock, waiting up to lockWait for another
+// holder, and returns a function that releases it.
+func acquireLock(ctx context.Context, database *mongo.Database) (func(), error) {
+	collection := database.Collection("migrations_lock")
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate lock owner: %w", err)
+	}
+	owner := hex.EncodeToString(b)
+
+	deadline := time.Now().Add(lockWait)
+	for {
+		now := time.Now()
+		// Matches only a missing or expired lock; otherwise the upsert
+		// collides with the held lock's _id
+		filter := bson.M{"_id": "migrations", "locked_until": bson.M{"$lt": now}}
+		update := bson.M{"$set": bson.M{"owner": owner, "locked_until": now.Add(lockLease)}}
+
+		_, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if now.After(deadline) {
+			return nil, errors.New("timed out waiting for another instance to finish migrating")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	release := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if _, err := collection.DeleteOne(ctx, bson.M{"_id": "migrations", "owner": owner}); err != nil {
+			log.Printf("failed to release migration lock: %v", err)
+		}
+	}
+	return release, nil
+}
+
+// createIndexes creates the indexes on a collection. Creating an index that
+// already exists with the same name and keys is a no-op.
+func createIndexes(ctx context.Context, collection *mongo.Collection, models ...mongo.IndexModel) error {
+	if _, err := collection.Indexes().CreateMany(ctx, models); err != nil {
+		return fmt.Errorf("failed to create %s indexes: %w", collection.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package migrations_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/migrations"
+	"github.com/bpalazzi512/easy-ballot/backend/repotest"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestVersions(t *testing.T) {
+	if len(migrations.All) == 0 {
+		t.Fatal("there are no migrations")
+	}
+	for i, migration := range migrations.All {
+		if migration.Version != i+1 || migration.Description == "" || migration.Up == nil {
+			t.Errorf("migration %d is version %d, %q; want version %d with a description and Up", i, migration.Version, migration.Description, i+1)
+		}
+	}
+}
+
+// emptyDatabase returns a database that has not been migrated, dropped when
+// the test ends.
+func emptyDatabase(t *testing.T, client *mongo.Client) *mongo.Database {
+	t.Helper()
+
+	database := client.Database("easy_ballot_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		if err := database.Drop(context.Background()); err != nil {
+			t.Logf("failed to drop %s: %v", database.Name(), err)
+		}
+	})
+	return database
+}
+
+func TestRun(t *testing.T) {
+	client := repotest.MongoClient(t)
+
+	for _, tc := range []struct {
+		name string
+		// runs is how many instances migrate at once
+		runs int
+	}{
+		{name: "Single", runs: 1},
+		{name: "Concurrent", runs: 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			database := emptyDatabase(t, client)
+
+			var wg sync.WaitGroup
+			errs := make([]error, tc.runs)
+			for i := range errs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					errs[i] = migrations.Run(ctx, database)
+				}(i)
+			}
+			wg.Wait()
+			for i, err := range errs {
+				if err != nil {
+					t.Errorf("Run %d: %v", i+1, err)
+				}
+			}
+
+			states, err := migrations.Status(ctx, database)
+			if err != nil {
+				t.Fatalf("Status: %v", err)
+			}
+			for _, state := range states {
+				if state.AppliedAt == nil {
+					t.Errorf("migration %d was not applied", state.Version)
+				}
+			}
+
+			// Each migration is recorded once, and the lock is released
+			if count, err := database.Collection("migrations").CountDocuments(ctx, bson.M{}); err != nil || count != int64(len(migrations.All)) {
+				t.Errorf("migrations has %d records and error %v, want %d", count, err, len(migrations.All))
+			}
+			if count, err := database.Collection("migrations_lock").CountDocuments(ctx, bson.M{}); err != nil || count != 0 {
+				t.Errorf("migrations_lock has %d documents and error %v, want 0", count, err)
+			}
+
+			// Running again applies nothing
+			if err := migrations.Run(ctx, database); err != nil {
+				t.Fatalf("Run again: %v", err)
+			}
+			again, err := migrations.Status(ctx, database)
+			if err != nil {
+				t.Fatalf("Status: %v", err)
+			}
+			for i := range states {
+				if states[i].AppliedAt != nil && !again[i].AppliedAt.Equal(*states[i].AppliedAt) {
+					t.Errorf("migration %d was applied again", states[i].Version)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+-- Deleted organizations are kept and marked with when they were deleted.
+-- Their memberships and ballots move to the archive tables, so no other
+-- query has to skip them.
+
+ALTER TABLE organizations ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE TABLE archived_memberships (
+    id              TEXT PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    user_id         TEXT NOT NULL,
+    role            TEXT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL,
+    updated_at      TIMESTAMPTZ NOT NULL,
+    archived_at     TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX archived_memberships_organization_id ON archived_memberships (organization_id);
+
+CREATE TABLE archived_ballots (
+    id               TEXT PRIMARY KEY,
+    organization_id  TEXT NOT NULL,
+    title            TEXT NOT NULL,
+    description      TEXT NOT NULL DEFAULT '',
+    opens_at         TIMESTAMPTZ NOT NULL,
+    closes_at        TIMESTAMPTZ NOT NULL,
+    questions        JSONB NOT NULL,
+    eligibility      JSONB NOT NULL,
+    secret           BOOLEAN NOT NULL DEFAULT FALSE,
+    roll_snapshot_at TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL,
+    updated_at       TIMESTAMPTZ NOT NULL,
+    archived_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX archived_ballots_organization_id ON archived_ballots (organization_id);
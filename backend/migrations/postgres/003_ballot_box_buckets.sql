@@ -0,0 +1,28 @@
+-- Secret votes move into bucket rows, as in the MongoDB ballot_box
+-- collection, so a vote no longer has a row of its own whose xmin and ctid
+-- follow the order votes were cast in. Existing votes are bucketed by the
+-- first character of their random ID.
+
+CREATE TABLE ballot_box_buckets (
+    id        TEXT PRIMARY KEY,
+    ballot_id TEXT NOT NULL,
+    votes     JSONB NOT NULL
+);
+
+INSERT INTO ballot_box_buckets (id, ballot_id, votes)
+SELECT ballot_id || ':' || left(id, 1), ballot_id,
+       jsonb_agg(jsonb_build_object(
+           'id', id,
+           'selections', selections,
+           'receipt', receipt,
+           'receipt_nonce', receipt_nonce,
+           'created_at', created_at
+       ) ORDER BY id)
+FROM ballot_box
+GROUP BY ballot_id, left(id, 1);
+
+DROP TABLE ballot_box;
+ALTER TABLE ballot_box_buckets RENAME TO ballot_box;
+ALTER INDEX ballot_box_buckets_pkey RENAME TO ballot_box_pkey;
+
+CREATE INDEX ballot_box_ballot_id ON ballot_box (ballot_id);
@@ -0,0 +1,3 @@
+-- Changing a password revokes every token issued before the change.
+
+ALTER TABLE users ADD COLUMN password_changed_at TIMESTAMPTZ;
@@ -0,0 +1,260 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// All lists the schema's migrations in the order they are applied. Append new
+// migrations with the next version; never change or reorder applied ones.
+var All = []Migration{
+	{
+		Version:     1,
+		Description: "Backfill lowercased name and email search fields",
+		Up:          backfillSearchFields,
+	},
+	{
+		Version:     2,
+		Description: "Create user indexes, including a unique email index",
+		Up: func(ctx context.Context, database *mongo.Database) error {
+			return createIndexes(ctx, database.Collection("users"),
+				// Emails are unique regardless of case
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "email_lower", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("email_lower_unique"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+					Options: options.Index().SetName("created_at_id"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "first_name_lower", Value: 1}, {Key: "_id", Value: 1}},
+					Options: options.Index().SetName("first_name_lower_id"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "last_name_lower", Value: 1}, {Key: "_id", Value: 1}},
+					Options: options.Index().SetName("last_name_lower_id"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "email_lower", Value: 1}, {Key: "_id", Value: 1}},
+					Options: options.Index().SetName("email_lower_id"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "first_name", Value: "text"}, {Key: "last_name", Value: "text"}},
+					Options: options.Index().SetName("name_text"),
+				},
+			)
+		},
+	},
+	{
+		Version:     3,
+		Description: "Create organization indexes",
+		Up: func(ctx context.Context, database *mongo.Database) error {
+			return createIndexes(ctx, database.Collection("organizations"),
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "owner_user_id", Value: 1}},
+					Options: options.Index().SetName("owner_user_id"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+					Options: options.Index().SetName("created_at_id"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "name_lower", Value: 1}, {Key: "_id", Value: 1}},
+					Options: options.Index().SetName("name_lower_id"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "name", Value: "text"}},
+					Options: options.Index().SetName("name_text"),
+				},
+			)
+		},
+	},
+	{
+		Version:     4,
+		Description: "Create membership indexes",
+		Up: func(ctx context.Context, database *mongo.Database) error {
+			return createIndexes(ctx, database.Collection("memberships"),
+				// A user joins each organization at most once
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "organization_id", Value: 1}, {Key: "user_id", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("organization_user_unique"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "user_id", Value: 1}},
+					Options: options.Index().SetName("user_id"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "organization_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+					Options: options.Index().SetName("organization_created_at_id"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "organization_id", Value: 1}, {Key: "role", Value: 1}, {Key: "user_id", Value: 1}},
+					Options: options.Index().SetName("organization_role_user"),
+				},
+			)
+		},
+	},
+	{
+		Version:     5,
+		Description: "Move user organization_id and role into memberships",
+		Up:          migrateLegacyMemberships,
+	},
+	{
+		Version:     6,
+		Description: "Create invitation indexes",
+		Up: func(ctx context.Context, database *mongo.Database) error {
+			return createIndexes(ctx, database.Collection("invitations"),
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "token_hash", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("token_hash_unique"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "organization_id", Value: 1}, {Key: "email", Value: 1}},
+					Options: options.Index().SetName("organization_email"),
+				},
+				// Mongo deletes invitations once they expire
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "expires_at", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+				},
+			)
+		},
+	},
+	{
+		Version:     7,
+		Description: "Create account token indexes",
+		Up: func(ctx context.Context, database *mongo.Database) error {
+			return createIndexes(ctx, database.Collection("account_tokens"),
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "token_hash", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("token_hash_unique"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "purpose", Value: 1}},
+					Options: options.Index().SetName("user_purpose"),
+				},
+				// Mongo deletes tokens once they expire
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "expires_at", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+				},
+			)
+		},
+	},
+	{
+		Version:     8,
+		Description: "Create ballot and voter roll indexes",
+		Up: func(ctx context.Context, database *mongo.Database) error {
+			err := createIndexes(ctx, database.Collection("ballots"),
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "organization_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+					Options: options.Index().SetName("organization_created_at_id"),
+				},
+			)
+			if err != nil {
+				return err
+			}
+
+			return createIndexes(ctx, database.Collection("voter_rolls"),
+				// Concurrent snapshots cannot add a voter to a roll twice
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "ballot_id", Value: 1}, {Key: "user_id", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("ballot_user_unique"),
+				},
+			)
+		},
+	},
+	{
+		Version:     9,
+		Description: "Create vote, participation and ballot box indexes",
+		Up: func(ctx context.Context, database *mongo.Database) error {
+			// A voter is recorded once per ballot, even under concurrent submissions
+			err := createIndexes(ctx, database.Collection("votes"),
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "ballot_id", Value: 1}, {Key: "voter_id", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("ballot_voter_unique"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "ballot_id", Value: 1}, {Key: "receipt", Value: 1}},
+					Options: options.Index().SetName("ballot_receipt"),
+				},
+			)
+			if err != nil {
+				return err
+			}
+
+			err = createIndexes(ctx, database.Collection("participations"),
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "ballot_id", Value: 1}, {Key: "voter_id", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("ballot_voter_unique"),
+				},
+			)
+			if err != nil {
+				return err
+			}
+
+			return createIndexes(ctx, database.Collection("ballot_box"),
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "ballot_id", Value: 1}},
+					Options: options.Index().SetName("ballot_id"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "ballot_id", Value: 1}, {Key: "votes.receipt", Value: 1}},
+					Options: options.Index().SetName("ballot_receipt"),
+				},
+			)
+		},
+	},
+	{
+		Version:     10,
+		Description: "Create archived membership and ballot indexes",
+		Up: func(ctx context.Context, database *mongo.Database) error {
+			// Creating the collections up front also spares organization
+			// deletion from creating them inside its transaction
+			err := createIndexes(ctx, database.Collection("archived_memberships"),
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "organization_id", Value: 1}},
+					Options: options.Index().SetName("organization_id"),
+				},
+			)
+			if err != nil {
+				return err
+			}
+
+			return createIndexes(ctx, database.Collection("archived_ballots"),
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "organization_id", Value: 1}},
+					Options: options.Index().SetName("organization_id"),
+				},
+			)
+		},
+	},
+}
+
+// backfillSearchFields fills in the lowercased fields that back case-insensitive
+// search and sorting on documents created before they existed.
+func backfillSearchFields(ctx context.Context, database *mongo.Database) error {
+	_, err := database.Collection("users").UpdateMany(ctx,
+		bson.M{"email_lower": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"first_name_lower": bson.M{"$toLower": "$first_name"},
+			"last_name_lower":  bson.M{"$toLower": "$last_name"},
+			"email_lower":      bson.M{"$toLower": "$email"},
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = database.Collection("organizations").UpdateMany(ctx,
+		bson.M{"name_lower": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"name_lower": bson.M{"$toLower": "$name"},
+		}}}},
+	)
+	return err
+}
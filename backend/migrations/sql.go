@@ -0,0 +1,187 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+)
+
+// SQL migrations are scripts named <version>_<description>.sql in a
+// directory per dialect. Like All, applied scripts must never be changed;
+// add a script with the next version instead.
+//
+//go:embed postgres/*.sql sqlite/*.sql
+var sqlScripts embed.FS
+
+// sqlMigration is one versioned SQL script.
+type sqlMigration struct {
+	version     int
+	description string
+	script      string
+}
+
+// RunSQL applies every SQL migration of the database's dialect that has not
+// been applied yet. The whole run is one transaction holding a lock, so
+// instances that start together wait for each other and a failed run
+// leaves nothing half applied. SQLite transactions hold the database's write
+// lock already; PostgreSQL takes an advisory lock.
+func RunSQL(ctx context.Context, db *sqldb.DB) error {
+	scripts, err := loadSQLMigrations(db.Dialect)
+	if err != nil {
+		return err
+	}
+
+	return db.WithTx(ctx, func(tx *sql.Tx) error {
+		if db.Dialect == sqldb.Postgres {
+			if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('schema_migrations'))"); err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, createSQLRecordsTable(db.Dialect)); err != nil {
+			return fmt.Errorf("failed to create schema_migrations: %w", err)
+		}
+
+		applied, err := appliedSQLVersions(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range scripts {
+			if _, ok := applied[migration.version]; ok {
+				continue
+			}
+
+			log.Printf("Applying migration %d: %s", migration.version, migration.description)
+			if _, err := tx.ExecContext(ctx, migration.script); err != nil {
+				return fmt.Errorf("migration %d (%s) failed: %w", migration.version, migration.description, err)
+			}
+
+			query := db.Query()
+			_, err := tx.ExecContext(ctx,
+				"INSERT INTO schema_migrations (version, description, applied_at) VALUES ("+query.Arg(migration.version)+", "+query.Arg(migration.description)+", "+query.Arg(time.Now())+")",
+				query.Args...)
+			if err != nil {
+				return fmt.Errorf("failed to record migration %d: %w", migration.version, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// SQLStatus lists every SQL migration of the database's dialect and when it
+// was applied.
+func SQLStatus(ctx context.Context, db *sqldb.DB) ([]State, error) {
+	scripts, err := loadSQLMigrations(db.Dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.ExecContext(ctx, createSQLRecordsTable(db.Dialect)); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	applied, err := appliedSQLVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]State, len(scripts))
+	for i, migration := range scripts {
+		states[i].Version = migration.version
+		states[i].Description = migration.description
+		if record, ok := applied[migration.version]; ok {
+			states[i].AppliedAt = &record.AppliedAt
+		}
+	}
+
+	return states, nil
+}
+
+// createSQLRecordsTable returns the statement creating the table of applied
+// migrations. SQLite drivers read back as times only columns declared
+// TIMESTAMP.
+func createSQLRecordsTable(dialect sqldb.Dialect) string {
+	timestamp := "TIMESTAMPTZ"
+	if dialect == sqldb.SQLite {
+		timestamp = "TIMESTAMP"
+	}
+	return `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version     INTEGER PRIMARY KEY,
+    description TEXT NOT NULL,
+    applied_at  ` + timestamp + ` NOT NULL
+)`
+}
+
+func appliedSQLVersions(ctx context.Context, db sqldb.Querier) (map[int]Record, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version, description, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]Record)
+	for rows.Next() {
+		var record Record
+		if err := rows.Scan(&record.Version, &record.Description, &record.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to decode applied migrations: %w", err)
+		}
+		applied[record.Version] = record
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+// loadSQLMigrations reads a dialect's scripts in version order. A script
+// named 002_add_ballot_index.sql is version 2, "Add ballot index".
+func loadSQLMigrations(dialect sqldb.Dialect) ([]sqlMigration, error) {
+	names, err := fs.Glob(sqlScripts, path.Join(string(dialect), "*.sql"))
+	if err != nil || len(names) == 0 {
+		return nil, fmt.Errorf("no SQL migrations for %s", dialect)
+	}
+
+	migrations := make([]sqlMigration, 0, len(names))
+	for _, name := range names {
+		base := strings.TrimSuffix(path.Base(name), ".sql")
+		number, description, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(number)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("SQL migration %s is not named <version>_<description>.sql", name)
+		}
+
+		script, err := sqlScripts.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SQL migration %s: %w", name, err)
+		}
+
+		description = strings.ReplaceAll(description, "_", " ")
+		migrations = append(migrations, sqlMigration{
+			version:     version,
+			description: strings.ToUpper(description[:1]) + description[1:],
+			script:      string(script),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version == migrations[i-1].version {
+			return nil, fmt.Errorf("two SQL migrations for %s have version %d", dialect, migrations[i].version)
+		}
+	}
+
+	return migrations, nil
+}
@@ -0,0 +1,111 @@
+package migrations_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/migrations"
+	"github.com/bpalazzi512/easy-ballot/backend/repotest"
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+)
+
+// openSQLite opens an empty SQLite database that has not been migrated.
+func openSQLite(t *testing.T) *sqldb.DB {
+	t.Helper()
+
+	db, err := sqldb.Open(context.Background(), sqldb.SQLite, filepath.Join(t.TempDir(), "easy_ballot.db"))
+	if err != nil {
+		t.Fatalf("failed to open SQLite database: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return db
+}
+
+// sqlStatus returns the state of every SQL migration, checking that they are
+// numbered from 1 without gaps.
+func sqlStatus(t *testing.T, db *sqldb.DB) []migrations.State {
+	t.Helper()
+
+	states, err := migrations.SQLStatus(context.Background(), db)
+	if err != nil {
+		t.Fatalf("SQLStatus: %v", err)
+	}
+	if len(states) == 0 {
+		t.Fatal("SQLStatus listed no migrations")
+	}
+	for i, state := range states {
+		if state.Version != i+1 || state.Description == "" {
+			t.Errorf("migration %d is version %d, %q; want version %d with a description", i, state.Version, state.Description, i+1)
+		}
+	}
+	return states
+}
+
+func TestRunSQL(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// setup prepares the database before migrating it
+		setup string
+		// applied is whether the run succeeds and records every migration
+		applied bool
+	}{
+		{name: "Empty", applied: true},
+		// A table the first script creates already exists, so it fails and
+		// the whole run rolls back
+		{name: "Conflicting", setup: "CREATE TABLE users (id TEXT PRIMARY KEY)"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := openSQLite(t)
+			if tc.setup != "" {
+				if _, err := db.ExecContext(ctx, tc.setup); err != nil {
+					t.Fatalf("setup: %v", err)
+				}
+			}
+
+			err := migrations.RunSQL(ctx, db)
+			if tc.applied != (err == nil) {
+				t.Fatalf("RunSQL returned %v, want success %t", err, tc.applied)
+			}
+
+			for _, state := range sqlStatus(t, db) {
+				if (state.AppliedAt != nil) != tc.applied {
+					t.Errorf("migration %d applied at %v, want applied %t", state.Version, state.AppliedAt, tc.applied)
+				}
+			}
+
+			// The tables of later scripts exist only if the run succeeded
+			_, err = db.ExecContext(ctx, "SELECT COUNT(*) FROM ballot_box")
+			if tc.applied != (err == nil) {
+				t.Errorf("querying ballot_box returned %v, want success %t", err, tc.applied)
+			}
+		})
+	}
+}
+
+// testRerun checks that running the migrations of an up-to-date database
+// again changes nothing.
+func testRerun(t *testing.T, db *sqldb.DB) {
+	before := sqlStatus(t, db)
+	if err := migrations.RunSQL(context.Background(), db); err != nil {
+		t.Fatalf("RunSQL: %v", err)
+	}
+	after := sqlStatus(t, db)
+
+	for i := range before {
+		if before[i].AppliedAt == nil || after[i].AppliedAt == nil || !before[i].AppliedAt.Equal(*after[i].AppliedAt) {
+			t.Errorf("migration %d applied at %v, then %v after running again", before[i].Version, before[i].AppliedAt, after[i].AppliedAt)
+		}
+	}
+}
+
+func TestSQLiteRerun(t *testing.T) {
+	testRerun(t, repotest.SQLiteDB(t))
+}
+
+func TestPostgresRerun(t *testing.T) {
+	testRerun(t, repotest.PostgresDB(t, repotest.PostgresURL(t)))
+}
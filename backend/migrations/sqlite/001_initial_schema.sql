@@ -0,0 +1,149 @@
+-- SQLite compares text byte by byte as MongoDB does, so cursors and ties
+-- order the same on every backend. Times are stored as UTC text in a format
+-- that sorts chronologically, and the driver reads columns declared
+-- TIMESTAMP back as times.
+
+CREATE TABLE users (
+    id                TEXT PRIMARY KEY,
+    first_name        TEXT NOT NULL,
+    last_name         TEXT NOT NULL,
+    email             TEXT NOT NULL,
+    password          TEXT NOT NULL,
+    profile_picture   TEXT NOT NULL DEFAULT '',
+    first_name_lower  TEXT NOT NULL,
+    last_name_lower   TEXT NOT NULL,
+    email_lower       TEXT NOT NULL,
+    email_verified_at TIMESTAMP,
+    created_at        TIMESTAMP NOT NULL,
+    updated_at        TIMESTAMP NOT NULL
+);
+
+-- Emails are unique regardless of case
+CREATE UNIQUE INDEX users_email_lower_unique ON users (email_lower);
+CREATE INDEX users_created_at_id ON users (created_at, id);
+CREATE INDEX users_first_name_lower_id ON users (first_name_lower, id);
+CREATE INDEX users_last_name_lower_id ON users (last_name_lower, id);
+
+CREATE TABLE organizations (
+    id            TEXT PRIMARY KEY,
+    name          TEXT NOT NULL,
+    name_lower    TEXT NOT NULL,
+    logo          TEXT NOT NULL DEFAULT '',
+    owner_user_id TEXT NOT NULL,
+    created_at    TIMESTAMP NOT NULL,
+    updated_at    TIMESTAMP NOT NULL
+);
+
+CREATE INDEX organizations_owner_user_id ON organizations (owner_user_id, created_at);
+CREATE INDEX organizations_created_at_id ON organizations (created_at, id);
+CREATE INDEX organizations_name_lower_id ON organizations (name_lower, id);
+
+CREATE TABLE memberships (
+    id              TEXT PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    user_id         TEXT NOT NULL,
+    role            TEXT NOT NULL,
+    created_at      TIMESTAMP NOT NULL,
+    updated_at      TIMESTAMP NOT NULL,
+    UNIQUE (organization_id, user_id)
+);
+
+CREATE INDEX memberships_organization_created_at_id ON memberships (organization_id, created_at, id);
+CREATE INDEX memberships_user_id ON memberships (user_id, created_at);
+
+-- Password reset and email verification links
+CREATE TABLE account_tokens (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    email      TEXT NOT NULL,
+    purpose    TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at    TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX account_tokens_user_purpose ON account_tokens (user_id, purpose);
+CREATE INDEX account_tokens_expires_at ON account_tokens (expires_at);
+
+CREATE TABLE invitations (
+    id              TEXT PRIMARY KEY,
+    organization_id TEXT NOT NULL,
+    email           TEXT NOT NULL,
+    role            TEXT NOT NULL,
+    token_hash      TEXT NOT NULL UNIQUE,
+    invited_by      TEXT NOT NULL,
+    expires_at      TIMESTAMP NOT NULL,
+    accepted_at     TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL,
+    updated_at      TIMESTAMP NOT NULL
+);
+
+CREATE INDEX invitations_organization_email ON invitations (organization_id, email);
+CREATE INDEX invitations_expires_at ON invitations (expires_at);
+
+-- Questions and eligibility are always read and written whole with their
+-- ballot, so they are kept as JSON text
+CREATE TABLE ballots (
+    id               TEXT PRIMARY KEY,
+    organization_id  TEXT NOT NULL,
+    title            TEXT NOT NULL,
+    description      TEXT NOT NULL DEFAULT '',
+    opens_at         TIMESTAMP NOT NULL,
+    closes_at        TIMESTAMP NOT NULL,
+    questions        TEXT NOT NULL,
+    eligibility      TEXT NOT NULL,
+    secret           BOOLEAN NOT NULL DEFAULT FALSE,
+    roll_snapshot_at TIMESTAMP,
+    created_at       TIMESTAMP NOT NULL,
+    updated_at       TIMESTAMP NOT NULL
+);
+
+CREATE INDEX ballots_organization_created_at_id ON ballots (organization_id, created_at, id);
+CREATE INDEX ballots_created_at_id ON ballots (created_at, id);
+
+CREATE TABLE voter_rolls (
+    id         TEXT PRIMARY KEY,
+    ballot_id  TEXT NOT NULL,
+    user_id    TEXT NOT NULL,
+    role       TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (ballot_id, user_id)
+);
+
+-- Votes on open ballots, one per voter
+CREATE TABLE votes (
+    id            TEXT PRIMARY KEY,
+    ballot_id     TEXT NOT NULL,
+    voter_id      TEXT NOT NULL,
+    selections    TEXT NOT NULL,
+    receipt       TEXT NOT NULL DEFAULT '',
+    receipt_nonce TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMP NOT NULL,
+    UNIQUE (ballot_id, voter_id)
+);
+
+CREATE INDEX votes_ballot_receipt ON votes (ballot_id, receipt);
+
+-- Secret ballots keep who voted apart from the anonymized votes, which have
+-- random IDs, day-granular timestamps and no voter ID, so neither table can
+-- be joined back to the other
+CREATE TABLE participations (
+    id         TEXT PRIMARY KEY,
+    ballot_id  TEXT NOT NULL,
+    voter_id   TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (ballot_id, voter_id)
+);
+
+CREATE TABLE ballot_box (
+    id            TEXT PRIMARY KEY,
+    ballot_id     TEXT NOT NULL,
+    selections    TEXT NOT NULL,
+    receipt       TEXT NOT NULL DEFAULT '',
+    receipt_nonce TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMP NOT NULL
+);
+
+CREATE INDEX ballot_box_ballot_id ON ballot_box (ballot_id, id);
+CREATE INDEX ballot_box_ballot_receipt ON ballot_box (ballot_id, receipt);
@@ -0,0 +1,29 @@
+-- Secret votes move into bucket rows, as in the MongoDB ballot_box
+-- collection, so a vote no longer has a row of its own whose position follows
+-- the order votes were cast in. The buckets are a WITHOUT ROWID table: a
+-- rowid would number them in the order they received their first vote, as
+-- it numbers participations. Existing votes are bucketed by the first
+-- character of their random ID.
+
+CREATE TABLE ballot_box_buckets (
+    id        TEXT PRIMARY KEY,
+    ballot_id TEXT NOT NULL,
+    votes     TEXT NOT NULL
+) WITHOUT ROWID;
+
+INSERT INTO ballot_box_buckets (id, ballot_id, votes)
+SELECT ballot_id || ':' || substr(id, 1, 1), ballot_id,
+       json_group_array(json_object(
+           'id', id,
+           'selections', json(selections),
+           'receipt', receipt,
+           'receipt_nonce', receipt_nonce,
+           'created_at', strftime('%Y-%m-%dT%H:%M:%SZ', created_at)
+       ) ORDER BY id)
+FROM ballot_box
+GROUP BY ballot_id, substr(id, 1, 1);
+
+DROP TABLE ballot_box;
+ALTER TABLE ballot_box_buckets RENAME TO ballot_box;
+
+CREATE INDEX ballot_box_ballot_id ON ballot_box (ballot_id);
@@ -0,0 +1,60 @@
+package pagination
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Slice returns the page of items, sorting them by the page's sort field and
+// ID as MongoFilter and MongoFindOptions do for stored items. keyOf returns an
+// item's sort value and ID, as for NextPage. items is sorted in place.
+func Slice[T any](items []T, page Page, keyOf func(T) (any, string)) []T {
+	sort.SliceStable(items, func(i, j int) bool {
+		return page.before(keyOf(items[i]))(keyOf(items[j]))
+	})
+
+	start := 0
+	if page.After != nil {
+		start = sort.Search(len(items), func(i int) bool {
+			return page.before(page.After.Value, page.After.ID)(keyOf(items[i]))
+		})
+	}
+	start = min(start+page.Offset, len(items))
+
+	end := len(items)
+	if page.Limit > 0 {
+		end = min(start+page.Limit, end)
+	}
+	return items[start:end]
+}
+
+// before returns whether the key (value, id) sorts before other keys in the
+// page's order.
+func (p Page) before(value any, id string) func(any, string) bool {
+	return func(otherValue any, otherID string) bool {
+		c := compareValues(value, otherValue)
+		if c == 0 {
+			c = strings.Compare(id, otherID)
+		}
+		if p.Sort.Descending {
+			return c > 0
+		}
+		return c < 0
+	}
+}
+
+// compareValues orders the kinds of values lists are sorted by: times and
+// strings.
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case time.Time:
+		b, _ := b.(time.Time)
+		return a.Compare(b)
+	case string:
+		b, _ := b.(string)
+		return strings.Compare(a, b)
+	default:
+		return 0
+	}
+}
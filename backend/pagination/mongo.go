@@ -0,0 +1,43 @@
+package pagination
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoFilter restricts filter to the items after the page's cursor. field is
+// the stored field the page is sorted by, which may differ from the sort's
+// API name.
+func MongoFilter(filter bson.M, page Page, field string) bson.M {
+	if page.After == nil {
+		return filter
+	}
+
+	operator := "$gt"
+	if page.Sort.Descending {
+		operator = "$lt"
+	}
+
+	after := bson.M{"$or": bson.A{
+		bson.M{field: bson.M{operator: page.After.Value}},
+		bson.M{field: page.After.Value, "_id": bson.M{operator: page.After.ID}},
+	}}
+	if len(filter) == 0 {
+		return after
+	}
+	return bson.M{"$and": bson.A{filter, after}}
+}
+
+// MongoFindOptions sorts by (field, _id) and applies the page's limit and
+// offset.
+func MongoFindOptions(page Page, field string) *options.FindOptions {
+	direction := 1
+	if page.Sort.Descending {
+		direction = -1
+	}
+
+	return options.Find().
+		SetLimit(int64(page.Limit)).
+		SetSkip(int64(page.Offset)).
+		SetSort(bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}})
+}
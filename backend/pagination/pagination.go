@@ -0,0 +1,204 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultLimit = 10
+	MaxLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Sort orders a list by one field, with the ID breaking ties.
+type Sort struct {
+	Field      string
+	Descending bool
+}
+
+// Cursor identifies the last item of a page by its sort value and ID, which
+// together are unique and stay stable while items are being added.
+type Cursor struct {
+	Field      string `json:"f"`
+	Descending bool   `json:"d,omitempty"`
+	Value      any    `json:"v"`
+	ID         string `json:"id"`
+}
+
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor reverses Encode. Values of timestamp fields, which by
+// convention end in "_at", are decoded back into times.
+func DecodeCursor(value string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(b, &cursor); err != nil || cursor.Field == "" || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	text, ok := cursor.Value.(string)
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	if strings.HasSuffix(cursor.Field, "_at") {
+		t, err := time.Parse(time.RFC3339Nano, text)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		cursor.Value = t
+	}
+
+	return &cursor, nil
+}
+
+// Page selects the items to list. With After set the page continues from that
+// cursor; otherwise Offset items are skipped, which is kept for older clients
+// but gets slower the further it goes.
+type Page struct {
+	Limit  int
+	Offset int
+	Sort   Sort
+	After  *Cursor
+}
+
+// Normalize applies the default and maximum limit, falls back to the given
+// sort, and ignores the offset when a cursor is given.
+func (p Page) Normalize(fallback Sort) Page {
+	if p.Limit <= 0 {
+		p.Limit = DefaultLimit
+	}
+	if p.Limit > MaxLimit {
+		p.Limit = MaxLimit
+	}
+	if p.Sort.Field == "" {
+		p.Sort = fallback
+	}
+	if p.Offset < 0 || p.After != nil {
+		p.Offset = 0
+	}
+	return p
+}
+
+// Lookahead asks for one extra item so NextPage can tell whether another page
+// follows.
+func (p Page) Lookahead() Page {
+	p.Limit++
+	return p
+}
+
+// List is one page of results along with the cursor for the next page, empty
+// on the last page, and the total number of items across all pages.
+type List[T any] struct {
+	Items      []T
+	NextCursor string
+	Total      int64
+}
+
+// NextPage trims items fetched with Lookahead back to the page limit and
+// returns the cursor of the next page. keyOf returns an item's value for the
+// page's sort field and its ID.
+func NextPage[T any](items []T, page Page, keyOf func(T) (any, string)) ([]T, string) {
+	if len(items) <= page.Limit {
+		return items, ""
+	}
+
+	items = items[:page.Limit]
+	value, id := keyOf(items[len(items)-1])
+	cursor := Cursor{
+		Field:      page.Sort.Field,
+		Descending: page.Sort.Descending,
+		Value:      value,
+		ID:         id,
+	}
+	return items, cursor.Encode()
+}
+
+// ParseQuery reads the limit, offset and cursor query parameters, and the
+// sort parameter when sortable fields are given. Sorts are a field name,
+// prefixed with "-" for descending order. Malformed limits and offsets fall
+// back to their defaults, but a malformed cursor or sort is an error since
+// silently changing the order would repeat or skip items.
+func ParseQuery(query url.Values, sortable ...string) (Page, error) {
+	var page Page
+
+	if l := query.Get("limit"); l != "" {
+		if parsedLimit, err := strconv.Atoi(l); err == nil && parsedLimit > 0 {
+			page.Limit = parsedLimit
+		}
+	}
+	if o := query.Get("offset"); o != "" {
+		if parsedOffset, err := strconv.Atoi(o); err == nil && parsedOffset >= 0 {
+			page.Offset = parsedOffset
+		}
+	}
+	if s := query.Get("sort"); s != "" && len(sortable) > 0 {
+		sort, err := parseSort(s, sortable)
+		if err != nil {
+			return Page{}, err
+		}
+		page.Sort = sort
+	}
+	if c := query.Get("cursor"); c != "" {
+		cursor, err := DecodeCursor(c)
+		if err != nil {
+			return Page{}, err
+		}
+		// The cursor carries its sort, so later pages only need the cursor.
+		// Lists with a fixed order keep it whatever the cursor says.
+		if len(sortable) > 0 {
+			cursorSort := Sort{Field: cursor.Field, Descending: cursor.Descending}
+			if _, err := parseSort(cursor.Field, sortable); err != nil {
+				return Page{}, ErrInvalidCursor
+			}
+			if page.Sort.Field != "" && page.Sort != cursorSort {
+				return Page{}, ErrInvalidCursor
+			}
+			page.Sort = cursorSort
+		}
+		page.After = cursor
+	}
+
+	return page, nil
+}
+
+func parseSort(value string, sortable []string) (Sort, error) {
+	sort := Sort{Field: strings.TrimPrefix(value, "-")}
+	sort.Descending = sort.Field != value
+
+	for _, field := range sortable {
+		if sort.Field == field {
+			return sort, nil
+		}
+	}
+	return Sort{}, fmt.Errorf("cannot sort by %s; use one of %s", sort.Field, strings.Join(sortable, ", "))
+}
+
+// ParseTime reads an optional RFC 3339 timestamp, such as the bounds of a
+// created_at range, from the query.
+func ParseTime(query url.Values, key string) (*time.Time, error) {
+	value := query.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", key)
+	}
+	return &t, nil
+}
@@ -0,0 +1,262 @@
+package pagination_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+)
+
+func TestCursor(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC)
+
+	for _, cursor := range []pagination.Cursor{
+		{Field: "created_at", Value: createdAt, ID: "b"},
+		{Field: "created_at", Descending: true, Value: createdAt, ID: "b"},
+		{Field: "last_name", Value: "Lovelace", ID: "a"},
+		{Field: "last_name", Value: "", ID: "a"},
+	} {
+		decoded, err := pagination.DecodeCursor(cursor.Encode())
+		if err != nil {
+			t.Errorf("DecodeCursor(%+v): %v", cursor, err)
+			continue
+		}
+		if decoded.Field != cursor.Field || decoded.Descending != cursor.Descending || decoded.ID != cursor.ID {
+			t.Errorf("DecodeCursor returned %+v, want %+v", *decoded, cursor)
+		}
+		if want, ok := cursor.Value.(time.Time); ok {
+			if got, ok := decoded.Value.(time.Time); !ok || !got.Equal(want) {
+				t.Errorf("DecodeCursor returned value %#v, want %v", decoded.Value, want)
+			}
+		} else if decoded.Value != cursor.Value {
+			t.Errorf("DecodeCursor returned value %#v, want %#v", decoded.Value, cursor.Value)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(payload string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(payload))
+	}
+	valid := pagination.Cursor{Field: "created_at", Value: time.Now(), ID: "a"}.Encode()
+
+	for name, value := range map[string]string{
+		"NotBase64":    "not a cursor!",
+		"PaddedBase64": encode(`{"f":"last_name","v":"Lovelace","id":"a"}`) + "==",
+		"Truncated":    valid[:len(valid)-4],
+		"NotJSON":      encode("created_at,a"),
+		"NoField":      encode(`{"v":"Lovelace","id":"a"}`),
+		"NoID":         encode(`{"f":"last_name","v":"Lovelace"}`),
+		"NoValue":      encode(`{"f":"last_name","id":"a"}`),
+		"NumberValue":  encode(`{"f":"last_name","v":42,"id":"a"}`),
+		"ObjectValue":  encode(`{"f":"last_name","v":{"$gt":""},"id":"a"}`),
+		"BadTime":      encode(`{"f":"created_at","v":"yesterday","id":"a"}`),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if cursor, err := pagination.DecodeCursor(value); !errors.Is(err, pagination.ErrInvalidCursor) {
+				t.Errorf("DecodeCursor returned %+v and error %v, want ErrInvalidCursor", cursor, err)
+			}
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	byName := pagination.Cursor{Field: "last_name", Descending: true, Value: "Lovelace", ID: "a"}.Encode()
+
+	for _, tc := range []struct {
+		name  string
+		query string
+		want  pagination.Page
+		err   bool
+	}{
+		{name: "Empty", query: ""},
+		{name: "LimitAndOffset", query: "limit=5&offset=10", want: pagination.Page{Limit: 5, Offset: 10}},
+		{name: "MalformedLimitAndOffset", query: "limit=-5&offset=ten", want: pagination.Page{}},
+		{name: "Sort", query: "sort=-last_name", want: pagination.Page{Sort: pagination.Sort{Field: "last_name", Descending: true}}},
+		{name: "UnsortableField", query: "sort=password", err: true},
+		{name: "CursorSort", query: "cursor=" + byName, want: pagination.Page{Sort: pagination.Sort{Field: "last_name", Descending: true}}},
+		{name: "SameSortAndCursor", query: "sort=-last_name&cursor=" + byName, want: pagination.Page{Sort: pagination.Sort{Field: "last_name", Descending: true}}},
+		{name: "OtherSortThanCursor", query: "sort=last_name&cursor=" + byName, err: true},
+		{name: "UnsortableCursor", query: "cursor=" + pagination.Cursor{Field: "password", Value: "a", ID: "a"}.Encode(), err: true},
+		{name: "InvalidCursor", query: "cursor=abc", err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			page, err := pagination.ParseQuery(query, "created_at", "last_name")
+			if tc.err {
+				if err == nil {
+					t.Errorf("ParseQuery returned %+v, want an error", page)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseQuery: %v", err)
+			}
+			if page.Limit != tc.want.Limit || page.Offset != tc.want.Offset || page.Sort != tc.want.Sort {
+				t.Errorf("ParseQuery returned %+v, want %+v", page, tc.want)
+			}
+			if query.Has("cursor") && (page.After == nil || page.After.ID != "a") {
+				t.Errorf("ParseQuery returned cursor %+v, want the one given", page.After)
+			}
+		})
+	}
+
+	// Lists with a fixed order ignore the cursor's sort
+	query := url.Values{"cursor": {byName}}
+	page, err := pagination.ParseQuery(query)
+	if err != nil || page.Sort != (pagination.Sort{}) || page.After == nil {
+		t.Errorf("ParseQuery without sortable fields returned %+v and error %v, want the cursor alone", page, err)
+	}
+}
+
+type item struct {
+	ID        string
+	Name      string
+	CreatedAt time.Time
+}
+
+func TestPages(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// Several items share each name and creation time, so only the ID
+	// keeps the order stable
+	var items []item
+	for i := 0; i < 23; i++ {
+		items = append(items, item{
+			ID:        fmt.Sprintf("%02d", (i*7)%23),
+			Name:      []string{"ada", "grace", "alan"}[i%3],
+			CreatedAt: start.Add(time.Duration(i%4) * time.Hour),
+		})
+	}
+
+	for _, sort := range []pagination.Sort{
+		{Field: "created_at"},
+		{Field: "created_at", Descending: true},
+		{Field: "name"},
+		{Field: "name", Descending: true},
+	} {
+		t.Run(fmt.Sprintf("%+v", sort), func(t *testing.T) {
+			keyOf := itemKey(sort.Field)
+			want := pagination.Slice(append([]item(nil), items...), pagination.Page{Sort: sort}, keyOf)
+			for i := 1; i < len(want); i++ {
+				if !ordered(want[i-1], want[i], sort) {
+					t.Errorf("sorted by %+v, %+v comes before %+v", sort, want[i-1], want[i])
+				}
+			}
+
+			got := listAll(t, items, sort, 4, nil)
+			if !sameItems(got, want) {
+				t.Errorf("paging returned %v, want %v", itemIDs(got), itemIDs(want))
+			}
+
+			// Items added to pages already listed are not listed again, and
+			// nothing after the cursor is skipped
+			added := false
+			got = listAll(t, items, sort, 4, func(listed []item) []item {
+				if added {
+					return nil
+				}
+				added = true
+				first := listed[0]
+				return []item{{ID: first.ID + "-added", Name: first.Name, CreatedAt: first.CreatedAt}}
+			})
+			if !sameItems(got, want) {
+				t.Errorf("paging while adding items returned %v, want %v", itemIDs(got), itemIDs(want))
+			}
+		})
+	}
+}
+
+// listAll pages through items as a client would, passing each next cursor
+// back through ParseQuery. addAfter may return items to store after each
+// page.
+func listAll(t *testing.T, items []item, sort pagination.Sort, limit int, addAfter func(listed []item) []item) []item {
+	t.Helper()
+
+	keyOf := itemKey(sort.Field)
+	stored := append([]item(nil), items...)
+	query := url.Values{"limit": {fmt.Sprint(limit)}}
+	if sort.Descending {
+		query.Set("sort", "-"+sort.Field)
+	} else {
+		query.Set("sort", sort.Field)
+	}
+
+	var all []item
+	for pages := 0; ; pages++ {
+		if pages > len(items) {
+			t.Fatalf("paging by %+v did not end", sort)
+		}
+
+		page, err := pagination.ParseQuery(query, "created_at", "name")
+		if err != nil {
+			t.Fatalf("ParseQuery(%v): %v", query, err)
+		}
+		page = page.Normalize(pagination.Sort{Field: "created_at"})
+
+		listed := pagination.Slice(append([]item(nil), stored...), page.Lookahead(), keyOf)
+		listed, next := pagination.NextPage(listed, page, keyOf)
+		all = append(all, listed...)
+		if next == "" {
+			return all
+		}
+
+		if addAfter != nil {
+			stored = append(stored, addAfter(listed)...)
+		}
+		query = url.Values{"limit": {fmt.Sprint(limit)}, "cursor": {next}}
+	}
+}
+
+func itemKey(field string) func(item) (any, string) {
+	return func(i item) (any, string) {
+		if field == "name" {
+			return i.Name, i.ID
+		}
+		return i.CreatedAt, i.ID
+	}
+}
+
+// ordered reports whether a strictly precedes b by the sort field and then ID.
+func ordered(a, b item, sort pagination.Sort) bool {
+	c := a.CreatedAt.Compare(b.CreatedAt)
+	if sort.Field == "name" {
+		c = strings.Compare(a.Name, b.Name)
+	}
+	if c == 0 {
+		c = strings.Compare(a.ID, b.ID)
+	}
+	if sort.Descending {
+		c = -c
+	}
+	return c < 0
+}
+
+func itemIDs(items []item) []string {
+	result := make([]string, len(items))
+	for i, item := range items {
+		result[i] = item.ID
+	}
+	return result
+}
+
+func sameItems(a, b []item) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package pagination
+
+import (
+	"fmt"
+
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+)
+
+// SQLAfter returns the condition restricting a query to the items after the
+// page's cursor, or "" without one. column is the column the page is sorted
+// by, which may differ from the sort's API name.
+func SQLAfter(q *sqldb.Query, page Page, column string) string {
+	if page.After == nil {
+		return ""
+	}
+
+	operator := ">"
+	if page.Sort.Descending {
+		operator = "<"
+	}
+
+	return fmt.Sprintf("(%s, id) %s (%s, %s)", column, operator, q.Arg(page.After.Value), q.Arg(page.After.ID))
+}
+
+// SQLOrderBy sorts by (column, id) and applies the page's limit and offset.
+func SQLOrderBy(q *sqldb.Query, page Page, column string) string {
+	direction := "ASC"
+	if page.Sort.Descending {
+		direction = "DESC"
+	}
+
+	return fmt.Sprintf(" ORDER BY %s %s, id %s", column, direction, direction) + q.Limit(page.Limit, page.Offset)
+}
@@ -0,0 +1,214 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/invitations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/bpalazzi512/easy-ballot/backend/tally"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeletionStores are the repositories deleting an organization touches, all
+// sharing one backend's storage.
+type DeletionStores struct {
+	Deletions     organizations.DeletionRepository
+	Organizations organizations.OrganizationRepository
+	Memberships   memberships.MembershipRepository
+	Invitations   invitations.InvitationRepository
+	Ballots       ballots.BallotRepository
+}
+
+// TestDeletionRepository checks that a DeletionRepository behaves like the
+// MongoDB one. newStores must return empty repositories each time it is
+// called.
+func TestDeletionRepository(t *testing.T, newStores func(t *testing.T) DeletionStores) {
+	ctx := context.Background()
+
+	t.Run("Delete", func(t *testing.T) {
+		stores := newStores(t)
+		now := time.Now()
+
+		organization := createOrganization(t, stores.Organizations, "Acme Corporation", "owner")
+		addMember(t, stores, organization.ID, "owner", users.RoleOwner)
+		addMember(t, stores, organization.ID, "voter", users.RoleVoter)
+		closed := addBallot(t, stores, organization.ID, now.Add(-48*time.Hour), now.Add(-24*time.Hour))
+		closedEarly := addBallot(t, stores, organization.ID, now.Add(-2*time.Hour), now.Add(-time.Hour))
+		pending := addInvitation(t, stores, organization.ID, "pending@example.com", now.Add(time.Hour))
+		accepted := addInvitation(t, stores, organization.ID, "accepted@example.com", now.Add(time.Hour))
+		if _, err := stores.Invitations.ConsumeInvitation(ctx, accepted.TokenHash, now); err != nil {
+			t.Fatalf("ConsumeInvitation: %v", err)
+		}
+
+		other := createOrganization(t, stores.Organizations, "Globex", "owner")
+		addMember(t, stores, other.ID, "owner", users.RoleOwner)
+		otherBallot := addBallot(t, stores, other.ID, now.Add(24*time.Hour), now.Add(48*time.Hour))
+		addInvitation(t, stores, other.ID, "pending@example.com", now.Add(time.Hour))
+
+		// Created last, since creating an invitation clears out expired ones
+		expired := addInvitation(t, stores, organization.ID, "expired@example.com", now.Add(-time.Hour))
+
+		report, err := stores.Deletions.DeleteOrganization(ctx, organization.ID, now)
+		if err != nil {
+			t.Fatalf("DeleteOrganization: %v", err)
+		}
+		want := organizations.DeletionReport{
+			OrganizationID:     organization.ID,
+			DeletedAt:          now,
+			MembersArchived:    2,
+			BallotsArchived:    2,
+			InvitationsDeleted: 3,
+			Retained:           []string{"votes", "participations", "ballot_box", "voter_rolls"},
+		}
+		if !reflect.DeepEqual(*report, want) {
+			t.Errorf("DeleteOrganization reported %+v, want %+v", *report, want)
+		}
+
+		_, err = stores.Organizations.GetOrganizationByID(ctx, organization.ID)
+		expectNotFound(t, "GetOrganizationByID after DeleteOrganization", err)
+		if _, err := stores.Memberships.GetMembership(ctx, organization.ID, "voter"); !errors.Is(err, memberships.ErrNotMember) {
+			t.Errorf("GetMembership after DeleteOrganization: got error %v, want ErrNotMember", err)
+		}
+		for _, ballot := range []*ballots.Ballot{closed, closedEarly} {
+			if _, err := stores.Ballots.GetBallotByID(ctx, ballot.ID); err == nil {
+				t.Errorf("GetBallotByID found ballot %s after DeleteOrganization", ballot.ID)
+			}
+		}
+		for _, invitation := range []*invitations.Invitation{pending, accepted, expired} {
+			_, err := stores.Invitations.GetInvitationByID(ctx, invitation.ID)
+			expectNotFound(t, "GetInvitationByID for "+invitation.Email+" after DeleteOrganization", err)
+		}
+
+		// The other organization is untouched
+		if _, err := stores.Organizations.GetOrganizationByID(ctx, other.ID); err != nil {
+			t.Errorf("GetOrganizationByID for another organization: %v", err)
+		}
+		if count, err := stores.Memberships.CountMembers(ctx, other.ID); err != nil || count != 1 {
+			t.Errorf("CountMembers for another organization returned %d and error %v, want 1", count, err)
+		}
+		if _, err := stores.Ballots.GetBallotByID(ctx, otherBallot.ID); err != nil {
+			t.Errorf("GetBallotByID for another organization's ballot: %v", err)
+		}
+		invited, err := stores.Invitations.ListPendingInvitations(ctx, other.ID, now)
+		if err != nil || len(invited) != 1 {
+			t.Errorf("ListPendingInvitations for another organization returned %d invitations and error %v, want 1", len(invited), err)
+		}
+
+		_, err = stores.Deletions.DeleteOrganization(ctx, organization.ID, now)
+		expectNotFound(t, "DeleteOrganization twice", err)
+	})
+
+	t.Run("UnfinishedBallots", func(t *testing.T) {
+		stores := newStores(t)
+		now := time.Now()
+
+		organization := createOrganization(t, stores.Organizations, "Acme Corporation", "owner")
+		addMember(t, stores, organization.ID, "owner", users.RoleOwner)
+		scheduled := addBallot(t, stores, organization.ID, now.Add(24*time.Hour), now.Add(48*time.Hour))
+		open := addBallot(t, stores, organization.ID, now.Add(-time.Hour), now.Add(time.Hour))
+		pending := addInvitation(t, stores, organization.ID, "pending@example.com", now.Add(time.Hour))
+
+		_, err := stores.Deletions.DeleteOrganization(ctx, organization.ID, now)
+		expectConflict(t, "DeleteOrganization with open and scheduled ballots", err)
+		if err != nil && !strings.Contains(err.Error(), "1 open, 1 scheduled") {
+			t.Errorf("DeleteOrganization returned error %q, want it to count 1 open and 1 scheduled ballot", err)
+		}
+
+		// Once the open ballot has closed, the scheduled one still blocks
+		// the deletion
+		_, err = stores.Deletions.DeleteOrganization(ctx, organization.ID, open.ClosesAt)
+		expectConflict(t, "DeleteOrganization with a scheduled ballot", err)
+
+		// Nothing was changed
+		if _, err := stores.Organizations.GetOrganizationByID(ctx, organization.ID); err != nil {
+			t.Errorf("GetOrganizationByID: %v", err)
+		}
+		if _, err := stores.Memberships.GetMembership(ctx, organization.ID, "owner"); err != nil {
+			t.Errorf("GetMembership: %v", err)
+		}
+		if count, err := stores.Ballots.CountBallots(ctx, organization.ID); err != nil || count != 2 {
+			t.Errorf("CountBallots returned %d and error %v, want 2", count, err)
+		}
+		if _, err := stores.Invitations.GetInvitationByID(ctx, pending.ID); err != nil {
+			t.Errorf("GetInvitationByID: %v", err)
+		}
+
+		// Once every ballot has closed the organization can be deleted
+		report, err := stores.Deletions.DeleteOrganization(ctx, organization.ID, scheduled.ClosesAt)
+		if err != nil {
+			t.Fatalf("DeleteOrganization after the ballots closed: %v", err)
+		}
+		if report.BallotsArchived != 2 {
+			t.Errorf("DeleteOrganization archived %d ballots, want 2", report.BallotsArchived)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		stores := newStores(t)
+
+		_, err := stores.Deletions.DeleteOrganization(ctx, primitive.NewObjectID().Hex(), time.Now())
+		expectNotFound(t, "DeleteOrganization", err)
+	})
+}
+
+func addMember(t *testing.T, stores DeletionStores, organizationID, userID string, role users.UserRole) {
+	t.Helper()
+
+	_, err := stores.Memberships.CreateMembership(context.Background(), memberships.Membership{
+		OrganizationID: organizationID,
+		UserID:         userID,
+		Role:           role,
+	})
+	if err != nil {
+		t.Fatalf("CreateMembership: %v", err)
+	}
+}
+
+func addBallot(t *testing.T, stores DeletionStores, organizationID string, opensAt, closesAt time.Time) *ballots.Ballot {
+	t.Helper()
+
+	ballot, err := stores.Ballots.CreateBallot(context.Background(), ballots.Ballot{
+		OrganizationID: organizationID,
+		Title:          "Board election",
+		OpensAt:        opensAt,
+		ClosesAt:       closesAt,
+		Questions: []ballots.Question{{
+			ID:            "chair",
+			Prompt:        "Who should chair the board?",
+			Method:        tally.Plurality,
+			Options:       []ballots.Option{{ID: "ada", Label: "Ada"}, {ID: "grace", Label: "Grace"}},
+			Seats:         1,
+			MaxSelections: 1,
+		}},
+		Eligibility: ballots.Eligibility{Mode: ballots.EligibilityAllMembers},
+	})
+	if err != nil {
+		t.Fatalf("CreateBallot: %v", err)
+	}
+	return ballot
+}
+
+func addInvitation(t *testing.T, stores DeletionStores, organizationID, email string, expiresAt time.Time) *invitations.Invitation {
+	t.Helper()
+
+	invitation, err := stores.Invitations.CreateInvitation(context.Background(), invitations.Invitation{
+		OrganizationID: organizationID,
+		Email:          email,
+		Role:           users.RoleVoter,
+		TokenHash:      primitive.NewObjectID().Hex(),
+		InvitedBy:      "owner",
+		ExpiresAt:      expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("CreateInvitation: %v", err)
+	}
+	return invitation
+}
@@ -0,0 +1,80 @@
+package repotest
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/migrations"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoClient connects to the MongoDB server at MONGODB_TEST_URI, skipping
+// the test when it is unset or the server can't be reached.
+func MongoClient(t *testing.T) *mongo.Client {
+	t.Helper()
+
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("set MONGODB_TEST_URI to run against MongoDB")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Skipf("MongoDB is not available: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		t.Skipf("MongoDB is not available: %v", err)
+	}
+
+	t.Cleanup(func() {
+		client.Disconnect(context.Background())
+	})
+	return client
+}
+
+// MongoDatabase creates an empty, fully migrated database for one test and
+// drops it when the test ends.
+func MongoDatabase(t *testing.T, client *mongo.Client) *mongo.Database {
+	t.Helper()
+
+	ctx := context.Background()
+	database := client.Database("easy_ballot_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		if err := database.Drop(ctx); err != nil {
+			t.Logf("failed to drop %s: %v", database.Name(), err)
+		}
+	})
+
+	if err := migrations.Run(ctx, database); err != nil {
+		t.Fatalf("failed to migrate %s: %v", database.Name(), err)
+	}
+	return database
+}
+
+// RequireTransactions skips the test unless the MongoDB server supports
+// transactions, which a standalone server does not.
+func RequireTransactions(t *testing.T, client *mongo.Client) {
+	t.Helper()
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := client.Database("admin").RunCommand(context.Background(), bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		t.Fatalf("failed to ask MongoDB about its topology: %v", err)
+	}
+	// A mongos router answers with msg isdbgrid
+	if hello.SetName == "" && hello.Msg != "isdbgrid" {
+		t.Skip("MongoDB transactions need a replica set or sharded cluster")
+	}
+}
@@ -0,0 +1,338 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestOrganizationRepository checks that an OrganizationRepository behaves
+// like the MongoDB one. newRepository must return an empty repository each
+// time it is called.
+func TestOrganizationRepository(t *testing.T, newRepository func(t *testing.T) organizations.OrganizationRepository) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepository(t)
+
+		created, err := repo.CreateOrganization(ctx, organizations.Organization{
+			Name:        "Acme Corporation",
+			Logo:        "https://example.com/logo.png",
+			OwnerUserID: "owner",
+		})
+		if err != nil {
+			t.Fatalf("CreateOrganization: %v", err)
+		}
+		if created.ID == "" {
+			t.Fatal("CreateOrganization did not assign an ID")
+		}
+		if created.CreatedAt.IsZero() || !created.UpdatedAt.Equal(created.CreatedAt) {
+			t.Errorf("CreateOrganization set created_at %v and updated_at %v", created.CreatedAt, created.UpdatedAt)
+		}
+
+		got, err := repo.GetOrganizationByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetOrganizationByID: %v", err)
+		}
+		checkOrganization(t, got, created)
+	})
+
+	t.Run("CreateWithID", func(t *testing.T) {
+		repo := newRepository(t)
+
+		id := primitive.NewObjectID().Hex()
+		created, err := repo.CreateOrganization(ctx, organizations.Organization{ID: id, Name: "Acme Corporation", OwnerUserID: "owner"})
+		if err != nil {
+			t.Fatalf("CreateOrganization: %v", err)
+		}
+		if created.ID != id {
+			t.Errorf("CreateOrganization assigned ID %s, want %s", created.ID, id)
+		}
+		if _, err := repo.GetOrganizationByID(ctx, id); err != nil {
+			t.Errorf("GetOrganizationByID: %v", err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepository(t)
+		createOrganization(t, repo, "Acme Corporation", "owner")
+
+		missing := primitive.NewObjectID().Hex()
+		_, err := repo.GetOrganizationByID(ctx, missing)
+		expectNotFound(t, "GetOrganizationByID", err)
+		err = repo.UpdateOrganization(ctx, missing, organizations.Organization{Name: "Missing", OwnerUserID: "owner"})
+		expectNotFound(t, "UpdateOrganization", err)
+		err = repo.PatchOrganization(ctx, missing, organizations.OrganizationChanges{Name: ptr("Missing")})
+		expectNotFound(t, "PatchOrganization", err)
+		err = repo.DeleteOrganization(ctx, missing)
+		expectNotFound(t, "DeleteOrganization", err)
+
+		owned, err := repo.GetOrganizationsByOwner(ctx, "nobody")
+		if err != nil || len(owned) != 0 {
+			t.Errorf("GetOrganizationsByOwner for an unknown owner returned %d organizations and error %v", len(owned), err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepository(t)
+		created := createOrganization(t, repo, "Acme Corporation", "owner")
+
+		organization, err := repo.GetOrganizationByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetOrganizationByID: %v", err)
+		}
+		organization.Name = "Acme Industries"
+		organization.Logo = "https://example.com/industries.png"
+		if err := repo.UpdateOrganization(ctx, organization.ID, *organization); err != nil {
+			t.Fatalf("UpdateOrganization: %v", err)
+		}
+
+		got, err := repo.GetOrganizationByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetOrganizationByID: %v", err)
+		}
+		checkOrganization(t, got, organization)
+		if got.UpdatedAt.Before(created.UpdatedAt) {
+			t.Errorf("UpdateOrganization moved updated_at back from %v to %v", created.UpdatedAt, got.UpdatedAt)
+		}
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		repo := newRepository(t)
+		created := createOrganization(t, repo, "Acme Corporation", "owner")
+
+		if err := repo.PatchOrganization(ctx, created.ID, organizations.OrganizationChanges{Name: ptr("Acme Industries")}); err != nil {
+			t.Fatalf("PatchOrganization: %v", err)
+		}
+		if err := repo.PatchOrganization(ctx, created.ID, organizations.OrganizationChanges{OwnerUserID: ptr("new-owner")}); err != nil {
+			t.Fatalf("PatchOrganization: %v", err)
+		}
+
+		got, err := repo.GetOrganizationByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetOrganizationByID: %v", err)
+		}
+		want := *created
+		want.Name = "Acme Industries"
+		want.OwnerUserID = "new-owner"
+		checkOrganization(t, got, &want)
+
+		owned, err := repo.GetOrganizationsByOwner(ctx, "new-owner")
+		if err != nil || len(owned) != 1 {
+			t.Errorf("GetOrganizationsByOwner for the new owner returned %d organizations and error %v", len(owned), err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepository(t)
+		created := createOrganization(t, repo, "Acme Corporation", "owner")
+
+		if err := repo.DeleteOrganization(ctx, created.ID); err != nil {
+			t.Fatalf("DeleteOrganization: %v", err)
+		}
+		_, err := repo.GetOrganizationByID(ctx, created.ID)
+		expectNotFound(t, "GetOrganizationByID after DeleteOrganization", err)
+		err = repo.DeleteOrganization(ctx, created.ID)
+		expectNotFound(t, "DeleteOrganization twice", err)
+	})
+
+	t.Run("SoftDelete", func(t *testing.T) {
+		repo := newRepository(t)
+		kept := createOrganization(t, repo, "Acme Corporation", "owner")
+		deleted := createOrganization(t, repo, "Acme Industries", "owner")
+
+		if err := repo.SoftDeleteOrganization(ctx, deleted.ID, time.Now()); err != nil {
+			t.Fatalf("SoftDeleteOrganization: %v", err)
+		}
+
+		_, err := repo.GetOrganizationByID(ctx, deleted.ID)
+		expectNotFound(t, "GetOrganizationByID after SoftDeleteOrganization", err)
+		err = repo.UpdateOrganization(ctx, deleted.ID, organizations.Organization{Name: "Restored", OwnerUserID: "owner"})
+		expectNotFound(t, "UpdateOrganization after SoftDeleteOrganization", err)
+		err = repo.PatchOrganization(ctx, deleted.ID, organizations.OrganizationChanges{Name: ptr("Restored")})
+		expectNotFound(t, "PatchOrganization after SoftDeleteOrganization", err)
+		err = repo.SoftDeleteOrganization(ctx, deleted.ID, time.Now())
+		expectNotFound(t, "SoftDeleteOrganization twice", err)
+
+		owned, err := repo.GetOrganizationsByOwner(ctx, "owner")
+		if err != nil {
+			t.Fatalf("GetOrganizationsByOwner: %v", err)
+		}
+		if got, want := ids(owned, organizationID), []string{kept.ID}; !sameIDs(got, want) {
+			t.Errorf("GetOrganizationsByOwner returned %v, want %v", got, want)
+		}
+
+		// Acme matches both names
+		filter := organizations.OrganizationFilter{Search: "acme"}
+		listed, err := repo.ListOrganizations(ctx, filter, pagination.Page{Limit: 10, Sort: pagination.Sort{Field: "created_at"}})
+		if err != nil {
+			t.Fatalf("ListOrganizations: %v", err)
+		}
+		if got, want := ids(listed, organizationID), []string{kept.ID}; !sameIDs(got, want) {
+			t.Errorf("ListOrganizations returned %v, want %v", got, want)
+		}
+		count, err := repo.CountOrganizations(ctx, filter)
+		if err != nil || count != 1 {
+			t.Errorf("CountOrganizations returned %d and error %v, want 1", count, err)
+		}
+	})
+
+	t.Run("GetOrganizationsByOwner", func(t *testing.T) {
+		repo := newRepository(t)
+		first := createOrganization(t, repo, "First", "owner")
+		createOrganization(t, repo, "Someone Else's", "other")
+		second := createOrganization(t, repo, "Second", "owner")
+
+		// Newest first
+		owned, err := repo.GetOrganizationsByOwner(ctx, "owner")
+		if err != nil {
+			t.Fatalf("GetOrganizationsByOwner: %v", err)
+		}
+		want := []string{second.ID, first.ID}
+		if got := ids(owned, organizationID); !sameIDs(got, want) {
+			t.Errorf("GetOrganizationsByOwner returned %v, want %v", got, want)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo := newRepository(t)
+		beta := createOrganization(t, repo, "beta", "owner")
+		alpha := createOrganization(t, repo, "Alpha", "owner")
+		delta := createOrganization(t, repo, "delta", "owner")
+		gamma := createOrganization(t, repo, "Gamma", "owner")
+
+		// Names sort regardless of case
+		want := map[pagination.Sort][]string{
+			{Field: "created_at"}:                   {beta.ID, alpha.ID, delta.ID, gamma.ID},
+			{Field: "created_at", Descending: true}: {gamma.ID, delta.ID, alpha.ID, beta.ID},
+			{Field: "name"}:                         {alpha.ID, beta.ID, delta.ID, gamma.ID},
+			{Field: "name", Descending: true}:       {gamma.ID, delta.ID, beta.ID, alpha.ID},
+		}
+
+		list := func(page pagination.Page) ([]organizations.Organization, error) {
+			return repo.ListOrganizations(ctx, organizations.OrganizationFilter{}, page)
+		}
+		for sort, wantIDs := range want {
+			keyOf := organizationKey(sort.Field)
+
+			all, err := list(pagination.Page{Limit: 100, Sort: sort})
+			if err != nil {
+				t.Fatalf("ListOrganizations by %+v: %v", sort, err)
+			}
+			checkOrder(t, all, sort, keyOf)
+			if got := ids(all, organizationID); !sameIDs(got, wantIDs) {
+				t.Errorf("ListOrganizations by %+v returned %v, want %v", sort, got, wantIDs)
+			}
+
+			paged := listAll(t, list, sort, 3, keyOf)
+			if got := ids(paged, organizationID); !sameIDs(got, wantIDs) {
+				t.Errorf("paging by %+v returned %v, want %v", sort, got, wantIDs)
+			}
+
+			offset, err := list(pagination.Page{Limit: 2, Offset: 1, Sort: sort})
+			if err != nil {
+				t.Fatalf("ListOrganizations by %+v with an offset: %v", sort, err)
+			}
+			if got := ids(offset, organizationID); !sameIDs(got, wantIDs[1:3]) {
+				t.Errorf("ListOrganizations by %+v with offset 1 and limit 2 returned %v, want %v", sort, got, wantIDs[1:3])
+			}
+		}
+
+		if _, err := list(pagination.Page{Limit: 10, Sort: pagination.Sort{Field: "logo"}}); err == nil {
+			t.Error("ListOrganizations sorted by an unknown field did not fail")
+		}
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		repo := newRepository(t)
+		acme := createOrganization(t, repo, "Acme Corporation", "owner")
+		river := createOrganization(t, repo, "River City Club", "owner")
+		rowing := createOrganization(t, repo, "Rowing Club", "owner")
+
+		// Stored times may be less precise than the ones returned on create
+		stored, err := repo.GetOrganizationByID(ctx, river.ID)
+		if err != nil {
+			t.Fatalf("GetOrganizationByID: %v", err)
+		}
+
+		tests := []struct {
+			name   string
+			filter organizations.OrganizationFilter
+			want   []string
+		}{
+			{"Everything", organizations.OrganizationFilter{}, []string{acme.ID, river.ID, rowing.ID}},
+			{"Search", organizations.OrganizationFilter{Search: "club"}, []string{river.ID, rowing.ID}},
+			{"SearchOneWord", organizations.OrganizationFilter{Search: "ACME"}, []string{acme.ID}},
+			{"NamePrefix", organizations.OrganizationFilter{NamePrefix: "r"}, []string{river.ID, rowing.ID}},
+			{"NamePrefixNoMatch", organizations.OrganizationFilter{NamePrefix: "club"}, nil},
+			{"CreatedAfter", organizations.OrganizationFilter{CreatedAfter: &stored.CreatedAt}, []string{river.ID, rowing.ID}},
+			{"CreatedBefore", organizations.OrganizationFilter{CreatedBefore: &stored.CreatedAt}, []string{acme.ID}},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				page := pagination.Page{Limit: 100, Sort: pagination.Sort{Field: "created_at"}}
+				got, err := repo.ListOrganizations(ctx, test.filter, page)
+				if err != nil {
+					t.Fatalf("ListOrganizations: %v", err)
+				}
+				if gotIDs := ids(got, organizationID); !sameIDs(gotIDs, test.want) {
+					t.Errorf("ListOrganizations returned %v, want %v", gotIDs, test.want)
+				}
+
+				count, err := repo.CountOrganizations(ctx, test.filter)
+				if err != nil {
+					t.Fatalf("CountOrganizations: %v", err)
+				}
+				if count != int64(len(test.want)) {
+					t.Errorf("CountOrganizations returned %d, want %d", count, len(test.want))
+				}
+			})
+		}
+	})
+}
+
+// createOrganization stores an organization, leaving a clear gap before the
+// next one is created so lists sorted by creation have a single right order.
+func createOrganization(t *testing.T, repo organizations.OrganizationRepository, name, ownerUserID string) *organizations.Organization {
+	t.Helper()
+
+	organization, err := repo.CreateOrganization(context.Background(), organizations.Organization{
+		Name:        name,
+		OwnerUserID: ownerUserID,
+	})
+	if err != nil {
+		t.Fatalf("CreateOrganization %s: %v", name, err)
+	}
+	distinctTimes()
+	return organization
+}
+
+func checkOrganization(t *testing.T, got, want *organizations.Organization) {
+	t.Helper()
+
+	if got.ID != want.ID || got.Name != want.Name || got.Logo != want.Logo || got.OwnerUserID != want.OwnerUserID {
+		t.Errorf("got organization %+v, want %+v", *got, *want)
+	}
+	if !sameTime(got.CreatedAt, want.CreatedAt) {
+		t.Errorf("got created_at %v, want %v", got.CreatedAt, want.CreatedAt)
+	}
+}
+
+func organizationID(organization organizations.Organization) string {
+	return organization.ID
+}
+
+// organizationKey returns an organization's value for a sort field, as the
+// services build cursors.
+func organizationKey(field string) func(organizations.Organization) (any, string) {
+	return func(organization organizations.Organization) (any, string) {
+		if field == "name" {
+			return organization.NameLower, organization.ID
+		}
+		return organization.CreatedAt, organization.ID
+	}
+}
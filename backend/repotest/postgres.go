@@ -0,0 +1,82 @@
+package repotest
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/migrations"
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PostgresURL returns the PostgreSQL server URL in POSTGRES_TEST_URL,
+// skipping the test when it is unset or the server can't be reached.
+func PostgresURL(t *testing.T) string {
+	t.Helper()
+
+	serverURL := os.Getenv("POSTGRES_TEST_URL")
+	if serverURL == "" {
+		t.Skip("set POSTGRES_TEST_URL to run against PostgreSQL")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	db, err := sqldb.Open(ctx, sqldb.Postgres, serverURL)
+	if err != nil {
+		t.Skipf("PostgreSQL is not available: %v", err)
+	}
+	db.Close()
+
+	return serverURL
+}
+
+// PostgresDB creates an empty, fully migrated schema for one test, connects
+// to it and drops it when the test ends.
+func PostgresDB(t *testing.T, serverURL string) *sqldb.DB {
+	t.Helper()
+
+	ctx := context.Background()
+	server, err := sqldb.Open(ctx, sqldb.Postgres, serverURL)
+	if err != nil {
+		t.Fatalf("failed to connect to PostgreSQL: %v", err)
+	}
+	t.Cleanup(func() {
+		server.Close()
+	})
+
+	schema := "easy_ballot_test_" + primitive.NewObjectID().Hex()
+	if _, err := server.ExecContext(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("failed to create schema %s: %v", schema, err)
+	}
+	t.Cleanup(func() {
+		if _, err := server.ExecContext(ctx, "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Logf("failed to drop %s: %v", schema, err)
+		}
+	})
+
+	schemaURL, err := url.Parse(serverURL)
+	if err != nil {
+		t.Fatalf("failed to parse POSTGRES_TEST_URL: %v", err)
+	}
+	query := schemaURL.Query()
+	query.Set("search_path", schema)
+	schemaURL.RawQuery = query.Encode()
+
+	db, err := sqldb.Open(ctx, sqldb.Postgres, schemaURL.String())
+	if err != nil {
+		t.Fatalf("failed to connect to schema %s: %v", schema, err)
+	}
+	// Registered after the schema cleanup, so it runs first
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	if err := migrations.RunSQL(ctx, db); err != nil {
+		t.Fatalf("failed to migrate %s: %v", schema, err)
+	}
+	return db
+}
@@ -0,0 +1,139 @@
+// Package repotest holds conformance tests that every implementation of a
+// repository interface must pass, so the storage backends stay
+// interchangeable. Each backend's package runs them against its own
+// implementation:
+//
+//	func TestMemoryUserRepository(t *testing.T) {
+//		repotest.TestUserRepository(t, func(t *testing.T) users.UserRepository {
+//			return users.NewMemoryUserRepository(memberships.NewMemoryMembershipRepository())
+//		})
+//	}
+package repotest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+)
+
+// timePrecision is the coarsest precision a backend may store times with.
+// MongoDB keeps milliseconds.
+const timePrecision = time.Millisecond
+
+// sameTime reports whether a stored time matches the time it was stored
+// from, allowing for the backend's precision.
+func sameTime(stored, original time.Time) bool {
+	d := stored.Sub(original)
+	return d > -timePrecision && d < timePrecision
+}
+
+// distinctTimes waits long enough for the next item stored to get a later
+// time than the last, whatever the backend's precision.
+func distinctTimes() {
+	time.Sleep(2 * timePrecision)
+}
+
+func expectNotFound(t *testing.T, operation string, err error) {
+	t.Helper()
+	if !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("%s: got error %v, want not found", operation, err)
+	}
+}
+
+func expectConflict(t *testing.T, operation string, err error) {
+	t.Helper()
+	if !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("%s: got error %v, want conflict", operation, err)
+	}
+}
+
+// listAll pages through a list the way the services do, following each
+// page's cursor until the last.
+func listAll[T any](t *testing.T, list func(page pagination.Page) ([]T, error), sort pagination.Sort, limit int, keyOf func(T) (any, string)) []T {
+	t.Helper()
+
+	page := pagination.Page{Limit: limit, Sort: sort}
+	var all []T
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatalf("paging by %+v did not end", sort)
+		}
+
+		items, err := list(page.Lookahead())
+		if err != nil {
+			t.Fatalf("listing by %+v: %v", sort, err)
+		}
+		items, next := pagination.NextPage(items, page, keyOf)
+		all = append(all, items...)
+		if next == "" {
+			return all
+		}
+
+		page.After, err = pagination.DecodeCursor(next)
+		if err != nil {
+			t.Fatalf("decoding cursor %s: %v", next, err)
+		}
+	}
+}
+
+// checkOrder fails the test unless the items are ordered by their sort value
+// and then their ID, as every list is.
+func checkOrder[T any](t *testing.T, items []T, sort pagination.Sort, keyOf func(T) (any, string)) {
+	t.Helper()
+
+	for i := 1; i < len(items); i++ {
+		prevValue, prevID := keyOf(items[i-1])
+		value, id := keyOf(items[i])
+
+		c := compare(prevValue, value)
+		if c == 0 {
+			c = strings.Compare(prevID, id)
+		}
+		if sort.Descending {
+			c = -c
+		}
+		if c >= 0 {
+			t.Errorf("sorted by %+v, item %d (%v, %s) comes after (%v, %s)", sort, i, value, id, prevValue, prevID)
+		}
+	}
+}
+
+func compare(a, b any) int {
+	switch a := a.(type) {
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case string:
+		return strings.Compare(a, b.(string))
+	default:
+		panic("repotest: unsupported sort value")
+	}
+}
+
+// ids returns the IDs of items in order.
+func ids[T any](items []T, idOf func(T) string) []string {
+	result := make([]string, len(items))
+	for i, item := range items {
+		result[i] = idOf(item)
+	}
+	return result
+}
+
+func sameIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
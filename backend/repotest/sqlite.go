@@ -0,0 +1,30 @@
+package repotest
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/migrations"
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+)
+
+// SQLiteDB creates an empty, fully migrated SQLite database for one test in
+// a temporary directory that is removed when the test ends.
+func SQLiteDB(t *testing.T) *sqldb.DB {
+	t.Helper()
+
+	ctx := context.Background()
+	db, err := sqldb.Open(ctx, sqldb.SQLite, filepath.Join(t.TempDir(), "easy_ballot.db"))
+	if err != nil {
+		t.Fatalf("failed to open SQLite database: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	if err := migrations.RunSQL(ctx, db); err != nil {
+		t.Fatalf("failed to migrate SQLite database: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,492 @@
+package repotest
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestUserRepository checks that a UserRepository behaves like the MongoDB
+// one. newRepository must return an empty repository each time it is called.
+func TestUserRepository(t *testing.T, newRepository func(t *testing.T) users.UserRepository) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepository(t)
+
+		created, err := repo.CreateUser(ctx, users.User{
+			FirstName:      "Ada",
+			LastName:       "Lovelace",
+			Email:          "Ada@Example.com",
+			Password:       "hash",
+			ProfilePicture: "https://example.com/ada.png",
+		})
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if created.ID == "" {
+			t.Fatal("CreateUser did not assign an ID")
+		}
+		if created.CreatedAt.IsZero() || !created.UpdatedAt.Equal(created.CreatedAt) {
+			t.Errorf("CreateUser set created_at %v and updated_at %v", created.CreatedAt, created.UpdatedAt)
+		}
+
+		got, err := repo.GetUserByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		checkUser(t, got, created)
+
+		// Emails match regardless of case
+		got, err = repo.GetUserByEmail(ctx, "ada@EXAMPLE.com")
+		if err != nil {
+			t.Fatalf("GetUserByEmail: %v", err)
+		}
+		checkUser(t, got, created)
+	})
+
+	t.Run("CreateWithID", func(t *testing.T) {
+		repo := newRepository(t)
+
+		id := primitive.NewObjectID().Hex()
+		created, err := repo.CreateUser(ctx, users.User{ID: id, FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com"})
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if created.ID != id {
+			t.Errorf("CreateUser assigned ID %s, want %s", created.ID, id)
+		}
+		if _, err := repo.GetUserByID(ctx, id); err != nil {
+			t.Errorf("GetUserByID: %v", err)
+		}
+	})
+
+	t.Run("DuplicateEmail", func(t *testing.T) {
+		repo := newRepository(t)
+
+		createUser(t, repo, "Grace", "Hopper", "grace@example.com")
+		_, err := repo.CreateUser(ctx, users.User{FirstName: "Grace", LastName: "Hopper", Email: "GRACE@example.com"})
+		expectConflict(t, "CreateUser", err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepository(t)
+		createUser(t, repo, "Ada", "Lovelace", "ada@example.com")
+
+		missing := primitive.NewObjectID().Hex()
+		_, err := repo.GetUserByID(ctx, missing)
+		expectNotFound(t, "GetUserByID", err)
+		_, err = repo.GetUserByEmail(ctx, "missing@example.com")
+		expectNotFound(t, "GetUserByEmail", err)
+		err = repo.UpdateUser(ctx, missing, users.User{FirstName: "Missing", LastName: "User", Email: "missing@example.com"})
+		expectNotFound(t, "UpdateUser", err)
+		err = repo.PatchUser(ctx, missing, users.UserChanges{FirstName: ptr("Missing")})
+		expectNotFound(t, "PatchUser", err)
+		err = repo.UpdatePassword(ctx, missing, "hash")
+		expectNotFound(t, "UpdatePassword", err)
+		err = repo.MarkEmailVerified(ctx, missing, time.Now())
+		expectNotFound(t, "MarkEmailVerified", err)
+		err = repo.DeleteUser(ctx, missing)
+		expectNotFound(t, "DeleteUser", err)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepository(t)
+		created := createUser(t, repo, "Ada", "Lovelace", "ada@example.com")
+		other := createUser(t, repo, "Grace", "Hopper", "grace@example.com")
+
+		user, err := repo.GetUserByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		changedAt := time.Now()
+		user.FirstName = "Augusta"
+		user.Email = "augusta@example.com"
+		user.ProfilePicture = "https://example.com/augusta.png"
+		user.PasswordChangedAt = &changedAt
+		if err := repo.UpdateUser(ctx, user.ID, *user); err != nil {
+			t.Fatalf("UpdateUser: %v", err)
+		}
+
+		got, err := repo.GetUserByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		checkUser(t, got, user)
+		if got.UpdatedAt.Before(created.UpdatedAt) {
+			t.Errorf("UpdateUser moved updated_at back from %v to %v", created.UpdatedAt, got.UpdatedAt)
+		}
+		if _, err := repo.GetUserByEmail(ctx, "augusta@example.com"); err != nil {
+			t.Errorf("GetUserByEmail with the new email: %v", err)
+		}
+		_, err = repo.GetUserByEmail(ctx, "ada@example.com")
+		expectNotFound(t, "GetUserByEmail with the old email", err)
+
+		user.Email = "Grace@Example.com"
+		err = repo.UpdateUser(ctx, user.ID, *user)
+		expectConflict(t, "UpdateUser to "+other.Email, err)
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		repo := newRepository(t)
+		created := createUser(t, repo, "Ada", "Lovelace", "ada@example.com")
+		createUser(t, repo, "Grace", "Hopper", "grace@example.com")
+
+		if err := repo.MarkEmailVerified(ctx, created.ID, time.Now()); err != nil {
+			t.Fatalf("MarkEmailVerified: %v", err)
+		}
+		if err := repo.PatchUser(ctx, created.ID, users.UserChanges{FirstName: ptr("Augusta")}); err != nil {
+			t.Fatalf("PatchUser: %v", err)
+		}
+
+		got, err := repo.GetUserByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		if got.FirstName != "Augusta" || got.LastName != "Lovelace" || got.Email != "ada@example.com" {
+			t.Errorf("after patching the first name, got %s %s <%s>", got.FirstName, got.LastName, got.Email)
+		}
+		if got.EmailVerifiedAt == nil {
+			t.Error("patching the first name cleared the email verification")
+		}
+		if got.PasswordChangedAt != nil {
+			t.Errorf("patching the first name set password changed at %v", got.PasswordChangedAt)
+		}
+
+		before := time.Now()
+		distinctTimes()
+		if err := repo.PatchUser(ctx, created.ID, users.UserChanges{PasswordHash: ptr("new-hash")}); err != nil {
+			t.Fatalf("PatchUser: %v", err)
+		}
+		got, err = repo.GetUserByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		if got.Password != "new-hash" || got.PasswordChangedAt == nil || !got.PasswordChangedAt.After(before) {
+			t.Errorf("after patching the password, got password %q changed at %v, want %q changed after %v", got.Password, got.PasswordChangedAt, "new-hash", before)
+		}
+
+		// A new email has to be verified again
+		if err := repo.PatchUser(ctx, created.ID, users.UserChanges{Email: ptr("augusta@example.com")}); err != nil {
+			t.Fatalf("PatchUser: %v", err)
+		}
+		got, err = repo.GetUserByEmail(ctx, "Augusta@example.com")
+		if err != nil {
+			t.Fatalf("GetUserByEmail: %v", err)
+		}
+		if got.ID != created.ID || got.EmailVerifiedAt != nil {
+			t.Errorf("after patching the email, got user %s verified at %v", got.ID, got.EmailVerifiedAt)
+		}
+
+		err = repo.PatchUser(ctx, created.ID, users.UserChanges{Email: ptr("GRACE@example.com")})
+		expectConflict(t, "PatchUser", err)
+	})
+
+	t.Run("UpdatePasswordAndVerification", func(t *testing.T) {
+		repo := newRepository(t)
+		created := createUser(t, repo, "Ada", "Lovelace", "ada@example.com")
+
+		verifiedAt := time.Now()
+		distinctTimes()
+		if err := repo.UpdatePassword(ctx, created.ID, "new-hash"); err != nil {
+			t.Fatalf("UpdatePassword: %v", err)
+		}
+		if err := repo.MarkEmailVerified(ctx, created.ID, verifiedAt); err != nil {
+			t.Fatalf("MarkEmailVerified: %v", err)
+		}
+
+		got, err := repo.GetUserByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		if got.Password != "new-hash" {
+			t.Errorf("got password %q, want %q", got.Password, "new-hash")
+		}
+		if got.PasswordChangedAt == nil || !got.PasswordChangedAt.After(verifiedAt) {
+			t.Errorf("got password changed at %v, want after %v", got.PasswordChangedAt, verifiedAt)
+		}
+		if got.EmailVerifiedAt == nil || !sameTime(*got.EmailVerifiedAt, verifiedAt) {
+			t.Errorf("got email verified at %v, want %v", got.EmailVerifiedAt, verifiedAt)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepository(t)
+		created := createUser(t, repo, "Ada", "Lovelace", "ada@example.com")
+
+		if err := repo.DeleteUser(ctx, created.ID); err != nil {
+			t.Fatalf("DeleteUser: %v", err)
+		}
+		_, err := repo.GetUserByID(ctx, created.ID)
+		expectNotFound(t, "GetUserByID after DeleteUser", err)
+		err = repo.DeleteUser(ctx, created.ID)
+		expectNotFound(t, "DeleteUser twice", err)
+
+		// The email is free again
+		createUser(t, repo, "Ada", "Lovelace", "ada@example.com")
+	})
+
+	t.Run("GetUsersByIDs", func(t *testing.T) {
+		repo := newRepository(t)
+		ada := createUser(t, repo, "Ada", "Lovelace", "ada@example.com")
+		createUser(t, repo, "Grace", "Hopper", "grace@example.com")
+		alan := createUser(t, repo, "Alan", "Turing", "alan@example.com")
+
+		got, err := repo.GetUsersByIDs(ctx, []string{alan.ID, ada.ID, primitive.NewObjectID().Hex()})
+		if err != nil {
+			t.Fatalf("GetUsersByIDs: %v", err)
+		}
+		gotIDs := ids(got, userID)
+		sort.Strings(gotIDs)
+		wantIDs := []string{ada.ID, alan.ID}
+		sort.Strings(wantIDs)
+		if !sameIDs(gotIDs, wantIDs) {
+			t.Errorf("GetUsersByIDs returned %v, want %v", gotIDs, wantIDs)
+		}
+
+		got, err = repo.GetUsersByIDs(ctx, nil)
+		if err != nil || len(got) != 0 {
+			t.Errorf("GetUsersByIDs with no IDs returned %d users and error %v", len(got), err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo := newRepository(t)
+		alice := createUser(t, repo, "alice", "Smith", "alice@example.com")
+		bob := createUser(t, repo, "Bob", "smith", "bob@example.com")
+		carol := createUser(t, repo, "carol", "Jones", "Carol@example.com")
+		dave := createUser(t, repo, "Dave", "Brown", "dave@example.com")
+		eve := createUser(t, repo, "eve", "Adams", "eve@example.com")
+
+		// Names and emails sort regardless of case, with ties broken by ID
+		want := map[pagination.Sort][]string{
+			{Field: "created_at"}:                   {alice.ID, bob.ID, carol.ID, dave.ID, eve.ID},
+			{Field: "created_at", Descending: true}: {eve.ID, dave.ID, carol.ID, bob.ID, alice.ID},
+			{Field: "first_name"}:                   {alice.ID, bob.ID, carol.ID, dave.ID, eve.ID},
+			{Field: "first_name", Descending: true}: {eve.ID, dave.ID, carol.ID, bob.ID, alice.ID},
+			{Field: "email"}:                        {alice.ID, bob.ID, carol.ID, dave.ID, eve.ID},
+		}
+		if alice.ID < bob.ID {
+			want[pagination.Sort{Field: "last_name"}] = []string{eve.ID, dave.ID, carol.ID, alice.ID, bob.ID}
+			want[pagination.Sort{Field: "last_name", Descending: true}] = []string{bob.ID, alice.ID, carol.ID, dave.ID, eve.ID}
+		} else {
+			want[pagination.Sort{Field: "last_name"}] = []string{eve.ID, dave.ID, carol.ID, bob.ID, alice.ID}
+			want[pagination.Sort{Field: "last_name", Descending: true}] = []string{alice.ID, bob.ID, carol.ID, dave.ID, eve.ID}
+		}
+
+		list := func(page pagination.Page) ([]users.User, error) {
+			return repo.ListUsers(ctx, users.UserFilter{}, page)
+		}
+		for sort, wantIDs := range want {
+			keyOf := userKey(sort.Field)
+
+			all, err := list(pagination.Page{Limit: 100, Sort: sort})
+			if err != nil {
+				t.Fatalf("ListUsers by %+v: %v", sort, err)
+			}
+			checkOrder(t, all, sort, keyOf)
+			if got := ids(all, userID); !sameIDs(got, wantIDs) {
+				t.Errorf("ListUsers by %+v returned %v, want %v", sort, got, wantIDs)
+			}
+
+			paged := listAll(t, list, sort, 2, keyOf)
+			if got := ids(paged, userID); !sameIDs(got, wantIDs) {
+				t.Errorf("paging by %+v returned %v, want %v", sort, got, wantIDs)
+			}
+
+			offset, err := list(pagination.Page{Limit: 2, Offset: 1, Sort: sort})
+			if err != nil {
+				t.Fatalf("ListUsers by %+v with an offset: %v", sort, err)
+			}
+			if got := ids(offset, userID); !sameIDs(got, wantIDs[1:3]) {
+				t.Errorf("ListUsers by %+v with offset 1 and limit 2 returned %v, want %v", sort, got, wantIDs[1:3])
+			}
+		}
+
+		if _, err := list(pagination.Page{Limit: 10, Sort: pagination.Sort{Field: "password"}}); err == nil {
+			t.Error("ListUsers sorted by an unknown field did not fail")
+		}
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		repo := newRepository(t)
+		alice := createUser(t, repo, "alice", "Smith", "alice@example.com")
+		bob := createUser(t, repo, "Bob", "smith", "bob@example.com")
+		carol := createUser(t, repo, "carol", "Jones", "Carol@example.com")
+		dave := createUser(t, repo, "Dave", "Brown", "dave@example.com")
+		eve := createUser(t, repo, "eve", "Adams", "eve@example.com")
+
+		// Stored times may be less precise than the ones returned on create
+		stored, err := repo.GetUserByID(ctx, carol.ID)
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+
+		tests := []struct {
+			name   string
+			filter users.UserFilter
+			want   []string
+		}{
+			{"Everyone", users.UserFilter{}, []string{alice.ID, bob.ID, carol.ID, dave.ID, eve.ID}},
+			{"Search", users.UserFilter{Search: "SMITH"}, []string{alice.ID, bob.ID}},
+			{"FirstNamePrefix", users.UserFilter{NamePrefix: "Ca"}, []string{carol.ID}},
+			{"LastNamePrefix", users.UserFilter{NamePrefix: "sm"}, []string{alice.ID, bob.ID}},
+			{"EmailPrefix", users.UserFilter{EmailPrefix: "CAROL@"}, []string{carol.ID}},
+			{"CreatedAfter", users.UserFilter{CreatedAfter: &stored.CreatedAt}, []string{carol.ID, dave.ID, eve.ID}},
+			{"CreatedBefore", users.UserFilter{CreatedBefore: &stored.CreatedAt}, []string{alice.ID, bob.ID}},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				page := pagination.Page{Limit: 100, Sort: pagination.Sort{Field: "created_at"}}
+				got, err := repo.ListUsers(ctx, test.filter, page)
+				if err != nil {
+					t.Fatalf("ListUsers: %v", err)
+				}
+				if gotIDs := ids(got, userID); !sameIDs(gotIDs, test.want) {
+					t.Errorf("ListUsers returned %v, want %v", gotIDs, test.want)
+				}
+
+				count, err := repo.CountUsers(ctx, test.filter)
+				if err != nil {
+					t.Fatalf("CountUsers: %v", err)
+				}
+				if count != int64(len(test.want)) {
+					t.Errorf("CountUsers returned %d, want %d", count, len(test.want))
+				}
+			})
+		}
+	})
+}
+
+// TestUserMemberFilter checks that a UserRepository filters users by
+// organization and role like the MongoDB one. newStores must return empty
+// repositories sharing one backend's storage each time it is called.
+func TestUserMemberFilter(t *testing.T, newStores func(t *testing.T) (users.UserRepository, memberships.MembershipRepository)) {
+	ctx := context.Background()
+
+	repo, membershipRepo := newStores(t)
+	alice := createUser(t, repo, "Alice", "Smith", "alice@example.com")
+	bob := createUser(t, repo, "Bob", "Smith", "bob@example.com")
+	carol := createUser(t, repo, "Carol", "Jones", "carol@example.com")
+	dave := createUser(t, repo, "Dave", "Brown", "dave@example.com")
+
+	organizationID := primitive.NewObjectID().Hex()
+	otherID := primitive.NewObjectID().Hex()
+	for _, membership := range []memberships.Membership{
+		{OrganizationID: organizationID, UserID: alice.ID, Role: users.RoleOwner},
+		{OrganizationID: organizationID, UserID: bob.ID, Role: users.RoleVoter},
+		{OrganizationID: organizationID, UserID: carol.ID, Role: users.RoleVoter},
+		{OrganizationID: otherID, UserID: dave.ID, Role: users.RoleVoter},
+		// A membership whose user no longer exists
+		{OrganizationID: organizationID, UserID: primitive.NewObjectID().Hex(), Role: users.RoleVoter},
+	} {
+		if _, err := membershipRepo.CreateMembership(ctx, membership); err != nil {
+			t.Fatalf("CreateMembership: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter users.UserFilter
+		page   pagination.Page
+		want   []string
+		total  int64
+	}{
+		{"Members", users.UserFilter{OrganizationID: organizationID}, pagination.Page{Limit: 10}, []string{alice.ID, bob.ID, carol.ID}, 3},
+		{"Role", users.UserFilter{OrganizationID: organizationID, Role: users.RoleVoter}, pagination.Page{Limit: 10}, []string{bob.ID, carol.ID}, 2},
+		{"RoleWithoutMembers", users.UserFilter{OrganizationID: organizationID, Role: users.RoleAdmin}, pagination.Page{Limit: 10}, nil, 0},
+		{"WithSearch", users.UserFilter{OrganizationID: organizationID, Search: "smith"}, pagination.Page{Limit: 10}, []string{alice.ID, bob.ID}, 2},
+		{"OtherOrganization", users.UserFilter{OrganizationID: otherID}, pagination.Page{Limit: 10}, []string{dave.ID}, 1},
+		{"UnknownOrganization", users.UserFilter{OrganizationID: primitive.NewObjectID().Hex()}, pagination.Page{Limit: 10}, nil, 0},
+		{"Limit", users.UserFilter{OrganizationID: organizationID}, pagination.Page{Limit: 2}, []string{alice.ID, bob.ID}, 3},
+		{"Offset", users.UserFilter{OrganizationID: organizationID}, pagination.Page{Limit: 2, Offset: 1}, []string{bob.ID, carol.ID}, 3},
+		{"Descending", users.UserFilter{OrganizationID: organizationID}, pagination.Page{Limit: 1, Sort: pagination.Sort{Field: "created_at", Descending: true}}, []string{carol.ID}, 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page := test.page
+			if page.Sort.Field == "" {
+				page.Sort.Field = "created_at"
+			}
+			got, err := repo.ListUsers(ctx, test.filter, page)
+			if err != nil {
+				t.Fatalf("ListUsers: %v", err)
+			}
+			if gotIDs := ids(got, userID); !sameIDs(gotIDs, test.want) {
+				t.Errorf("ListUsers returned %v, want %v", gotIDs, test.want)
+			}
+
+			count, err := repo.CountUsers(ctx, test.filter)
+			if err != nil {
+				t.Fatalf("CountUsers: %v", err)
+			}
+			if count != test.total {
+				t.Errorf("CountUsers returned %d, want %d", count, test.total)
+			}
+		})
+	}
+}
+
+// createUser stores a user, leaving a clear gap before the next one is
+// created so lists sorted by creation have a single right order.
+func createUser(t *testing.T, repo users.UserRepository, firstName, lastName, email string) *users.User {
+	t.Helper()
+
+	user, err := repo.CreateUser(context.Background(), users.User{
+		FirstName: firstName,
+		LastName:  lastName,
+		Email:     email,
+		Password:  "hash",
+	})
+	if err != nil {
+		t.Fatalf("CreateUser %s: %v", email, err)
+	}
+	distinctTimes()
+	return user
+}
+
+func checkUser(t *testing.T, got, want *users.User) {
+	t.Helper()
+
+	if got.ID != want.ID || got.FirstName != want.FirstName || got.LastName != want.LastName ||
+		got.Email != want.Email || got.Password != want.Password || got.ProfilePicture != want.ProfilePicture {
+		t.Errorf("got user %+v, want %+v", *got, *want)
+	}
+	if (got.EmailVerifiedAt == nil) != (want.EmailVerifiedAt == nil) {
+		t.Errorf("got email verified at %v, want %v", got.EmailVerifiedAt, want.EmailVerifiedAt)
+	}
+	if (got.PasswordChangedAt == nil) != (want.PasswordChangedAt == nil) ||
+		got.PasswordChangedAt != nil && !sameTime(*got.PasswordChangedAt, *want.PasswordChangedAt) {
+		t.Errorf("got password changed at %v, want %v", got.PasswordChangedAt, want.PasswordChangedAt)
+	}
+	if !sameTime(got.CreatedAt, want.CreatedAt) {
+		t.Errorf("got created_at %v, want %v", got.CreatedAt, want.CreatedAt)
+	}
+}
+
+func userID(user users.User) string {
+	return user.ID
+}
+
+// userKey returns a user's value for a sort field, as the services build
+// cursors.
+func userKey(field string) func(users.User) (any, string) {
+	return func(user users.User) (any, string) {
+		switch field {
+		case "first_name":
+			return user.FirstNameLower, user.ID
+		case "last_name":
+			return user.LastNameLower, user.ID
+		case "email":
+			return user.EmailLower, user.ID
+		default:
+			return user.CreatedAt, user.ID
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/services/votes"
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestBallotBoxRepository checks that a BallotBoxRepository behaves like the
+// MongoDB one. newRepo must return an empty repository each time it is
+// called.
+func TestBallotBoxRepository(t *testing.T, newRepo func(t *testing.T) votes.BallotBoxRepository) {
+	ctx := context.Background()
+
+	t.Run("Cast", func(t *testing.T) {
+		repo := newRepo(t)
+		ballotID := primitive.NewObjectID().Hex()
+		otherBallotID := primitive.NewObjectID().Hex()
+
+		cast := castSecretVote(t, repo, ballotID, "voter", "receipt")
+		if cast.ID == "" {
+			t.Error("CastSecretVote returned a vote without an ID")
+		}
+		if cast.VoterID != "" {
+			t.Errorf("CastSecretVote returned a vote with voter ID %q", cast.VoterID)
+		}
+		if !isDay(cast.CreatedAt) {
+			t.Errorf("CastSecretVote returned created_at %v, want a UTC day", cast.CreatedAt)
+		}
+
+		for _, tc := range []struct {
+			ballotID, voterID string
+			want              bool
+		}{
+			{ballotID, "voter", true},
+			{ballotID, "other", false},
+			{otherBallotID, "voter", false},
+		} {
+			participated, err := repo.HasParticipated(ctx, tc.ballotID, tc.voterID)
+			if err != nil || participated != tc.want {
+				t.Errorf("HasParticipated(%s) returned %v and error %v, want %v", tc.voterID, participated, err, tc.want)
+			}
+		}
+
+		for _, tc := range []struct {
+			ballotID, receipt string
+			want              bool
+		}{
+			{ballotID, "receipt", true},
+			{ballotID, "missing", false},
+			{otherBallotID, "receipt", false},
+		} {
+			found, err := repo.HasReceipt(ctx, tc.ballotID, tc.receipt)
+			if err != nil || found != tc.want {
+				t.Errorf("HasReceipt(%s) returned %v and error %v, want %v", tc.receipt, found, err, tc.want)
+			}
+		}
+
+		if count, err := repo.CountVotes(ctx, ballotID); err != nil || count != 1 {
+			t.Errorf("CountVotes returned %d and error %v, want 1", count, err)
+		}
+		if count, err := repo.CountVotes(ctx, otherBallotID); err != nil || count != 0 {
+			t.Errorf("CountVotes for another ballot returned %d and error %v, want 0", count, err)
+		}
+
+		listed, err := repo.ListVotes(ctx, ballotID)
+		if err != nil {
+			t.Fatalf("ListVotes: %v", err)
+		}
+		if len(listed) != 1 {
+			t.Fatalf("ListVotes returned %d votes, want 1", len(listed))
+		}
+		stored := listed[0]
+		if stored.ID != cast.ID || stored.BallotID != ballotID || stored.VoterID != "" {
+			t.Errorf("ListVotes returned ID %q, ballot %q and voter %q, want %q, %q and none", stored.ID, stored.BallotID, stored.VoterID, cast.ID, ballotID)
+		}
+		if stored.Receipt != "receipt" || stored.ReceiptNonce != "nonce" {
+			t.Errorf("ListVotes returned receipt %q and nonce %q, want %q and %q", stored.Receipt, stored.ReceiptNonce, "receipt", "nonce")
+		}
+		if !reflect.DeepEqual(stored.Selections, cast.Selections) {
+			t.Errorf("ListVotes returned selections %+v, want %+v", stored.Selections, cast.Selections)
+		}
+		if !stored.CreatedAt.Equal(cast.CreatedAt) {
+			t.Errorf("ListVotes returned created_at %v, want %v", stored.CreatedAt, cast.CreatedAt)
+		}
+
+		if listed, err := repo.ListVotes(ctx, otherBallotID); err != nil || len(listed) != 0 {
+			t.Errorf("ListVotes for another ballot returned %d votes and error %v, want none", len(listed), err)
+		}
+	})
+
+	t.Run("AlreadyVoted", func(t *testing.T) {
+		repo := newRepo(t)
+		ballotID := primitive.NewObjectID().Hex()
+
+		castSecretVote(t, repo, ballotID, "voter", "first")
+		_, err := repo.CastSecretVote(ctx, votes.Participation{BallotID: ballotID, VoterID: "voter"}, secretVote(ballotID, "voter", "second"))
+		if !errors.Is(err, votes.ErrAlreadyVoted) {
+			t.Errorf("CastSecretVote twice: got error %v, want ErrAlreadyVoted", err)
+		}
+
+		if count, err := repo.CountVotes(ctx, ballotID); err != nil || count != 1 {
+			t.Errorf("CountVotes returned %d and error %v, want 1", count, err)
+		}
+		if found, err := repo.HasReceipt(ctx, ballotID, "second"); err != nil || found {
+			t.Errorf("HasReceipt for the rejected vote returned %v and error %v, want false", found, err)
+		}
+	})
+
+	t.Run("Unordered", func(t *testing.T) {
+		repo := newRepo(t)
+		ballotID := primitive.NewObjectID().Hex()
+
+		// Some order other than the casting order is all but certain
+		const voters = 12
+		var castOrder []string
+		for i := 0; i < voters; i++ {
+			receipt := fmt.Sprintf("receipt-%02d", i)
+			castSecretVote(t, repo, ballotID, fmt.Sprintf("voter-%02d", i), receipt)
+			castOrder = append(castOrder, receipt)
+		}
+
+		listed, err := repo.ListVotes(ctx, ballotID)
+		if err != nil {
+			t.Fatalf("ListVotes: %v", err)
+		}
+		var listOrder []string
+		for _, vote := range listed {
+			listOrder = append(listOrder, vote.Receipt)
+			if !vote.CreatedAt.Equal(listed[0].CreatedAt) {
+				t.Errorf("ListVotes returned created_at %v and %v, want one day for every vote", vote.CreatedAt, listed[0].CreatedAt)
+			}
+		}
+		if len(listOrder) != voters {
+			t.Fatalf("ListVotes returned %d votes, want %d", len(listOrder), voters)
+		}
+		if reflect.DeepEqual(listOrder, castOrder) {
+			t.Errorf("ListVotes returned the votes in the order they were cast")
+		}
+	})
+}
+
+// TestBallotBoxTables casts votes through a SQL ballot box and reads its
+// tables directly, checking that no column of a participation also appears on
+// the ballot box, and that SQLite does not number the ballot box's rows.
+func TestBallotBoxTables(t *testing.T, db *sqldb.DB, repo votes.BallotBoxRepository) {
+	ctx := context.Background()
+	ballotID := primitive.NewObjectID().Hex()
+
+	for i := 0; i < 12; i++ {
+		castSecretVote(t, repo, ballotID, fmt.Sprintf("voter-%02d", i), fmt.Sprintf("receipt-%02d", i))
+	}
+
+	// Rowids count up in insertion order, like the participations' do
+	if db.Dialect == sqldb.SQLite {
+		if _, err := db.ExecContext(ctx, "SELECT rowid FROM ballot_box"); err == nil {
+			t.Error("ballot_box has rowids")
+		}
+	}
+
+	q := db.Query()
+	rows, err := db.QueryContext(ctx, "SELECT id, voter_id, created_at FROM participations WHERE ballot_id = "+q.Arg(ballotID), q.Args...)
+	if err != nil {
+		t.Fatalf("failed to read participations: %v", err)
+	}
+	var participations []votes.Participation
+	for rows.Next() {
+		var participation votes.Participation
+		if err := rows.Scan(&participation.ID, &participation.VoterID, &participation.CreatedAt); err != nil {
+			t.Fatalf("failed to read participations: %v", err)
+		}
+		if !isDay(participation.CreatedAt) {
+			t.Errorf("participation %s has created_at %v, want a UTC day", participation.ID, participation.CreatedAt)
+		}
+		participations = append(participations, participation)
+	}
+	rows.Close()
+	if len(participations) != 12 {
+		t.Fatalf("read %d participations, want 12", len(participations))
+	}
+
+	q = db.Query()
+	rows, err = db.QueryContext(ctx, "SELECT id, votes FROM ballot_box WHERE ballot_id = "+q.Arg(ballotID), q.Args...)
+	if err != nil {
+		t.Fatalf("failed to read ballot_box: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, boxed string
+		if err := rows.Scan(&id, &boxed); err != nil {
+			t.Fatalf("failed to read ballot_box: %v", err)
+		}
+		for _, participation := range participations {
+			for _, value := range []string{participation.ID, participation.VoterID} {
+				if strings.Contains(id, value) || strings.Contains(boxed, value) {
+					t.Errorf("ballot box row %s contains participation value %q", id, value)
+				}
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("failed to read ballot_box: %v", err)
+	}
+}
+
+func castSecretVote(t *testing.T, repo votes.BallotBoxRepository, ballotID, voterID, receipt string) *votes.Vote {
+	t.Helper()
+
+	cast, err := repo.CastSecretVote(context.Background(), votes.Participation{BallotID: ballotID, VoterID: voterID}, secretVote(ballotID, voterID, receipt))
+	if err != nil {
+		t.Fatalf("CastSecretVote: %v", err)
+	}
+	return cast
+}
+
+// secretVote builds a vote as the vote service passes it in, still carrying
+// the voter's ID for the repository to strip.
+func secretVote(ballotID, voterID, receipt string) votes.Vote {
+	return votes.Vote{
+		BallotID:     ballotID,
+		VoterID:      voterID,
+		Selections:   []votes.Selection{{QuestionID: "chair", OptionIDs: []string{"ada"}}},
+		Receipt:      receipt,
+		ReceiptNonce: "nonce",
+	}
+}
+
+// isDay reports whether a time is midnight UTC, the only precision secret
+// votes and participations are stored with.
+func isDay(t time.Time) bool {
+	return t.Equal(t.UTC().Truncate(24 * time.Hour))
+}
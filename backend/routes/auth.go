@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	authHandlers "github.com/bpalazzi512/easy-ballot/backend/handlers/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/types"
+	"github.com/gorilla/mux"
+)
+
+// RegisterAuthRoutes registers all authentication-related routes
+func RegisterAuthRoutes(router *mux.Router, protected *mux.Router, handler *authHandlers.Handler) {
+	router.HandleFunc("/auth/login", handler.Login).Methods("POST")
+	router.HandleFunc("/auth/refresh", handler.Refresh).Methods("POST")
+	router.HandleFunc("/auth/forgot", handler.ForgotPassword).Methods("POST")
+	router.HandleFunc("/auth/reset", handler.ResetPassword).Methods("POST")
+	router.HandleFunc("/auth/verify", handler.VerifyEmail).Methods("POST")
+	protected.HandleFunc("/auth/me", handler.Me).Methods("GET")
+	protected.HandleFunc("/auth/verify/resend", handler.ResendVerification).Methods("POST")
+}
+
+// AuthMiddleware rejects requests without a valid bearer token and stores the
+// authenticated user in the request context
+func AuthMiddleware(authService *auth.AuthService) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				writeUnauthorized(w, "authentication required")
+				return
+			}
+
+			user, err := authService.Authenticate(r.Context(), token)
+			if err != nil {
+				writeUnauthorized(w, err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+		})
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func writeUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(types.APIResponse{
+		Success: false,
+		Message: message,
+	})
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	ballotHandlers "github.com/bpalazzi512/easy-ballot/backend/handlers/ballots"
+	"github.com/gorilla/mux"
+)
+
+// RegisterBallotRoutes registers all ballot-related routes
+func RegisterBallotRoutes(router *mux.Router, handler *ballotHandlers.Handler) {
+	// Ballot CRUD endpoints
+	router.HandleFunc("/ballots", handler.CreateBallot).Methods("POST")
+	router.HandleFunc("/ballots", handler.ListBallots).Methods("GET")
+	router.HandleFunc("/ballots/{id}", handler.GetBallot).Methods("GET")
+	router.HandleFunc("/ballots/{id}", handler.UpdateBallot).Methods("PUT")
+	router.HandleFunc("/ballots/{id}", handler.DeleteBallot).Methods("DELETE")
+
+	// Ballot lifecycle endpoints
+	router.HandleFunc("/ballots/{id}/open", handler.OpenBallot).Methods("POST")
+	router.HandleFunc("/ballots/{id}/close", handler.CloseBallot).Methods("POST")
+
+	// Voter roll endpoints
+	router.HandleFunc("/ballots/{id}/eligibility", handler.SetEligibility).Methods("PUT")
+	router.HandleFunc("/ballots/{id}/roll", handler.GetRoll).Methods("GET")
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	invitationHandlers "github.com/bpalazzi512/easy-ballot/backend/handlers/invitations"
+	"github.com/gorilla/mux"
+)
+
+// RegisterInvitationRoutes registers all invitation-related routes. Accepting
+// an invitation is public so that invitees without an account can join.
+func RegisterInvitationRoutes(router *mux.Router, protected *mux.Router, handler *invitationHandlers.Handler) {
+	router.HandleFunc("/invitations/accept", handler.AcceptInvitation).Methods("POST")
+
+	protected.HandleFunc("/organizations/{id}/invitations", handler.CreateInvitation).Methods("POST")
+	protected.HandleFunc("/organizations/{id}/invitations", handler.ListInvitations).Methods("GET")
+	protected.HandleFunc("/organizations/{id}/invitations/{invitationId}/resend", handler.ResendInvitation).Methods("POST")
+	protected.HandleFunc("/organizations/{id}/invitations/{invitationId}", handler.RevokeInvitation).Methods("DELETE")
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	membershipHandlers "github.com/bpalazzi512/easy-ballot/backend/handlers/memberships"
+	"github.com/gorilla/mux"
+)
+
+// RegisterMembershipRoutes registers all membership-related routes
+func RegisterMembershipRoutes(router *mux.Router, handler *membershipHandlers.Handler) {
+	router.HandleFunc("/memberships", handler.ListMyMemberships).Methods("GET")
+	router.HandleFunc("/organizations/{id}/members", handler.ListMembers).Methods("GET")
+	router.HandleFunc("/organizations/{id}/members", handler.AddMember).Methods("POST")
+	router.HandleFunc("/organizations/{id}/members/{userId}", handler.UpdateMember).Methods("PUT")
+	router.HandleFunc("/organizations/{id}/members/{userId}", handler.RemoveMember).Methods("DELETE")
+}
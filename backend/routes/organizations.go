@@ -13,5 +13,6 @@ func RegisterOrganizationRoutes(router *mux.Router, handler *organizationHandler
 	router.HandleFunc("/organizations/owner", handler.GetOrganizationsByOwner).Methods("GET")
 	router.HandleFunc("/organizations/{id}", handler.GetOrganization).Methods("GET")
 	router.HandleFunc("/organizations/{id}", handler.UpdateOrganization).Methods("PUT")
+	router.HandleFunc("/organizations/{id}", handler.PatchOrganization).Methods("PATCH")
 	router.HandleFunc("/organizations/{id}", handler.DeleteOrganization).Methods("DELETE")
 }
@@ -6,11 +6,14 @@ import (
 )
 
 // RegisterUserRoutes registers all user-related routes
-func RegisterUserRoutes(router *mux.Router, handler *userHandlers.Handler) {
-    // User CRUD endpoints
+func RegisterUserRoutes(router *mux.Router, protected *mux.Router, handler *userHandlers.Handler) {
+    // Sign-up stays public; everything else requires authentication
     router.HandleFunc("/users", handler.CreateUser).Methods("POST")
-    router.HandleFunc("/users", handler.ListUsers).Methods("GET")
-    router.HandleFunc("/users/{id}", handler.GetUser).Methods("GET")
-    router.HandleFunc("/users/{id}", handler.UpdateUser).Methods("PUT")
-    router.HandleFunc("/users/{id}", handler.DeleteUser).Methods("DELETE")
+
+    // User CRUD endpoints
+    protected.HandleFunc("/users", handler.ListUsers).Methods("GET")
+    protected.HandleFunc("/users/{id}", handler.GetUser).Methods("GET")
+    protected.HandleFunc("/users/{id}", handler.UpdateUser).Methods("PUT")
+    protected.HandleFunc("/users/{id}", handler.PatchUser).Methods("PATCH")
+    protected.HandleFunc("/users/{id}", handler.DeleteUser).Methods("DELETE")
 }
\ No newline at end of file
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	voteHandlers "github.com/bpalazzi512/easy-ballot/backend/handlers/votes"
+	"github.com/gorilla/mux"
+)
+
+// RegisterVoteRoutes registers all vote-related routes
+func RegisterVoteRoutes(router *mux.Router, handler *voteHandlers.Handler) {
+	router.HandleFunc("/ballots/{id}/votes", handler.CastVote).Methods("POST")
+	router.HandleFunc("/ballots/{id}/results", handler.GetResults).Methods("GET")
+	router.HandleFunc("/ballots/{id}/turnout", handler.GetTurnout).Methods("GET")
+	router.HandleFunc("/ballots/{id}/receipts", handler.GetReceipts).Methods("GET")
+	router.HandleFunc("/ballots/{id}/receipts/{code}", handler.CheckReceipt).Methods("GET")
+}
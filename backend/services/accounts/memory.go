@@ -0,0 +1,80 @@
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryTokenRepository keeps tokens in memory, for tests and for running the
+// server without a database. It is safe for concurrent use. Expired tokens
+// are dropped as new ones are created, as the TTL index does in MongoDB.
+type MemoryTokenRepository struct {
+	mu     sync.Mutex
+	tokens []Token
+}
+
+func NewMemoryTokenRepository() *MemoryTokenRepository {
+	return &MemoryTokenRepository{}
+}
+
+func (r *MemoryTokenRepository) CreateToken(ctx context.Context, token Token) (*Token, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token.CreatedAt = time.Now()
+
+	if token.ID == "" {
+		token.ID = primitive.NewObjectID().Hex()
+	}
+
+	r.removeWhere(func(stored Token) bool {
+		return stored.ExpiresAt.Before(token.CreatedAt)
+	})
+	for _, stored := range r.tokens {
+		if stored.ID == token.ID || stored.TokenHash == token.TokenHash {
+			return nil, fmt.Errorf("failed to create token: duplicate token")
+		}
+	}
+
+	r.tokens = append(r.tokens, token)
+	return &token, nil
+}
+
+func (r *MemoryTokenRepository) ConsumeToken(ctx context.Context, purpose TokenPurpose, tokenHash string, now time.Time) (*Token, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, token := range r.tokens {
+		if token.TokenHash == tokenHash && token.Purpose == purpose && token.UsedAt == nil && token.ExpiresAt.After(now) {
+			token.UsedAt = &now
+			r.tokens[i] = token
+			return &token, nil
+		}
+	}
+
+	return nil, ErrTokenInvalid
+}
+
+func (r *MemoryTokenRepository) DeleteTokens(ctx context.Context, userID string, purpose TokenPurpose) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeWhere(func(token Token) bool {
+		return token.UserID == userID && token.Purpose == purpose
+	})
+	return nil
+}
+
+func (r *MemoryTokenRepository) removeWhere(remove func(Token) bool) {
+	kept := r.tokens[:0]
+	for _, token := range r.tokens {
+		if !remove(token) {
+			kept = append(kept, token)
+		}
+	}
+	r.tokens = kept
+}
@@ -0,0 +1,71 @@
+package accounts
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter allows at most limit events per key within a sliding window.
+// State is kept in memory, so each server instance enforces its own limit.
+type RateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	events    map[string][]time.Time
+	lastSweep time.Time
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:  limit,
+		window: window,
+		events: make(map[string][]time.Time),
+	}
+}
+
+// Allow records an event for key and reports whether it is within the limit.
+// Keys are compared case-insensitively.
+func (l *RateLimiter) Allow(key string) bool {
+	key = strings.ToLower(strings.TrimSpace(key))
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > l.window {
+		l.sweep(now)
+	}
+
+	events := recent(l.events[key], now.Add(-l.window))
+	if len(events) >= l.limit {
+		l.events[key] = events
+		return false
+	}
+
+	l.events[key] = append(events, now)
+	return true
+}
+
+// sweep drops keys with no events inside the window so the map doesn't grow
+// with every address ever seen.
+func (l *RateLimiter) sweep(now time.Time) {
+	cutoff := now.Add(-l.window)
+	for key, events := range l.events {
+		if events = recent(events, cutoff); len(events) == 0 {
+			delete(l.events, key)
+		} else {
+			l.events[key] = events
+		}
+	}
+	l.lastSweep = now
+}
+
+func recent(events []time.Time, cutoff time.Time) []time.Time {
+	for i, event := range events {
+		if event.After(cutoff) {
+			return events[i:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoDBTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoDBTokenRepository(collection *mongo.Collection) *MongoDBTokenRepository {
+	return &MongoDBTokenRepository{
+		collection: collection,
+	}
+}
+
+func (r *MongoDBTokenRepository) CreateToken(ctx context.Context, token Token) (*Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	token.CreatedAt = time.Now()
+
+	if token.ID == "" {
+		token.ID = primitive.NewObjectID().Hex()
+	}
+
+	_, err := r.collection.InsertOne(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *MongoDBTokenRepository) ConsumeToken(ctx context.Context, purpose TokenPurpose, tokenHash string, now time.Time) (*Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"token_hash": tokenHash,
+		"purpose":    purpose,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+	update := bson.M{"$set": bson.M{"used_at": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var token Token
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to redeem token: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *MongoDBTokenRepository) DeleteTokens(ctx context.Context, userID string, purpose TokenPurpose) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID, "purpose": purpose})
+	if err != nil {
+		return fmt.Errorf("failed to delete tokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package accounts
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/services/notifications"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+)
+
+type AccountService struct {
+	repository       TokenRepository
+	userService      *users.UserService
+	notifier         *notifications.Notifier
+	limiter          *RateLimiter
+	passwordResetTTL time.Duration
+	verificationTTL  time.Duration
+	baseURL          string
+}
+
+func NewAccountService(repository TokenRepository, userService *users.UserService, notifier *notifications.Notifier, limiter *RateLimiter, passwordResetTTL, verificationTTL time.Duration, baseURL string) *AccountService {
+	return &AccountService{
+		repository:       repository,
+		userService:      userService,
+		notifier:         notifier,
+		limiter:          limiter,
+		passwordResetTTL: passwordResetTTL,
+		verificationTTL:  verificationTTL,
+		baseURL:          baseURL,
+	}
+}
+
+// ForgotPassword emails a reset link if the address belongs to an account.
+// The lookup and delivery happen in the background so neither the result nor
+// the response time reveals whether an account exists.
+func (s *AccountService) ForgotPassword(ctx context.Context, request ForgotPasswordRequest) error {
+	email := strings.TrimSpace(request.Email)
+	if email == "" {
+		return apperr.Validation("email", "is required")
+	}
+
+	if !s.limiter.Allow(email) {
+		return ErrRateLimited
+	}
+
+	go s.sendPasswordReset(context.WithoutCancel(ctx), email)
+	return nil
+}
+
+func (s *AccountService) sendPasswordReset(ctx context.Context, email string) {
+	user, err := s.userService.GetUserByEmail(ctx, email)
+	if err != nil {
+		return
+	}
+
+	link, token, err := s.issue(ctx, user, PurposePasswordReset, s.passwordResetTTL, "/reset-password")
+	if err != nil {
+		log.Printf("failed to issue password reset for user %s: %v", user.ID, err)
+		return
+	}
+
+	if err := s.notifier.SendPasswordReset(ctx, *user, link, token.ExpiresAt); err != nil {
+		log.Printf("failed to email password reset to user %s: %v", user.ID, err)
+	}
+}
+
+// ResetPassword redeems a reset token and sets the new password. Other
+// outstanding reset links for the account stop working, and so do its access
+// and refresh tokens, signing it out everywhere.
+func (s *AccountService) ResetPassword(ctx context.Context, request ResetPasswordRequest) error {
+	if strings.TrimSpace(request.Token) == "" {
+		return ErrTokenInvalid
+	}
+
+	// Check the password before redeeming so a rejected one doesn't use up the link
+	if err := users.ValidatePassword(request.Password); err != nil {
+		return err
+	}
+
+	user, err := s.redeem(ctx, PurposePasswordReset, request.Token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userService.SetPassword(ctx, user.ID, request.Password); err != nil {
+		return err
+	}
+
+	// Receiving the link proves the address belongs to the user
+	if user.EmailVerifiedAt == nil {
+		if err := s.userService.MarkEmailVerified(ctx, user.ID); err != nil {
+			log.Printf("failed to mark email verified for user %s: %v", user.ID, err)
+		}
+	}
+
+	if err := s.repository.DeleteTokens(ctx, user.ID, PurposePasswordReset); err != nil {
+		log.Printf("failed to delete password reset tokens for user %s: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+// SendVerification emails a link confirming the user's current address.
+func (s *AccountService) SendVerification(ctx context.Context, user users.User) error {
+	if user.EmailVerifiedAt != nil {
+		return ErrAlreadyVerified
+	}
+
+	if !s.limiter.Allow(user.Email) {
+		return ErrRateLimited
+	}
+
+	link, token, err := s.issue(ctx, &user, PurposeEmailVerification, s.verificationTTL, "/verify-email")
+	if err != nil {
+		return err
+	}
+
+	return s.notifier.SendEmailVerification(ctx, user, link, token.ExpiresAt)
+}
+
+func (s *AccountService) VerifyEmail(ctx context.Context, request VerifyEmailRequest) error {
+	if strings.TrimSpace(request.Token) == "" {
+		return ErrTokenInvalid
+	}
+
+	user, err := s.redeem(ctx, PurposeEmailVerification, request.Token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userService.MarkEmailVerified(ctx, user.ID); err != nil {
+		return err
+	}
+
+	if err := s.repository.DeleteTokens(ctx, user.ID, PurposeEmailVerification); err != nil {
+		log.Printf("failed to delete verification tokens for user %s: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+// redeem consumes a token and returns its user, provided the account still
+// has the address the token was sent to.
+func (s *AccountService) redeem(ctx context.Context, purpose TokenPurpose, rawToken string) (*users.User, error) {
+	token, err := s.repository.ConsumeToken(ctx, purpose, hashToken(rawToken), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUserByID(ctx, token.UserID)
+	if err != nil || !strings.EqualFold(user.Email, token.Email) {
+		return nil, ErrTokenInvalid
+	}
+
+	return user, nil
+}
+
+func (s *AccountService) issue(ctx context.Context, user *users.User, purpose TokenPurpose, ttl time.Duration, path string) (string, *Token, error) {
+	rawToken, tokenHash, err := newToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	token, err := s.repository.CreateToken(ctx, Token{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Purpose:   purpose,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return s.baseURL + path + "?token=" + url.QueryEscape(rawToken), token, nil
+}
+
+func newToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate account token: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
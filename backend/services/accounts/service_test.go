@@ -0,0 +1,252 @@
+package accounts_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/mergepatch"
+	"github.com/bpalazzi512/easy-ballot/backend/services/accounts"
+	"github.com/bpalazzi512/easy-ballot/backend/services/notifications"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	email    = "ada@example.com"
+	password = "correct horse battery"
+)
+
+var tokenPattern = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+type fixture struct {
+	service     *accounts.AccountService
+	userService *users.UserService
+	mailer      *notifications.MemoryMailer
+	user        *users.User
+}
+
+// newFixture builds an account service over in-memory storage holding one
+// user. Tokens expire after ttl, and each address may request limit emails
+// per hour.
+func newFixture(t *testing.T, ttl time.Duration, limit int) fixture {
+	t.Helper()
+
+	templates, err := notifications.LoadTemplates()
+	if err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+
+	userRepository := users.NewMemoryUserRepository(nil)
+	f := fixture{
+		userService: users.NewUserService(userRepository, users.NewPasswordHasher(bcrypt.MinCost)),
+		mailer:      notifications.NewMemoryMailer(),
+	}
+	notifier := notifications.NewNotifier(f.mailer, templates, organizations.NewMemoryOrganizationRepository(), userRepository, "http://localhost")
+	f.service = accounts.NewAccountService(accounts.NewMemoryTokenRepository(), f.userService, notifier, accounts.NewRateLimiter(limit, time.Hour), ttl, ttl, "http://localhost")
+
+	f.user, err = f.userService.CreateUser(context.Background(), users.CreateUserRequest{FirstName: "Ada", LastName: "Lovelace", Email: email, Password: password})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return f
+}
+
+// requestReset asks for a password reset and returns the token from the
+// emailed link. Reset emails are sent in the background, so it waits for the
+// email to arrive.
+func (f fixture) requestReset(t *testing.T) string {
+	t.Helper()
+
+	sent := len(f.mailer.Messages())
+	if err := f.service.ForgotPassword(context.Background(), accounts.ForgotPasswordRequest{Email: email}); err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(f.mailer.Messages()) == sent {
+		if time.Now().After(deadline) {
+			t.Fatal("no password reset email was sent")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return f.lastToken(t)
+}
+
+// lastToken returns the token from the last emailed link.
+func (f fixture) lastToken(t *testing.T) string {
+	t.Helper()
+
+	messages := f.mailer.Messages()
+	if len(messages) == 0 {
+		t.Fatal("no email was sent")
+	}
+	match := tokenPattern.FindStringSubmatch(messages[len(messages)-1].Text)
+	if match == nil {
+		t.Fatalf("the email has no token: %s", messages[len(messages)-1].Text)
+	}
+	return match[1]
+}
+
+func TestResetPassword(t *testing.T) {
+	const newPassword = "tr0ub4dor and three"
+
+	for _, tc := range []struct {
+		name string
+		ttl  time.Duration
+		// before runs between requesting the reset and redeeming it, and
+		// returns the token to redeem
+		before   func(t *testing.T, f fixture, token string) string
+		password string
+		err      error
+	}{
+		{name: "Valid", password: newPassword},
+		{name: "Expired", ttl: -time.Minute, password: newPassword, err: accounts.ErrTokenInvalid},
+		{name: "EmptyToken", password: newPassword, err: accounts.ErrTokenInvalid,
+			before: func(t *testing.T, f fixture, token string) string {
+				return ""
+			},
+		},
+		{name: "Reused", password: newPassword, err: accounts.ErrTokenInvalid,
+			before: func(t *testing.T, f fixture, token string) string {
+				if err := f.service.ResetPassword(context.Background(), accounts.ResetPasswordRequest{Token: token, Password: "an earlier new password"}); err != nil {
+					t.Fatalf("ResetPassword: %v", err)
+				}
+				return token
+			},
+		},
+		{name: "OtherLinkUsed", password: newPassword, err: accounts.ErrTokenInvalid,
+			before: func(t *testing.T, f fixture, token string) string {
+				other := f.requestReset(t)
+				if err := f.service.ResetPassword(context.Background(), accounts.ResetPasswordRequest{Token: other, Password: "an earlier new password"}); err != nil {
+					t.Fatalf("ResetPassword: %v", err)
+				}
+				return token
+			},
+		},
+		{name: "RetryAfterShortPassword", password: newPassword,
+			before: func(t *testing.T, f fixture, token string) string {
+				err := f.service.ResetPassword(context.Background(), accounts.ResetPasswordRequest{Token: token, Password: "short"})
+				if !errors.Is(err, apperr.ErrValidation) {
+					t.Fatalf("ResetPassword with a short password returned %v, want a validation error", err)
+				}
+				return token
+			},
+		},
+		{name: "EmailChanged", password: newPassword, err: accounts.ErrTokenInvalid,
+			before: func(t *testing.T, f fixture, token string) string {
+				patch := users.UserPatch{
+					Email:           mergepatch.Field[string]{Set: true, Value: "ada@example.org"},
+					CurrentPassword: mergepatch.Field[string]{Set: true, Value: password},
+				}
+				if _, err := f.userService.PatchUser(context.Background(), f.user.ID, patch); err != nil {
+					t.Fatalf("PatchUser: %v", err)
+				}
+				return token
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			ttl := tc.ttl
+			if ttl == 0 {
+				ttl = time.Hour
+			}
+			f := newFixture(t, ttl, 10)
+
+			token := f.requestReset(t)
+			if tc.before != nil {
+				token = tc.before(t, f, token)
+			}
+
+			err := f.service.ResetPassword(ctx, accounts.ResetPasswordRequest{Token: token, Password: tc.password})
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("ResetPassword returned %v, want %v", err, tc.err)
+			}
+			if tc.err != nil {
+				if _, err := f.userService.Authenticate(ctx, email, tc.password); err == nil {
+					t.Error("Authenticate accepted the new password after a failed reset")
+				}
+				return
+			}
+
+			user, err := f.userService.Authenticate(ctx, email, tc.password)
+			if err != nil {
+				t.Fatalf("Authenticate with the new password returned %v", err)
+			}
+			if user.EmailVerifiedAt == nil {
+				t.Error("resetting the password should verify the email it was sent to")
+			}
+		})
+	}
+}
+
+func TestForgotPasswordRateLimit(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t, time.Hour, 2)
+
+	for i, requested := range []string{email, "ADA@example.com ", email} {
+		err := f.service.ForgotPassword(ctx, accounts.ForgotPasswordRequest{Email: requested})
+		if want := i < 2; (err == nil) != want {
+			t.Errorf("ForgotPassword %d for %q returned %v, want success %t", i+1, requested, err, want)
+		}
+		if i == 2 && !errors.Is(err, accounts.ErrRateLimited) {
+			t.Errorf("ForgotPassword over the limit returned %v, want %v", err, accounts.ErrRateLimited)
+		}
+	}
+
+	// Other addresses have their own limit, whether or not they have an account
+	if err := f.service.ForgotPassword(ctx, accounts.ForgotPasswordRequest{Email: "grace@example.com"}); err != nil {
+		t.Errorf("ForgotPassword for another address returned %v", err)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := accounts.NewRateLimiter(2, 50*time.Millisecond)
+
+	for i, want := range []bool{true, true, false} {
+		if got := limiter.Allow("key"); got != want {
+			t.Errorf("Allow %d = %t, want %t", i+1, got, want)
+		}
+	}
+	if !limiter.Allow("other") {
+		t.Error("Allow for another key = false, want true")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if !limiter.Allow("key") {
+		t.Error("Allow after the window passed = false, want true")
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t, time.Hour, 10)
+
+	if err := f.service.SendVerification(ctx, *f.user); err != nil {
+		t.Fatalf("SendVerification: %v", err)
+	}
+	token := f.lastToken(t)
+
+	if err := f.service.VerifyEmail(ctx, accounts.VerifyEmailRequest{Token: token}); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if err := f.service.VerifyEmail(ctx, accounts.VerifyEmailRequest{Token: token}); !errors.Is(err, accounts.ErrTokenInvalid) {
+		t.Errorf("VerifyEmail with a used token returned %v, want %v", err, accounts.ErrTokenInvalid)
+	}
+
+	user, err := f.userService.GetUserByID(ctx, f.user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if user.EmailVerifiedAt == nil {
+		t.Error("VerifyEmail did not mark the email verified")
+	}
+	if err := f.service.SendVerification(ctx, *user); !errors.Is(err, accounts.ErrAlreadyVerified) {
+		t.Errorf("SendVerification for a verified email returned %v, want %v", err, accounts.ErrAlreadyVerified)
+	}
+}
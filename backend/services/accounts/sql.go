@@ -0,0 +1,90 @@
+package accounts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SQLTokenRepository stores tokens in the account_tokens table of a SQL
+// database. Expired tokens are dropped as new ones are created, as the TTL
+// index does in MongoDB.
+type SQLTokenRepository struct {
+	db *sqldb.DB
+}
+
+func NewSQLTokenRepository(db *sqldb.DB) *SQLTokenRepository {
+	return &SQLTokenRepository{
+		db: db,
+	}
+}
+
+const tokenColumns = "id, user_id, email, purpose, token_hash, expires_at, used_at, created_at"
+
+func (r *SQLTokenRepository) CreateToken(ctx context.Context, token Token) (*Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	token.CreatedAt = time.Now()
+
+	if token.ID == "" {
+		token.ID = primitive.NewObjectID().Hex()
+	}
+
+	q := r.db.Query()
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM account_tokens WHERE expires_at < "+q.Arg(token.CreatedAt), q.Args...); err != nil {
+		return nil, fmt.Errorf("failed to delete expired tokens: %w", err)
+	}
+
+	q = r.db.Query()
+	values := q.Values(token.ID, token.UserID, token.Email, token.Purpose, token.TokenHash,
+		token.ExpiresAt, token.UsedAt, token.CreatedAt)
+	_, err := r.db.ExecContext(ctx, "INSERT INTO account_tokens ("+tokenColumns+") VALUES "+values, q.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *SQLTokenRepository) ConsumeToken(ctx context.Context, purpose TokenPurpose, tokenHash string, now time.Time) (*Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	query := "UPDATE account_tokens SET used_at = " + q.Arg(now) +
+		" WHERE token_hash = " + q.Arg(tokenHash) +
+		" AND purpose = " + q.Arg(purpose) +
+		" AND used_at IS NULL AND expires_at > " + q.Arg(now) +
+		" RETURNING " + tokenColumns
+
+	var token Token
+	err := r.db.QueryRowContext(ctx, query, q.Args...).Scan(&token.ID, &token.UserID, &token.Email, &token.Purpose,
+		&token.TokenHash, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to redeem token: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *SQLTokenRepository) DeleteTokens(ctx context.Context, userID string, purpose TokenPurpose) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	_, err := r.db.ExecContext(ctx, "DELETE FROM account_tokens WHERE user_id = "+q.Arg(userID)+" AND purpose = "+q.Arg(purpose), q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete tokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrTokenInvalid    = errors.New("token is invalid or has expired")
+	ErrRateLimited     = errors.New("too many emails requested for this address; try again later")
+	ErrAlreadyVerified = errors.New("email is already verified")
+)
+
+type TokenPurpose string
+
+const (
+	PurposePasswordReset     TokenPurpose = "password_reset"
+	PurposeEmailVerification TokenPurpose = "email_verification"
+)
+
+// Token is a single-use link sent to a user's email address. Only a hash of
+// the token is stored, and it is bound to the address it was sent to so
+// changing the email invalidates it.
+type Token struct {
+	ID        string       `json:"id" bson:"_id,omitempty"`
+	UserID    string       `json:"user_id" bson:"user_id"`
+	Email     string       `json:"email" bson:"email"`
+	Purpose   TokenPurpose `json:"purpose" bson:"purpose"`
+	TokenHash string       `json:"-" bson:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at" bson:"expires_at"`
+	UsedAt    *time.Time   `json:"used_at,omitempty" bson:"used_at,omitempty"`
+	CreatedAt time.Time    `json:"created_at" bson:"created_at"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type TokenRepository interface {
+	CreateToken(ctx context.Context, token Token) (*Token, error)
+	// ConsumeToken atomically marks the unused, unexpired token with the hash
+	// as used, so each token can be redeemed only once.
+	ConsumeToken(ctx context.Context, purpose TokenPurpose, tokenHash string, now time.Time) (*Token, error)
+	// DeleteTokens removes every outstanding token of a purpose for the user.
+	DeleteTokens(ctx context.Context, userID string, purpose TokenPurpose) error
+}
@@ -0,0 +1,99 @@
+package archives
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/invitations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+)
+
+// MemoryArchiveRepository deletes organizations through the other
+// repositories and keeps what it archives in memory, for tests and for
+// running the server without a database. Deletions are serialized, and every
+// check runs before the first change, so there is nothing to roll back.
+type MemoryArchiveRepository struct {
+	mu                  sync.Mutex
+	organizations       organizations.OrganizationRepository
+	memberships         memberships.MembershipRepository
+	invitations         invitations.InvitationRepository
+	ballots             ballots.BallotRepository
+	archivedMemberships []ArchivedMembership
+	archivedBallots     []ArchivedBallot
+}
+
+func NewMemoryArchiveRepository(organizationRepository organizations.OrganizationRepository, membershipRepository memberships.MembershipRepository, invitationRepository invitations.InvitationRepository, ballotRepository ballots.BallotRepository) *MemoryArchiveRepository {
+	return &MemoryArchiveRepository{
+		organizations: organizationRepository,
+		memberships:   membershipRepository,
+		invitations:   invitationRepository,
+		ballots:       ballotRepository,
+	}
+}
+
+func (r *MemoryArchiveRepository) DeleteOrganization(ctx context.Context, id string, now time.Time) (*organizations.DeletionReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.organizations.GetOrganizationByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	// A zero limit lists everything
+	all := pagination.Page{Sort: pagination.Sort{Field: "created_at"}}
+
+	organizationBallots, err := r.ballots.ListBallots(ctx, id, all)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for unfinished ballots: %w", err)
+	}
+	var unfinished unfinishedBallots
+	for _, ballot := range organizationBallots {
+		unfinished.add(ballot, now)
+	}
+	if err := unfinished.err(); err != nil {
+		return nil, err
+	}
+
+	members, err := r.memberships.ListMembers(ctx, id, all)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive memberships: %w", err)
+	}
+	if err := r.organizations.SoftDeleteOrganization(ctx, id, now); err != nil {
+		return nil, err
+	}
+
+	report := &organizations.DeletionReport{OrganizationID: id, DeletedAt: now, Retained: retainedCollections()}
+
+	for _, membership := range members {
+		err := r.memberships.DeleteMembership(ctx, id, membership.UserID)
+		if errors.Is(err, memberships.ErrNotMember) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to archive memberships: %w", err)
+		}
+		r.archivedMemberships = append(r.archivedMemberships, ArchivedMembership{Membership: membership, ArchivedAt: now})
+		report.MembersArchived++
+	}
+
+	for _, ballot := range organizationBallots {
+		if err := r.ballots.DeleteBallot(ctx, ballot.ID); err != nil {
+			return nil, fmt.Errorf("failed to archive ballots: %w", err)
+		}
+		r.archivedBallots = append(r.archivedBallots, ArchivedBallot{Ballot: ballot, ArchivedAt: now})
+		report.BallotsArchived++
+	}
+
+	report.InvitationsDeleted, err = r.invitations.DeleteOrganizationInvitations(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete invitations: %w", err)
+	}
+
+	return report, nil
+}
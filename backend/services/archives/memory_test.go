@@ -0,0 +1,25 @@
+package archives_test
+
+import (
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/repotest"
+	"github.com/bpalazzi512/easy-ballot/backend/services/archives"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/invitations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+)
+
+func TestMemoryArchiveRepository(t *testing.T) {
+	repotest.TestDeletionRepository(t, func(t *testing.T) repotest.DeletionStores {
+		stores := repotest.DeletionStores{
+			Organizations: organizations.NewMemoryOrganizationRepository(),
+			Memberships:   memberships.NewMemoryMembershipRepository(),
+			Invitations:   invitations.NewMemoryInvitationRepository(),
+			Ballots:       ballots.NewMemoryBallotRepository(),
+		}
+		stores.Deletions = archives.NewMemoryArchiveRepository(stores.Organizations, stores.Memberships, stores.Invitations, stores.Ballots)
+		return stores
+	})
+}
@@ -0,0 +1,157 @@
+package archives
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// illegalOperation is the code MongoDB fails transactions with on a
+// standalone server.
+const illegalOperation = 20
+
+// MongoDBArchiveRepository deletes an organization inside a MongoDB
+// transaction, which needs a replica set or sharded cluster: against a
+// standalone server DeleteOrganization always fails and changes nothing. A
+// single-node replica set is enough.
+type MongoDBArchiveRepository struct {
+	client              *mongo.Client
+	organizations       *mongo.Collection
+	memberships         *mongo.Collection
+	invitations         *mongo.Collection
+	ballots             *mongo.Collection
+	archivedMemberships *mongo.Collection
+	archivedBallots     *mongo.Collection
+}
+
+func NewMongoDBArchiveRepository(database *mongo.Database) *MongoDBArchiveRepository {
+	return &MongoDBArchiveRepository{
+		client:              database.Client(),
+		organizations:       database.Collection("organizations"),
+		memberships:         database.Collection("memberships"),
+		invitations:         database.Collection("invitations"),
+		ballots:             database.Collection("ballots"),
+		archivedMemberships: database.Collection("archived_memberships"),
+		archivedBallots:     database.Collection("archived_ballots"),
+	}
+}
+
+func (r *MongoDBArchiveRepository) DeleteOrganization(ctx context.Context, id string, now time.Time) (*organizations.DeletionReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	session, err := r.client.StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	// WithTransaction retries the whole function when it conflicts with
+	// another write, such as a ballot being rescheduled meanwhile
+	report, err := session.WithTransaction(ctx, func(ctx mongo.SessionContext) (any, error) {
+		return r.deleteOrganization(ctx, id, now)
+	})
+	if err != nil {
+		var serverErr mongo.ServerError
+		if errors.As(err, &serverErr) && serverErr.HasErrorCode(illegalOperation) {
+			return nil, fmt.Errorf("failed to delete organization: MongoDB only supports transactions on a replica set: %w", err)
+		}
+		return nil, err
+	}
+
+	return report.(*organizations.DeletionReport), nil
+}
+
+func (r *MongoDBArchiveRepository) deleteOrganization(ctx context.Context, id string, now time.Time) (*organizations.DeletionReport, error) {
+	// Marking the organization first makes concurrent deletions of it
+	// conflict, so only one of them archives anything
+	filter := bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}
+	result, err := r.organizations.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"deleted_at": now}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete organization: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, apperr.NotFound("organization not found")
+	}
+
+	// Mirrors ballots.Ballot.IsOpen
+	open, err := r.ballots.CountDocuments(ctx, bson.M{
+		"organization_id": id,
+		"opens_at":        bson.M{"$lte": now},
+		"closes_at":       bson.M{"$gt": now},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for unfinished ballots: %w", err)
+	}
+	scheduled, err := r.ballots.CountDocuments(ctx, bson.M{
+		"organization_id": id,
+		"opens_at":        bson.M{"$gt": now},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for unfinished ballots: %w", err)
+	}
+	unfinished := unfinishedBallots{open: int(open), scheduled: int(scheduled)}
+	if err := unfinished.err(); err != nil {
+		return nil, err
+	}
+
+	report := &organizations.DeletionReport{OrganizationID: id, DeletedAt: now, Retained: retainedCollections()}
+
+	report.MembersArchived, err = archiveDocuments(ctx, r.memberships, r.archivedMemberships, id, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive memberships: %w", err)
+	}
+
+	report.BallotsArchived, err = archiveDocuments(ctx, r.ballots, r.archivedBallots, id, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive ballots: %w", err)
+	}
+
+	deleted, err := r.invitations.DeleteMany(ctx, bson.M{"organization_id": id})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete invitations: %w", err)
+	}
+	report.InvitationsDeleted = deleted.DeletedCount
+
+	return report, nil
+}
+
+// archiveDocuments moves an organization's documents from one collection to
+// another, adding archived_at, and returns how many it moved. Documents are
+// copied whole, so nothing is lost if their schema changes.
+func archiveDocuments(ctx context.Context, from, to *mongo.Collection, organizationID string, now time.Time) (int64, error) {
+	filter := bson.M{"organization_id": organizationID}
+
+	cursor, err := from.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	var documents []bson.M
+	if err := cursor.All(ctx, &documents); err != nil {
+		return 0, err
+	}
+	if len(documents) == 0 {
+		return 0, nil
+	}
+
+	archived := make([]any, len(documents))
+	for i, document := range documents {
+		document["archived_at"] = now
+		archived[i] = document
+	}
+	if _, err := to.InsertMany(ctx, archived); err != nil {
+		return 0, err
+	}
+
+	result, err := from.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
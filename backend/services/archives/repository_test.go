@@ -0,0 +1,28 @@
+package archives_test
+
+import (
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/repotest"
+	"github.com/bpalazzi512/easy-ballot/backend/services/archives"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/invitations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+)
+
+func TestMongoDBArchiveRepository(t *testing.T) {
+	client := repotest.MongoClient(t)
+	repotest.RequireTransactions(t, client)
+
+	repotest.TestDeletionRepository(t, func(t *testing.T) repotest.DeletionStores {
+		database := repotest.MongoDatabase(t, client)
+		return repotest.DeletionStores{
+			Deletions:     archives.NewMongoDBArchiveRepository(database),
+			Organizations: organizations.NewMongoDBOrganizationRepository(database.Collection("organizations")),
+			Memberships:   memberships.NewMongoDBMembershipRepository(database.Collection("memberships")),
+			Invitations:   invitations.NewMongoDBInvitationRepository(database.Collection("invitations")),
+			Ballots:       ballots.NewMongoDBBallotRepository(database.Collection("ballots")),
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package archives
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+)
+
+// SQLArchiveRepository deletes an organization in a single SQL transaction,
+// moving its rows into the archived_memberships and archived_ballots tables.
+type SQLArchiveRepository struct {
+	db *sqldb.DB
+}
+
+func NewSQLArchiveRepository(db *sqldb.DB) *SQLArchiveRepository {
+	return &SQLArchiveRepository{
+		db: db,
+	}
+}
+
+const (
+	membershipColumns = "id, organization_id, user_id, role, created_at, updated_at"
+	ballotColumns     = "id, organization_id, title, description, opens_at, closes_at, questions, eligibility, secret, roll_snapshot_at, created_at, updated_at"
+)
+
+func (r *SQLArchiveRepository) DeleteOrganization(ctx context.Context, id string, now time.Time) (*organizations.DeletionReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	report := &organizations.DeletionReport{OrganizationID: id, DeletedAt: now, Retained: retainedCollections()}
+	err := r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		q := r.db.Query()
+		result, err := tx.ExecContext(ctx, "UPDATE organizations SET deleted_at = "+q.Arg(now)+" WHERE id = "+q.Arg(id)+" AND deleted_at IS NULL", q.Args...)
+		if err != nil {
+			return fmt.Errorf("failed to delete organization: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to read rows affected: %w", err)
+		}
+		if affected == 0 {
+			return apperr.NotFound("organization not found")
+		}
+
+		unfinished, err := r.countUnfinishedBallots(ctx, tx, id, now)
+		if err != nil {
+			return fmt.Errorf("failed to check for unfinished ballots: %w", err)
+		}
+		if err := unfinished.err(); err != nil {
+			return err
+		}
+
+		report.MembersArchived, err = archiveRows(ctx, r.db, tx, "memberships", membershipColumns, id, now)
+		if err != nil {
+			return fmt.Errorf("failed to archive memberships: %w", err)
+		}
+
+		report.BallotsArchived, err = archiveRows(ctx, r.db, tx, "ballots", ballotColumns, id, now)
+		if err != nil {
+			return fmt.Errorf("failed to archive ballots: %w", err)
+		}
+
+		q = r.db.Query()
+		result, err = tx.ExecContext(ctx, "DELETE FROM invitations WHERE organization_id = "+q.Arg(id), q.Args...)
+		if err != nil {
+			return fmt.Errorf("failed to delete invitations: %w", err)
+		}
+		report.InvitationsDeleted, err = result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to read rows affected: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// countUnfinishedBallots counts the organization's ballots that are open or
+// scheduled at now. On PostgreSQL the ballots stay locked until the
+// transaction ends, so none can be rescheduled before they are archived; a
+// SQLite transaction already holds the database's write lock.
+func (r *SQLArchiveRepository) countUnfinishedBallots(ctx context.Context, tx *sql.Tx, organizationID string, now time.Time) (unfinishedBallots, error) {
+	q := r.db.Query()
+	query := "SELECT opens_at, closes_at FROM ballots WHERE organization_id = " + q.Arg(organizationID)
+	if r.db.Dialect == sqldb.Postgres {
+		query += " FOR UPDATE"
+	}
+
+	var unfinished unfinishedBallots
+	rows, err := tx.QueryContext(ctx, query, q.Args...)
+	if err != nil {
+		return unfinished, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ballot ballots.Ballot
+		if err := rows.Scan(&ballot.OpensAt, &ballot.ClosesAt); err != nil {
+			return unfinished, err
+		}
+		unfinished.add(ballot, now)
+	}
+
+	return unfinished, rows.Err()
+}
+
+// archiveRows moves an organization's rows from a table to its archived_
+// table, adding archived_at, and returns how many it moved.
+func archiveRows(ctx context.Context, db *sqldb.DB, tx *sql.Tx, table, columns, organizationID string, now time.Time) (int64, error) {
+	q := db.Query()
+	archivedAt := q.Arg(now)
+	if db.Dialect == sqldb.Postgres {
+		// PostgreSQL types a parameter in a select list as text
+		archivedAt = "CAST(" + archivedAt + " AS TIMESTAMPTZ)"
+	}
+	query := "INSERT INTO archived_" + table + " (" + columns + ", archived_at) SELECT " + columns + ", " + archivedAt +
+		" FROM " + table + " WHERE organization_id = " + q.Arg(organizationID)
+	if _, err := tx.ExecContext(ctx, query, q.Args...); err != nil {
+		return 0, err
+	}
+
+	q = db.Query()
+	result, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE organization_id = "+q.Arg(organizationID), q.Args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
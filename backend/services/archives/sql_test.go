@@ -0,0 +1,37 @@
+package archives_test
+
+import (
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/repotest"
+	"github.com/bpalazzi512/easy-ballot/backend/services/archives"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/invitations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+)
+
+func TestPostgresArchiveRepository(t *testing.T) {
+	serverURL := repotest.PostgresURL(t)
+
+	repotest.TestDeletionRepository(t, func(t *testing.T) repotest.DeletionStores {
+		return sqlStores(repotest.PostgresDB(t, serverURL))
+	})
+}
+
+func TestSQLiteArchiveRepository(t *testing.T) {
+	repotest.TestDeletionRepository(t, func(t *testing.T) repotest.DeletionStores {
+		return sqlStores(repotest.SQLiteDB(t))
+	})
+}
+
+func sqlStores(db *sqldb.DB) repotest.DeletionStores {
+	return repotest.DeletionStores{
+		Deletions:     archives.NewSQLArchiveRepository(db),
+		Organizations: organizations.NewSQLOrganizationRepository(db),
+		Memberships:   memberships.NewSQLMembershipRepository(db),
+		Invitations:   invitations.NewSQLInvitationRepository(db),
+		Ballots:       ballots.NewSQLBallotRepository(db),
+	}
+}
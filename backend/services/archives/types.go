@@ -0,0 +1,58 @@
+// Package archives deletes organizations without losing their history. The
+// organization is soft-deleted, and its memberships and ballots move to
+// archive collections stamped with when they were archived. Its invitations
+// are deleted. Votes and voter rolls stay where they are, keyed by the
+// archived ballots' IDs.
+package archives
+
+import (
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+)
+
+// ArchivedMembership is a membership of a deleted organization.
+type ArchivedMembership struct {
+	memberships.Membership `bson:",inline"`
+	ArchivedAt             time.Time `json:"archived_at" bson:"archived_at"`
+}
+
+// ArchivedBallot is a ballot of a deleted organization.
+type ArchivedBallot struct {
+	ballots.Ballot `bson:",inline"`
+	ArchivedAt     time.Time `json:"archived_at" bson:"archived_at"`
+}
+
+// retainedCollections lists what deleting an organization leaves in place.
+// Votes and voter rolls only refer to ballots by ID, so the archived ballots
+// can still be tallied and audited.
+func retainedCollections() []string {
+	return []string{"votes", "participations", "ballot_box", "voter_rolls"}
+}
+
+// unfinishedBallots counts an organization's ballots that have not closed.
+// Deleting the organization would archive them, cutting a vote short or
+// cancelling one that is scheduled.
+type unfinishedBallots struct {
+	open      int
+	scheduled int
+}
+
+func (u *unfinishedBallots) add(ballot ballots.Ballot, now time.Time) {
+	switch {
+	case ballot.IsOpen(now):
+		u.open++
+	case !ballot.HasOpened(now):
+		u.scheduled++
+	}
+}
+
+// err refuses to delete the organization while any ballot is unfinished.
+func (u unfinishedBallots) err() error {
+	if u.open == 0 && u.scheduled == 0 {
+		return nil
+	}
+	return apperr.Conflict("organization cannot be deleted while it has open or scheduled ballots (%d open, %d scheduled)", u.open, u.scheduled)
+}
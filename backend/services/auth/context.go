@@ -0,0 +1,20 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+)
+
+type contextKey struct{}
+
+// WithUser returns a copy of ctx carrying the authenticated user.
+func WithUser(ctx context.Context, user *users.User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// UserFromContext returns the authenticated user stored by the auth middleware.
+func UserFromContext(ctx context.Context) (*users.User, bool) {
+	user, ok := ctx.Value(contextKey{}).(*users.User)
+	return user, ok && user != nil
+}
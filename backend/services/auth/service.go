@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const tokenIssuer = "easy-ballot"
+
+type AuthService struct {
+	userService     *users.UserService
+	secret          []byte
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+}
+
+func NewAuthService(userService *users.UserService, secret []byte, accessTokenTTL, refreshTokenTTL time.Duration) *AuthService {
+	return &AuthService{
+		userService:     userService,
+		secret:          secret,
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
+	}
+}
+
+func (s *AuthService) Login(ctx context.Context, request LoginRequest) (*TokenPair, error) {
+	if strings.TrimSpace(request.Email) == "" || request.Password == "" {
+		return nil, users.ErrInvalidCredentials
+	}
+
+	user, err := s.userService.Authenticate(ctx, request.Email, request.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user)
+}
+
+func (s *AuthService) Refresh(ctx context.Context, request RefreshRequest) (*TokenPair, error) {
+	claims, err := s.parseToken(request.RefreshToken, RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure the account still exists and the token hasn't been revoked
+	// before handing out new tokens.
+	user, err := s.currentUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user)
+}
+
+// Authenticate resolves an access token to the user it was issued for.
+func (s *AuthService) Authenticate(ctx context.Context, accessToken string) (*users.User, error) {
+	claims, err := s.parseToken(accessToken, AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.currentUser(ctx, claims)
+}
+
+// currentUser returns the user a token was issued for, unless their password
+// has changed since, which signs them out everywhere.
+func (s *AuthService) currentUser(ctx context.Context, claims *Claims) (*users.User, error) {
+	user, err := s.userService.GetUserByID(ctx, claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.PasswordChangedAt != passwordChangedAt(user) {
+		return nil, ErrInvalidToken
+	}
+
+	return user, nil
+}
+
+func (s *AuthService) issueTokens(user *users.User) (*TokenPair, error) {
+	accessToken, err := s.signToken(user, AccessToken, s.accessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := s.signToken(user, RefreshToken, s.refreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (s *AuthService) signToken(user *users.User, tokenType TokenType, ttl time.Duration) (string, error) {
+	tokenID, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := Claims{
+		TokenType:         tokenType,
+		PasswordChangedAt: passwordChangedAt(user),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    tokenIssuer,
+			Subject:   user.ID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (s *AuthService) parseToken(tokenString string, tokenType TokenType) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.TokenType != tokenType || claims.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+
+	return &claims, nil
+}
+
+// passwordChangedAt returns when the user's password last changed in Unix
+// milliseconds, or zero if it never has. Both sides of the comparison are
+// read back from the repository, so its precision doesn't matter.
+func passwordChangedAt(user *users.User) int64 {
+	if user.PasswordChangedAt == nil {
+		return 0
+	}
+	return user.PasswordChangedAt.UnixMilli()
+}
+
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
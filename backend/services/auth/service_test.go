@@ -0,0 +1,148 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	email    = "ada@example.com"
+	password = "correct horse battery"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+// newService returns an auth service over in-memory storage holding one user,
+// issuing access tokens that last accessTTL.
+func newService(t *testing.T, accessTTL time.Duration) (*auth.AuthService, *users.UserService, *users.User) {
+	t.Helper()
+
+	userService := users.NewUserService(users.NewMemoryUserRepository(nil), users.NewPasswordHasher(bcrypt.MinCost))
+	user, err := userService.CreateUser(context.Background(), users.CreateUserRequest{FirstName: "Ada", LastName: "Lovelace", Email: email, Password: password})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	return auth.NewAuthService(userService, secret, accessTTL, time.Hour), userService, user
+}
+
+func TestLogin(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		email    string
+		password string
+		err      error
+	}{
+		{name: "Valid", email: email, password: password},
+		{name: "EmailCase", email: "ADA@example.com", password: password},
+		{name: "WrongPassword", email: email, password: "incorrect horse", err: users.ErrInvalidCredentials},
+		{name: "UnknownEmail", email: "grace@example.com", password: password, err: users.ErrInvalidCredentials},
+		{name: "MissingPassword", email: email, err: users.ErrInvalidCredentials},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			service, _, user := newService(t, time.Hour)
+
+			tokens, err := service.Login(ctx, auth.LoginRequest{Email: tc.email, Password: tc.password})
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("Login returned %v, want %v", err, tc.err)
+			}
+			if tc.err != nil {
+				return
+			}
+
+			authenticated, err := service.Authenticate(ctx, tokens.AccessToken)
+			if err != nil || authenticated.ID != user.ID {
+				t.Errorf("Authenticate returned %v and error %v, want user %s", authenticated, err, user.ID)
+			}
+		})
+	}
+}
+
+func TestTokens(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		accessTTL time.Duration
+		// before runs after logging in, and returns the access and refresh
+		// tokens to use
+		before func(t *testing.T, userService *users.UserService, user *users.User, tokens *auth.TokenPair) (string, string)
+		// err is what authenticating returns, refreshErr what refreshing does
+		err        error
+		refreshErr error
+	}{
+		{name: "Valid"},
+		{name: "ExpiredAccessToken", accessTTL: -time.Minute, err: auth.ErrInvalidToken},
+		{name: "SwappedTypes", err: auth.ErrInvalidToken, refreshErr: auth.ErrInvalidToken,
+			before: func(t *testing.T, userService *users.UserService, user *users.User, tokens *auth.TokenPair) (string, string) {
+				return tokens.RefreshToken, tokens.AccessToken
+			},
+		},
+		{name: "OtherSecret", err: auth.ErrInvalidToken, refreshErr: auth.ErrInvalidToken,
+			before: func(t *testing.T, userService *users.UserService, user *users.User, tokens *auth.TokenPair) (string, string) {
+				other := auth.NewAuthService(userService, []byte("fedcba9876543210fedcba9876543210"), time.Hour, time.Hour)
+				otherTokens, err := other.Login(context.Background(), auth.LoginRequest{Email: email, Password: password})
+				if err != nil {
+					t.Fatalf("Login: %v", err)
+				}
+				return otherTokens.AccessToken, otherTokens.RefreshToken
+			},
+		},
+		{name: "PasswordChanged", err: auth.ErrInvalidToken, refreshErr: auth.ErrInvalidToken,
+			before: func(t *testing.T, userService *users.UserService, user *users.User, tokens *auth.TokenPair) (string, string) {
+				if err := userService.SetPassword(context.Background(), user.ID, "tr0ub4dor and three"); err != nil {
+					t.Fatalf("SetPassword: %v", err)
+				}
+				return tokens.AccessToken, tokens.RefreshToken
+			},
+		},
+		{name: "UserDeleted", err: auth.ErrInvalidToken, refreshErr: auth.ErrInvalidToken,
+			before: func(t *testing.T, userService *users.UserService, user *users.User, tokens *auth.TokenPair) (string, string) {
+				if err := userService.DeleteUser(context.Background(), user.ID); err != nil {
+					t.Fatalf("DeleteUser: %v", err)
+				}
+				return tokens.AccessToken, tokens.RefreshToken
+			},
+		},
+		{name: "Malformed", err: auth.ErrInvalidToken, refreshErr: auth.ErrInvalidToken,
+			before: func(t *testing.T, userService *users.UserService, user *users.User, tokens *auth.TokenPair) (string, string) {
+				return "not.a.token", "not.a.token"
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			accessTTL := tc.accessTTL
+			if accessTTL == 0 {
+				accessTTL = time.Hour
+			}
+			service, userService, user := newService(t, accessTTL)
+
+			tokens, err := service.Login(ctx, auth.LoginRequest{Email: email, Password: password})
+			if err != nil {
+				t.Fatalf("Login: %v", err)
+			}
+			accessToken, refreshToken := tokens.AccessToken, tokens.RefreshToken
+			if tc.before != nil {
+				accessToken, refreshToken = tc.before(t, userService, user, tokens)
+			}
+
+			if _, err := service.Authenticate(ctx, accessToken); !errors.Is(err, tc.err) {
+				t.Errorf("Authenticate returned %v, want %v", err, tc.err)
+			}
+
+			refreshed, err := service.Refresh(ctx, auth.RefreshRequest{RefreshToken: refreshToken})
+			if !errors.Is(err, tc.refreshErr) {
+				t.Fatalf("Refresh returned %v, want %v", err, tc.refreshErr)
+			}
+			if tc.refreshErr == nil && refreshed.RefreshToken == refreshToken {
+				t.Error("Refresh should issue a new refresh token")
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/bpalazzi512/easy-ballot/backend/types"
+)
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = apperr.Forbidden("you do not have permission to perform this action")
+)
+
+type Action string
+
+const (
+	ActionManageOrganization Action = "organization:manage"
+	ActionManageMembers      Action = "members:manage"
+	ActionViewUsers          Action = "users:view"
+	ActionManageBallots      Action = "ballots:manage"
+	ActionRunElections       Action = "ballots:run"
+	ActionViewBallots        Action = "ballots:view"
+	ActionViewTurnout        Action = "ballots:turnout"
+	ActionVote               Action = "ballots:vote"
+)
+
+// permissions maps each role to the actions it may perform within its own
+// organization. Opening and closing ballots is deliberately limited to
+// election officers so that organization admins cannot run their own elections.
+var permissions = map[users.UserRole][]Action{
+	users.RoleOwner: {
+		ActionManageOrganization,
+		ActionManageMembers,
+		ActionViewUsers,
+		ActionManageBallots,
+		ActionViewBallots,
+		ActionViewTurnout,
+		ActionVote,
+	},
+	users.RoleAdmin: {
+		ActionManageOrganization,
+		ActionManageMembers,
+		ActionViewUsers,
+		ActionManageBallots,
+		ActionViewBallots,
+		ActionViewTurnout,
+		ActionVote,
+	},
+	users.RoleOfficer: {
+		ActionViewUsers,
+		ActionManageBallots,
+		ActionRunElections,
+		ActionViewBallots,
+		ActionViewTurnout,
+		ActionVote,
+	},
+	users.RoleVoter: {
+		ActionViewUsers,
+		ActionViewBallots,
+		ActionVote,
+	},
+	users.RoleObserver: {
+		ActionViewUsers,
+		ActionViewBallots,
+	},
+}
+
+// Can reports whether the role grants the action.
+func Can(role users.UserRole, action Action) bool {
+	for _, allowed := range permissions[role] {
+		if allowed == action {
+			return true
+		}
+	}
+	return false
+}
+
+type Authorizer struct {
+	organizationRepository organizations.OrganizationRepository
+	membershipRepository   memberships.MembershipRepository
+}
+
+func NewAuthorizer(organizationRepository organizations.OrganizationRepository, membershipRepository memberships.MembershipRepository) *Authorizer {
+	return &Authorizer{
+		organizationRepository: organizationRepository,
+		membershipRepository:   membershipRepository,
+	}
+}
+
+// RoleIn returns the role the user's membership grants in the organization, or
+// an empty role if the user does not belong to it. The organization's owner is
+// always treated as RoleOwner.
+func (a *Authorizer) RoleIn(ctx context.Context, user *users.User, organizationID string) (users.UserRole, error) {
+	if user == nil || organizationID == "" {
+		return "", nil
+	}
+
+	organization, err := a.organizationRepository.GetOrganizationByID(ctx, organizationID)
+	if err != nil {
+		return "", err
+	}
+	if organization.OwnerUserID == user.ID {
+		return users.RoleOwner, nil
+	}
+
+	membership, err := a.membershipRepository.GetMembership(ctx, organizationID, user.ID)
+	if err != nil {
+		if errors.Is(err, memberships.ErrNotMember) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return membership.Role, nil
+}
+
+// Authorize returns ErrForbidden unless the user may perform the action in the
+// organization.
+func (a *Authorizer) Authorize(ctx context.Context, user *users.User, organizationID string, action Action) error {
+	if user == nil {
+		return ErrUnauthenticated
+	}
+
+	role, err := a.RoleIn(ctx, user, organizationID)
+	if err != nil {
+		return err
+	}
+
+	if !Can(role, action) {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// AuthorizeRequest authorizes the authenticated user of the request and
+// returns it on success.
+func (a *Authorizer) AuthorizeRequest(r *http.Request, organizationID string, action Action) (*users.User, error) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	if err := a.Authorize(r.Context(), user, organizationID, action); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// StatusCode maps an authorization error to the HTTP status handlers should
+// respond with. Other errors, including ErrForbidden, a missing organization
+// or a failed lookup, are mapped by apperr.
+func StatusCode(err error) int {
+	if errors.Is(err, ErrUnauthenticated) {
+		return http.StatusUnauthorized
+	}
+	return apperr.StatusCode(err)
+}
+
+// WriteError responds with the status of an authentication error, and hands
+// any other error to apperr.WriteError.
+func WriteError(w http.ResponseWriter, err error) {
+	if !errors.Is(err, ErrUnauthenticated) {
+		apperr.WriteError(w, err)
+		return
+	}
+
+	response := types.APIResponse{
+		Success: false,
+		Message: err.Error(),
+	}
+	w.WriteHeader(StatusCode(err))
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,181 @@
+package authz_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/services/auth"
+	"github.com/bpalazzi512/easy-ballot/backend/services/authz"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+)
+
+var actions = []authz.Action{
+	authz.ActionManageOrganization,
+	authz.ActionManageMembers,
+	authz.ActionViewUsers,
+	authz.ActionManageBallots,
+	authz.ActionRunElections,
+	authz.ActionViewBallots,
+	authz.ActionViewTurnout,
+	authz.ActionVote,
+}
+
+func TestCan(t *testing.T) {
+	for _, tc := range []struct {
+		role    users.UserRole
+		allowed []authz.Action
+	}{
+		{
+			role: users.RoleOwner,
+			allowed: []authz.Action{authz.ActionManageOrganization, authz.ActionManageMembers, authz.ActionViewUsers,
+				authz.ActionManageBallots, authz.ActionViewBallots, authz.ActionViewTurnout, authz.ActionVote},
+		},
+		{
+			role: users.RoleAdmin,
+			allowed: []authz.Action{authz.ActionManageOrganization, authz.ActionManageMembers, authz.ActionViewUsers,
+				authz.ActionManageBallots, authz.ActionViewBallots, authz.ActionViewTurnout, authz.ActionVote},
+		},
+		{
+			role: users.RoleOfficer,
+			allowed: []authz.Action{authz.ActionViewUsers, authz.ActionManageBallots, authz.ActionRunElections,
+				authz.ActionViewBallots, authz.ActionViewTurnout, authz.ActionVote},
+		},
+		{
+			role:    users.RoleVoter,
+			allowed: []authz.Action{authz.ActionViewUsers, authz.ActionViewBallots, authz.ActionVote},
+		},
+		{
+			role:    users.RoleObserver,
+			allowed: []authz.Action{authz.ActionViewUsers, authz.ActionViewBallots},
+		},
+		{
+			role: "",
+		},
+		{
+			role: "superuser",
+		},
+	} {
+		allowed := make(map[authz.Action]bool)
+		for _, action := range tc.allowed {
+			allowed[action] = true
+		}
+		for _, action := range actions {
+			if got := authz.Can(tc.role, action); got != allowed[action] {
+				t.Errorf("Can(%q, %q) = %t, want %t", tc.role, action, got, allowed[action])
+			}
+		}
+	}
+}
+
+// newAuthorizer returns an authorizer over in-memory storage holding one
+// organization owned by "owner", where "admin", "officer", "voter" and
+// "observer" hold the role of the same name.
+func newAuthorizer(t *testing.T) (*authz.Authorizer, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	organizationRepository := organizations.NewMemoryOrganizationRepository()
+	membershipRepository := memberships.NewMemoryMembershipRepository()
+
+	organization, err := organizationRepository.CreateOrganization(ctx, organizations.Organization{Name: "Acme Corporation", OwnerUserID: "owner"})
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	members := map[string]users.UserRole{"admin": users.RoleAdmin, "officer": users.RoleOfficer, "voter": users.RoleVoter, "observer": users.RoleObserver}
+	for userID, role := range members {
+		membership := memberships.Membership{OrganizationID: organization.ID, UserID: userID, Role: role}
+		if _, err := membershipRepository.CreateMembership(ctx, membership); err != nil {
+			t.Fatalf("CreateMembership: %v", err)
+		}
+	}
+
+	return authz.NewAuthorizer(organizationRepository, membershipRepository), organization.ID
+}
+
+func TestAuthorize(t *testing.T) {
+	authorizer, organizationID := newAuthorizer(t)
+
+	for _, tc := range []struct {
+		name           string
+		user           *users.User
+		organizationID string
+		action         authz.Action
+		role           users.UserRole
+		err            error
+	}{
+		{name: "Owner", user: &users.User{ID: "owner"}, action: authz.ActionManageOrganization, role: users.RoleOwner},
+		{name: "Admin", user: &users.User{ID: "admin"}, action: authz.ActionManageMembers, role: users.RoleAdmin},
+		{name: "AdminCannotRunElections", user: &users.User{ID: "admin"}, action: authz.ActionRunElections, role: users.RoleAdmin, err: authz.ErrForbidden},
+		{name: "Officer", user: &users.User{ID: "officer"}, action: authz.ActionRunElections, role: users.RoleOfficer},
+		{name: "Voter", user: &users.User{ID: "voter"}, action: authz.ActionVote, role: users.RoleVoter},
+		{name: "VoterCannotManageBallots", user: &users.User{ID: "voter"}, action: authz.ActionManageBallots, role: users.RoleVoter, err: authz.ErrForbidden},
+		{name: "ObserverCannotVote", user: &users.User{ID: "observer"}, action: authz.ActionVote, role: users.RoleObserver, err: authz.ErrForbidden},
+		{name: "NotMember", user: &users.User{ID: "stranger"}, action: authz.ActionViewBallots, err: authz.ErrForbidden},
+		{name: "Unauthenticated", action: authz.ActionViewBallots, err: authz.ErrUnauthenticated},
+		{name: "UnknownOrganization", user: &users.User{ID: "owner"}, organizationID: "missing", action: authz.ActionViewBallots, err: apperr.ErrNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.organizationID == "" {
+				tc.organizationID = organizationID
+			}
+
+			err := authorizer.Authorize(ctx, tc.user, tc.organizationID, tc.action)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("Authorize returned %v, want %v", err, tc.err)
+			}
+
+			if tc.user == nil || tc.organizationID != organizationID {
+				return
+			}
+			role, err := authorizer.RoleIn(ctx, tc.user, tc.organizationID)
+			if err != nil || role != tc.role {
+				t.Errorf("RoleIn returned %q and error %v, want %q", role, err, tc.role)
+			}
+		})
+	}
+}
+
+func TestAuthorizeRequest(t *testing.T) {
+	authorizer, organizationID := newAuthorizer(t)
+
+	for _, tc := range []struct {
+		name   string
+		userID string
+		status int
+	}{
+		{name: "Allowed", userID: "admin", status: http.StatusOK},
+		{name: "Forbidden", userID: "voter", status: http.StatusForbidden},
+		{name: "Unauthenticated", status: http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.userID != "" {
+				r = r.WithContext(auth.WithUser(r.Context(), &users.User{ID: tc.userID}))
+			}
+
+			user, err := authorizer.AuthorizeRequest(r, organizationID, authz.ActionManageMembers)
+			if tc.status == http.StatusOK {
+				if err != nil || user == nil || user.ID != tc.userID {
+					t.Fatalf("AuthorizeRequest returned %v and error %v, want user %q", user, err, tc.userID)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("AuthorizeRequest succeeded, want an error")
+			}
+
+			w := httptest.NewRecorder()
+			authz.WriteError(w, err)
+			if w.Code != tc.status {
+				t.Errorf("WriteError responded with %d, want %d", w.Code, tc.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package ballots
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryBallotRepository keeps ballots in memory, for tests and for running
+// the server without a database. It is safe for concurrent use.
+type MemoryBallotRepository struct {
+	mu      sync.RWMutex
+	ballots map[string]Ballot
+}
+
+func NewMemoryBallotRepository() *MemoryBallotRepository {
+	return &MemoryBallotRepository{
+		ballots: make(map[string]Ballot),
+	}
+}
+
+func (r *MemoryBallotRepository) CreateBallot(ctx context.Context, ballot Ballot) (*Ballot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	ballot.CreatedAt = now
+	ballot.UpdatedAt = now
+
+	if ballot.ID == "" {
+		ballot.ID = primitive.NewObjectID().Hex()
+	}
+
+	if _, ok := r.ballots[ballot.ID]; ok {
+		return nil, fmt.Errorf("failed to create ballot: duplicate ID %s", ballot.ID)
+	}
+
+	r.ballots[ballot.ID] = cloneBallot(ballot)
+	return &ballot, nil
+}
+
+func (r *MemoryBallotRepository) GetBallotByID(ctx context.Context, id string) (*Ballot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ballot, ok := r.ballots[id]
+	if !ok {
+		return nil, apperr.NotFound("ballot not found")
+	}
+
+	ballot = cloneBallot(ballot)
+	return &ballot, nil
+}
+
+func (r *MemoryBallotRepository) UpdateBallot(ctx context.Context, id string, ballot Ballot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.ballots[id]
+	if !ok {
+		return apperr.NotFound("ballot not found")
+	}
+
+	ballot.UpdatedAt = time.Now()
+	ballot.ID = id
+	// An unset snapshot time is left out of the update, as omitempty does
+	if ballot.RollSnapshotAt == nil {
+		ballot.RollSnapshotAt = existing.RollSnapshotAt
+	}
+
+	r.ballots[id] = cloneBallot(ballot)
+	return nil
+}
+
+func (r *MemoryBallotRepository) SetRollSnapshotAt(ctx context.Context, id string, snapshotAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ballot, ok := r.ballots[id]
+	if !ok {
+		return apperr.NotFound("ballot not found")
+	}
+
+	ballot.RollSnapshotAt = &snapshotAt
+	ballot.UpdatedAt = time.Now()
+	r.ballots[id] = ballot
+	return nil
+}
+
+func (r *MemoryBallotRepository) DeleteBallot(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.ballots[id]; !ok {
+		return apperr.NotFound("ballot not found")
+	}
+
+	delete(r.ballots, id)
+	return nil
+}
+
+func (r *MemoryBallotRepository) ListBallots(ctx context.Context, organizationID string, page pagination.Page) ([]Ballot, error) {
+	ballots := r.inOrganization(organizationID)
+	return pagination.Slice(ballots, page, func(ballot Ballot) (any, string) {
+		return ballot.CreatedAt, ballot.ID
+	}), nil
+}
+
+func (r *MemoryBallotRepository) CountBallots(ctx context.Context, organizationID string) (int64, error) {
+	return int64(len(r.inOrganization(organizationID))), nil
+}
+
+// inOrganization returns copies of the organization's ballots, or of every
+// ballot if organizationID is empty.
+func (r *MemoryBallotRepository) inOrganization(organizationID string) []Ballot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ballots []Ballot
+	for _, ballot := range r.ballots {
+		if organizationID == "" || ballot.OrganizationID == organizationID {
+			ballots = append(ballots, cloneBallot(ballot))
+		}
+	}
+	return ballots
+}
+
+// cloneBallot copies the slices of a ballot so stored ballots can't be
+// changed through the copies handed out.
+func cloneBallot(ballot Ballot) Ballot {
+	questions := make([]Question, len(ballot.Questions))
+	for i, question := range ballot.Questions {
+		question.Options = append([]Option(nil), question.Options...)
+		questions[i] = question
+	}
+	ballot.Questions = questions
+	ballot.Eligibility.Roles = append(ballot.Eligibility.Roles[:0:0], ballot.Eligibility.Roles...)
+	ballot.Eligibility.UserIDs = append(ballot.Eligibility.UserIDs[:0:0], ballot.Eligibility.UserIDs...)
+	return ballot
+}
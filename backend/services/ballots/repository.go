@@ -0,0 +1,166 @@
+package ballots
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoDBBallotRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoDBBallotRepository(collection *mongo.Collection) *MongoDBBallotRepository {
+	return &MongoDBBallotRepository{
+		collection: collection,
+	}
+}
+
+func (r *MongoDBBallotRepository) CreateBallot(ctx context.Context, ballot Ballot) (*Ballot, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	ballot.CreatedAt = now
+	ballot.UpdatedAt = now
+
+	if ballot.ID == "" {
+		ballot.ID = primitive.NewObjectID().Hex()
+	}
+
+	_, err := r.collection.InsertOne(ctx, ballot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ballot: %w", err)
+	}
+
+	return &ballot, nil
+}
+
+func (r *MongoDBBallotRepository) GetBallotByID(ctx context.Context, id string) (*Ballot, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var ballot Ballot
+	filter := bson.M{"_id": id}
+
+	err := r.collection.FindOne(ctx, filter).Decode(&ballot)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperr.NotFound("ballot not found")
+		}
+		return nil, fmt.Errorf("failed to get ballot: %w", err)
+	}
+
+	return &ballot, nil
+}
+
+func (r *MongoDBBallotRepository) UpdateBallot(ctx context.Context, id string, ballot Ballot) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	ballot.UpdatedAt = time.Now()
+	ballot.ID = id
+
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": ballot}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update ballot: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return apperr.NotFound("ballot not found")
+	}
+
+	return nil
+}
+
+func (r *MongoDBBallotRepository) SetRollSnapshotAt(ctx context.Context, id string, snapshotAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{
+		"roll_snapshot_at": snapshotAt,
+		"updated_at":       time.Now(),
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update ballot: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return apperr.NotFound("ballot not found")
+	}
+
+	return nil
+}
+
+func (r *MongoDBBallotRepository) DeleteBallot(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id}
+
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete ballot: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return apperr.NotFound("ballot not found")
+	}
+
+	return nil
+}
+
+func (r *MongoDBBallotRepository) ListBallots(ctx context.Context, organizationID string, page pagination.Page) ([]Ballot, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if organizationID != "" {
+		filter["organization_id"] = organizationID
+	}
+	filter = pagination.MongoFilter(filter, page, "created_at")
+
+	opts := pagination.MongoFindOptions(page, "created_at")
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ballots: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var ballots []Ballot
+	if err = cursor.All(ctx, &ballots); err != nil {
+		return nil, fmt.Errorf("failed to decode ballots: %w", err)
+	}
+
+	return ballots, nil
+}
+
+func (r *MongoDBBallotRepository) CountBallots(ctx context.Context, organizationID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if organizationID != "" {
+		filter["organization_id"] = organizationID
+	}
+
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count ballots: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,323 @@
+package ballots
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/bpalazzi512/easy-ballot/backend/tally"
+	"github.com/bpalazzi512/easy-ballot/backend/validation"
+)
+
+const (
+	maxTitleLength       = 200
+	maxDescriptionLength = 5000
+)
+
+type BallotService struct {
+	repository BallotRepository
+	votes      VoteCounter
+}
+
+// NewBallotService creates a ballot service. votes is consulted before a
+// ballot is deleted.
+func NewBallotService(repository BallotRepository, votes VoteCounter) *BallotService {
+	return &BallotService{
+		repository: repository,
+		votes:      votes,
+	}
+}
+
+func (s *BallotService) CreateBallot(ctx context.Context, ballot CreateBallotRequest) (*Ballot, error) {
+	questions := assignQuestionIDs(ballot.Questions)
+
+	newBallot := Ballot{
+		OrganizationID: ballot.OrganizationID,
+		Title:          ballot.Title,
+		Description:    ballot.Description,
+		OpensAt:        ballot.OpensAt,
+		ClosesAt:       ballot.ClosesAt,
+		Questions:      questions,
+		Eligibility:    applyEligibilityDefaults(ballot.Eligibility),
+		Secret:         ballot.Secret,
+	}
+
+	if err := s.validateBallot(newBallot); err != nil {
+		return nil, err
+	}
+
+	return s.repository.CreateBallot(ctx, newBallot)
+}
+
+func (s *BallotService) GetBallotByID(ctx context.Context, id string) (*Ballot, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, apperr.Validation("id", "is required")
+	}
+
+	return s.repository.GetBallotByID(ctx, id)
+}
+
+func (s *BallotService) UpdateBallot(ctx context.Context, id string, ballot Ballot) error {
+	if strings.TrimSpace(id) == "" {
+		return apperr.Validation("id", "is required")
+	}
+
+	ballot.Questions = assignQuestionIDs(ballot.Questions)
+	ballot.Eligibility = applyEligibilityDefaults(ballot.Eligibility)
+	if err := s.validateBallot(ballot); err != nil {
+		return err
+	}
+
+	existingBallot, err := s.repository.GetBallotByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if existingBallot.HasOpened(time.Now()) {
+		return apperr.Conflict("ballot cannot be modified after it has opened")
+	}
+
+	ballot.OrganizationID = existingBallot.OrganizationID
+	ballot.RollSnapshotAt = existingBallot.RollSnapshotAt
+	ballot.CreatedAt = existingBallot.CreatedAt
+	ballot.UpdatedAt = time.Now()
+
+	return s.repository.UpdateBallot(ctx, id, ballot)
+}
+
+// OpenBallot starts the voting window immediately. freeze is called with the
+// opened ballot before it is saved, and the ballot stays closed if it fails,
+// so a ballot never opens without its voter roll.
+func (s *BallotService) OpenBallot(ctx context.Context, id string, freeze func(context.Context, *Ballot) error) (*Ballot, error) {
+	ballot, err := s.GetBallotByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if ballot.IsOpen(now) {
+		return nil, apperr.Conflict("ballot is already open")
+	}
+	if !now.Before(ballot.ClosesAt) {
+		return nil, apperr.Conflict("ballot has already closed")
+	}
+
+	ballot.OpensAt = now
+	if err := freeze(ctx, ballot); err != nil {
+		return nil, err
+	}
+	if err := s.repository.UpdateBallot(ctx, id, *ballot); err != nil {
+		return nil, err
+	}
+
+	return ballot, nil
+}
+
+// CloseBallot ends the voting window immediately.
+func (s *BallotService) CloseBallot(ctx context.Context, id string) (*Ballot, error) {
+	ballot, err := s.GetBallotByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !ballot.IsOpen(now) {
+		return nil, apperr.Conflict("ballot is not open")
+	}
+
+	ballot.ClosesAt = now
+	if err := s.repository.UpdateBallot(ctx, id, *ballot); err != nil {
+		return nil, err
+	}
+
+	return ballot, nil
+}
+
+// SetEligibility replaces the rules used to build the ballot's voter roll.
+func (s *BallotService) SetEligibility(ctx context.Context, id string, eligibility Eligibility) (*Ballot, error) {
+	ballot, err := s.GetBallotByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if ballot.HasOpened(time.Now()) {
+		return nil, apperr.Conflict("eligibility cannot be changed after the ballot has opened")
+	}
+
+	eligibility = applyEligibilityDefaults(eligibility)
+
+	var v validation.Validator
+	validateEligibility(&v, "", eligibility)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	ballot.Eligibility = eligibility
+	if err := s.repository.UpdateBallot(ctx, id, *ballot); err != nil {
+		return nil, err
+	}
+
+	return ballot, nil
+}
+
+// DeleteBallot deletes a ballot that has not opened and has no votes.
+func (s *BallotService) DeleteBallot(ctx context.Context, id string) error {
+	if strings.TrimSpace(id) == "" {
+		return apperr.Validation("id", "is required")
+	}
+
+	ballot, err := s.repository.GetBallotByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if ballot.HasOpened(time.Now()) {
+		return apperr.Conflict("ballot cannot be deleted after it has opened")
+	}
+
+	count, err := s.votes.CountVotes(ctx, id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return apperr.Conflict("ballot cannot be deleted after votes have been cast")
+	}
+
+	return s.repository.DeleteBallot(ctx, id)
+}
+
+func (s *BallotService) ListBallots(ctx context.Context, organizationID string, page pagination.Page) (*pagination.List[Ballot], error) {
+	page = page.Normalize(pagination.Sort{Field: "created_at", Descending: true})
+
+	ballots, err := s.repository.ListBallots(ctx, organizationID, page.Lookahead())
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repository.CountBallots(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	ballots, nextCursor := pagination.NextPage(ballots, page, func(ballot Ballot) (any, string) {
+		return ballot.CreatedAt, ballot.ID
+	})
+	return &pagination.List[Ballot]{
+		Items:      ballots,
+		NextCursor: nextCursor,
+		Total:      total,
+	}, nil
+}
+
+func (s *BallotService) CountBallots(ctx context.Context, organizationID string) (int64, error) {
+	return s.repository.CountBallots(ctx, organizationID)
+}
+
+func (s *BallotService) validateBallot(ballot Ballot) error {
+	var v validation.Validator
+	v.Required("organization_id", ballot.OrganizationID)
+	if v.Required("title", ballot.Title) {
+		v.Length("title", ballot.Title, 0, maxTitleLength)
+	}
+	v.Length("description", ballot.Description, 0, maxDescriptionLength)
+	v.Check(!ballot.OpensAt.IsZero(), "opens_at", "is required")
+	v.Check(!ballot.ClosesAt.IsZero(), "closes_at", "is required")
+	if !ballot.OpensAt.IsZero() && !ballot.ClosesAt.IsZero() {
+		v.Check(ballot.ClosesAt.After(ballot.OpensAt), "closes_at", "must be after opens_at")
+	}
+	v.Check(len(ballot.Questions) > 0, "questions", "must have at least one question")
+	validateEligibility(&v, "eligibility.", ballot.Eligibility)
+
+	questionIDs := make(map[string]bool)
+	for i, question := range ballot.Questions {
+		field := fmt.Sprintf("questions[%d]", i)
+		validateQuestion(&v, field, question)
+		if questionIDs[question.ID] {
+			v.Add(field+".id", fmt.Sprintf("duplicates question ID %s", question.ID))
+		}
+		questionIDs[question.ID] = true
+	}
+
+	return v.Err()
+}
+
+func validateQuestion(v *validation.Validator, field string, question Question) {
+	v.Required(field+".prompt", question.Prompt)
+
+	for i, option := range question.Options {
+		v.Required(fmt.Sprintf("%s.options[%d].label", field, i), option.Label)
+	}
+
+	if err := question.TallyQuestion().Validate(); err != nil {
+		v.Add(field, err.Error())
+	}
+}
+
+// validateEligibility checks eligibility rules, naming fields with prefix.
+func validateEligibility(v *validation.Validator, prefix string, eligibility Eligibility) {
+	switch eligibility.Mode {
+	case EligibilityAllMembers:
+	case EligibilityRoles:
+		v.Check(len(eligibility.Roles) > 0, prefix+"roles", "must have at least one role")
+		for i, role := range eligibility.Roles {
+			validation.OneOf(v, fmt.Sprintf("%sroles[%d]", prefix, i), role, users.Roles...)
+		}
+	case EligibilityList:
+		v.Check(len(eligibility.UserIDs) > 0, prefix+"user_ids", "must have at least one user ID")
+	default:
+		validation.OneOf(v, prefix+"mode", eligibility.Mode, EligibilityAllMembers, EligibilityRoles, EligibilityList)
+	}
+}
+
+// applyEligibilityDefaults makes every organization member eligible unless
+// the ballot says otherwise.
+func applyEligibilityDefaults(eligibility Eligibility) Eligibility {
+	if eligibility.Mode == "" {
+		eligibility.Mode = EligibilityAllMembers
+	}
+	return eligibility
+}
+
+// assignQuestionIDs fills in IDs for questions and options the client left
+// blank and applies method defaults: plurality with a single seat, one
+// selection per seat for plurality and any number of approvals, ranks or
+// scores otherwise, and a 0-5 range for score voting. IDs only need to be
+// unique within a ballot, so positional identifiers are sufficient.
+func assignQuestionIDs(questions []Question) []Question {
+	assigned := make([]Question, len(questions))
+	for i, question := range questions {
+		if strings.TrimSpace(question.ID) == "" {
+			question.ID = fmt.Sprintf("q%d", i+1)
+		}
+		if question.Method == "" {
+			question.Method = tally.Plurality
+		}
+		if question.Seats == 0 {
+			question.Seats = 1
+		}
+		if question.MaxSelections == 0 {
+			question.MaxSelections = len(question.Options)
+			if question.Method == tally.Plurality {
+				question.MaxSelections = question.Seats
+			}
+		}
+		if question.Method == tally.Score && question.MinScore == 0 && question.MaxScore == 0 {
+			question.MaxScore = 5
+		}
+
+		options := make([]Option, len(question.Options))
+		for j, option := range question.Options {
+			if strings.TrimSpace(option.ID) == "" {
+				option.ID = fmt.Sprintf("%s-o%d", question.ID, j+1)
+			}
+			options[j] = option
+		}
+		question.Options = options
+
+		assigned[i] = question
+	}
+	return assigned
+}
@@ -0,0 +1,239 @@
+package ballots_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/bpalazzi512/easy-ballot/backend/tally"
+)
+
+// voteCounts counts votes from a map of ballot IDs to counts.
+type voteCounts map[string]int64
+
+func (c voteCounts) CountVotes(ctx context.Context, ballotID string) (int64, error) {
+	return c[ballotID], nil
+}
+
+func question() ballots.Question {
+	return ballots.Question{Prompt: "Who should chair the committee?", Options: []ballots.Option{{ID: "a", Label: "Ada"}, {ID: "b", Label: "Grace"}}}
+}
+
+func TestCreateBallot(t *testing.T) {
+	now := time.Now()
+	valid := ballots.CreateBallotRequest{
+		OrganizationID: "organization",
+		Title:          "Committee chair",
+		OpensAt:        now.Add(time.Hour),
+		ClosesAt:       now.Add(2 * time.Hour),
+		Questions:      []ballots.Question{question()},
+	}
+
+	for _, tc := range []struct {
+		name string
+		// change modifies a valid request
+		change func(request *ballots.CreateBallotRequest)
+		// field is the field a validation error is expected for
+		field string
+	}{
+		{name: "Valid", change: func(request *ballots.CreateBallotRequest) {}},
+		{name: "NoTitle", field: "title", change: func(request *ballots.CreateBallotRequest) {
+			request.Title = ""
+		}},
+		{name: "NoOrganization", field: "organization_id", change: func(request *ballots.CreateBallotRequest) {
+			request.OrganizationID = ""
+		}},
+		{name: "ClosesBeforeOpening", field: "closes_at", change: func(request *ballots.CreateBallotRequest) {
+			request.ClosesAt = request.OpensAt.Add(-time.Minute)
+		}},
+		{name: "NoQuestions", field: "questions", change: func(request *ballots.CreateBallotRequest) {
+			request.Questions = nil
+		}},
+		{name: "DuplicateQuestionIDs", field: "questions[1].id", change: func(request *ballots.CreateBallotRequest) {
+			first, second := question(), question()
+			first.ID, second.ID = "q", "q"
+			request.Questions = []ballots.Question{first, second}
+		}},
+		{name: "RolesWithoutRoles", field: "eligibility.roles", change: func(request *ballots.CreateBallotRequest) {
+			request.Eligibility = ballots.Eligibility{Mode: ballots.EligibilityRoles}
+		}},
+		{name: "UnknownRole", field: "eligibility.roles[0]", change: func(request *ballots.CreateBallotRequest) {
+			request.Eligibility = ballots.Eligibility{Mode: ballots.EligibilityRoles, Roles: []users.UserRole{"chair"}}
+		}},
+		{name: "EmptyList", field: "eligibility.user_ids", change: func(request *ballots.CreateBallotRequest) {
+			request.Eligibility = ballots.Eligibility{Mode: ballots.EligibilityList}
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			service := ballots.NewBallotService(ballots.NewMemoryBallotRepository(), voteCounts{})
+
+			request := valid
+			request.Questions = append([]ballots.Question(nil), valid.Questions...)
+			tc.change(&request)
+
+			ballot, err := service.CreateBallot(context.Background(), request)
+			if tc.field != "" {
+				var validationErr *apperr.ValidationError
+				if !errors.As(err, &validationErr) || len(validationErr.Fields[tc.field]) == 0 {
+					t.Fatalf("CreateBallot returned %v, want a validation error for %s", err, tc.field)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateBallot: %v", err)
+			}
+
+			// Blank IDs, methods and eligibility get defaults
+			created := ballot.Questions[0]
+			if created.ID != "q1" || created.Method != tally.Plurality || created.Seats != 1 {
+				t.Errorf("CreateBallot stored question %+v, want ID q1 with one plurality seat", created)
+			}
+			if ballot.Eligibility.Mode != ballots.EligibilityAllMembers {
+				t.Errorf("CreateBallot stored eligibility %q, want %q", ballot.Eligibility.Mode, ballots.EligibilityAllMembers)
+			}
+		})
+	}
+}
+
+func TestBallotLifecycle(t *testing.T) {
+	type state int
+	const (
+		scheduled state = iota
+		open
+		closed
+	)
+
+	for _, tc := range []struct {
+		name      string
+		state     state
+		votes     int64
+		operation string
+		err       error
+	}{
+		{name: "OpenScheduled", state: scheduled, operation: "open"},
+		{name: "OpenOpen", state: open, operation: "open", err: apperr.ErrConflict},
+		{name: "OpenClosed", state: closed, operation: "open", err: apperr.ErrConflict},
+		{name: "CloseOpen", state: open, operation: "close"},
+		{name: "CloseScheduled", state: scheduled, operation: "close", err: apperr.ErrConflict},
+		{name: "CloseClosed", state: closed, operation: "close", err: apperr.ErrConflict},
+		{name: "UpdateScheduled", state: scheduled, operation: "update"},
+		{name: "UpdateOpen", state: open, operation: "update", err: apperr.ErrConflict},
+		{name: "EligibilityScheduled", state: scheduled, operation: "eligibility"},
+		{name: "EligibilityOpen", state: open, operation: "eligibility", err: apperr.ErrConflict},
+		{name: "DeleteScheduled", state: scheduled, operation: "delete"},
+		{name: "DeleteScheduledWithVotes", state: scheduled, votes: 1, operation: "delete", err: apperr.ErrConflict},
+		{name: "DeleteOpen", state: open, operation: "delete", err: apperr.ErrConflict},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			repository := ballots.NewMemoryBallotRepository()
+
+			now := time.Now()
+			ballot := ballots.Ballot{
+				OrganizationID: "organization",
+				Title:          "Committee chair",
+				Questions:      []ballots.Question{question()},
+				Eligibility:    ballots.Eligibility{Mode: ballots.EligibilityAllMembers},
+			}
+			switch tc.state {
+			case scheduled:
+				ballot.OpensAt, ballot.ClosesAt = now.Add(time.Hour), now.Add(2*time.Hour)
+			case open:
+				ballot.OpensAt, ballot.ClosesAt = now.Add(-time.Hour), now.Add(time.Hour)
+			case closed:
+				ballot.OpensAt, ballot.ClosesAt = now.Add(-2*time.Hour), now.Add(-time.Hour)
+			}
+			created, err := repository.CreateBallot(ctx, ballot)
+			if err != nil {
+				t.Fatalf("CreateBallot: %v", err)
+			}
+
+			service := ballots.NewBallotService(repository, voteCounts{created.ID: tc.votes})
+			froze := false
+			switch tc.operation {
+			case "open":
+				_, err = service.OpenBallot(ctx, created.ID, func(ctx context.Context, ballot *ballots.Ballot) error {
+					froze = true
+					return nil
+				})
+			case "close":
+				_, err = service.CloseBallot(ctx, created.ID)
+			case "update":
+				update := *created
+				update.Title = "Committee chair election"
+				err = service.UpdateBallot(ctx, created.ID, update)
+			case "eligibility":
+				_, err = service.SetEligibility(ctx, created.ID, ballots.Eligibility{Mode: ballots.EligibilityRoles, Roles: []users.UserRole{users.RoleVoter}})
+			case "delete":
+				err = service.DeleteBallot(ctx, created.ID)
+			}
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("%s returned %v, want %v", tc.operation, err, tc.err)
+			}
+
+			stored, getErr := repository.GetBallotByID(ctx, created.ID)
+			if tc.operation == "delete" && tc.err == nil {
+				if !errors.Is(getErr, apperr.ErrNotFound) {
+					t.Errorf("GetBallotByID after deleting returned %v, want not found", getErr)
+				}
+				return
+			}
+			if getErr != nil {
+				t.Fatalf("GetBallotByID: %v", getErr)
+			}
+
+			isOpen := stored.IsOpen(time.Now())
+			switch {
+			case tc.err != nil:
+				if isOpen != (tc.state == open) {
+					t.Errorf("a failed %s changed whether the ballot is open", tc.operation)
+				}
+			case tc.operation == "open":
+				if !isOpen || !froze {
+					t.Errorf("after opening, the ballot is open %t and its roll frozen %t, want both", isOpen, froze)
+				}
+			case tc.operation == "close":
+				if isOpen {
+					t.Error("the ballot is still open after closing it")
+				}
+			}
+		})
+	}
+}
+
+func TestOpenBallotFreezeFails(t *testing.T) {
+	ctx := context.Background()
+	repository := ballots.NewMemoryBallotRepository()
+	now := time.Now()
+	created, err := repository.CreateBallot(ctx, ballots.Ballot{
+		OrganizationID: "organization",
+		Title:          "Committee chair",
+		OpensAt:        now.Add(time.Hour),
+		ClosesAt:       now.Add(2 * time.Hour),
+		Questions:      []ballots.Question{question()},
+	})
+	if err != nil {
+		t.Fatalf("CreateBallot: %v", err)
+	}
+
+	failure := errors.New("roll unavailable")
+	service := ballots.NewBallotService(repository, voteCounts{})
+	_, err = service.OpenBallot(ctx, created.ID, func(ctx context.Context, ballot *ballots.Ballot) error {
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("OpenBallot returned %v, want %v", err, failure)
+	}
+
+	stored, err := repository.GetBallotByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetBallotByID: %v", err)
+	}
+	if stored.HasOpened(time.Now()) {
+		t.Error("the ballot opened although its roll could not be frozen")
+	}
+}
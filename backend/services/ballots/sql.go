@@ -0,0 +1,228 @@
+package ballots
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SQLBallotRepository stores ballots in the ballots table of a SQL database.
+// Questions and eligibility are kept as JSON.
+type SQLBallotRepository struct {
+	db *sqldb.DB
+}
+
+func NewSQLBallotRepository(db *sqldb.DB) *SQLBallotRepository {
+	return &SQLBallotRepository{
+		db: db,
+	}
+}
+
+const ballotColumns = "id, organization_id, title, description, opens_at, closes_at, questions, eligibility, secret, roll_snapshot_at, created_at, updated_at"
+
+func (r *SQLBallotRepository) CreateBallot(ctx context.Context, ballot Ballot) (*Ballot, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	ballot.CreatedAt = now
+	ballot.UpdatedAt = now
+
+	if ballot.ID == "" {
+		ballot.ID = primitive.NewObjectID().Hex()
+	}
+
+	questions, eligibility, err := encodeBallot(ballot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ballot: %w", err)
+	}
+
+	q := r.db.Query()
+	values := q.Values(ballot.ID, ballot.OrganizationID, ballot.Title, ballot.Description, ballot.OpensAt, ballot.ClosesAt,
+		questions, eligibility, ballot.Secret, ballot.RollSnapshotAt, ballot.CreatedAt, ballot.UpdatedAt)
+	_, err = r.db.ExecContext(ctx, "INSERT INTO ballots ("+ballotColumns+") VALUES "+values, q.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ballot: %w", err)
+	}
+
+	return &ballot, nil
+}
+
+func (r *SQLBallotRepository) GetBallotByID(ctx context.Context, id string) (*Ballot, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	ballot, err := scanBallot(r.db.QueryRowContext(ctx, "SELECT "+ballotColumns+" FROM ballots WHERE id = "+q.Arg(id), q.Args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperr.NotFound("ballot not found")
+		}
+		return nil, fmt.Errorf("failed to get ballot: %w", err)
+	}
+
+	return ballot, nil
+}
+
+func (r *SQLBallotRepository) UpdateBallot(ctx context.Context, id string, ballot Ballot) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	ballot.UpdatedAt = time.Now()
+	ballot.ID = id
+
+	questions, eligibility, err := encodeBallot(ballot)
+	if err != nil {
+		return fmt.Errorf("failed to update ballot: %w", err)
+	}
+
+	// An unset snapshot time keeps the stored one, as omitempty does in MongoDB
+	q := r.db.Query()
+	result, err := r.db.ExecContext(ctx, "UPDATE ballots SET "+
+		"organization_id = "+q.Arg(ballot.OrganizationID)+
+		", title = "+q.Arg(ballot.Title)+
+		", description = "+q.Arg(ballot.Description)+
+		", opens_at = "+q.Arg(ballot.OpensAt)+
+		", closes_at = "+q.Arg(ballot.ClosesAt)+
+		", questions = "+q.Arg(questions)+
+		", eligibility = "+q.Arg(eligibility)+
+		", secret = "+q.Arg(ballot.Secret)+
+		", roll_snapshot_at = COALESCE("+q.Arg(ballot.RollSnapshotAt)+", roll_snapshot_at)"+
+		", created_at = "+q.Arg(ballot.CreatedAt)+
+		", updated_at = "+q.Arg(ballot.UpdatedAt)+
+		" WHERE id = "+q.Arg(id), q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to update ballot: %w", err)
+	}
+
+	return ballotUpdated(result)
+}
+
+func (r *SQLBallotRepository) SetRollSnapshotAt(ctx context.Context, id string, snapshotAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	result, err := r.db.ExecContext(ctx, "UPDATE ballots SET roll_snapshot_at = "+q.Arg(snapshotAt)+", updated_at = "+q.Arg(time.Now())+" WHERE id = "+q.Arg(id), q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to update ballot: %w", err)
+	}
+
+	return ballotUpdated(result)
+}
+
+func (r *SQLBallotRepository) DeleteBallot(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	result, err := r.db.ExecContext(ctx, "DELETE FROM ballots WHERE id = "+q.Arg(id), q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete ballot: %w", err)
+	}
+
+	return ballotUpdated(result)
+}
+
+func (r *SQLBallotRepository) ListBallots(ctx context.Context, organizationID string, page pagination.Page) ([]Ballot, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	conditions := ballotConditions(q, organizationID)
+	if after := pagination.SQLAfter(q, page, "created_at"); after != "" {
+		conditions = append(conditions, after)
+	}
+
+	query := "SELECT " + ballotColumns + " FROM ballots" + sqldb.Where(conditions) + pagination.SQLOrderBy(q, page, "created_at")
+	rows, err := r.db.QueryContext(ctx, query, q.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ballots: %w", err)
+	}
+	defer rows.Close()
+
+	var ballots []Ballot
+	for rows.Next() {
+		ballot, err := scanBallot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode ballots: %w", err)
+		}
+		ballots = append(ballots, *ballot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list ballots: %w", err)
+	}
+
+	return ballots, nil
+}
+
+func (r *SQLBallotRepository) CountBallots(ctx context.Context, organizationID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	var count int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM ballots"+sqldb.Where(ballotConditions(q, organizationID)), q.Args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count ballots: %w", err)
+	}
+
+	return count, nil
+}
+
+func encodeBallot(ballot Ballot) (questions, eligibility string, err error) {
+	questionsJSON, err := json.Marshal(ballot.Questions)
+	if err != nil {
+		return "", "", err
+	}
+	eligibilityJSON, err := json.Marshal(ballot.Eligibility)
+	if err != nil {
+		return "", "", err
+	}
+	return string(questionsJSON), string(eligibilityJSON), nil
+}
+
+func scanBallot(row sqldb.Scanner) (*Ballot, error) {
+	var ballot Ballot
+	var questions, eligibility []byte
+	err := row.Scan(&ballot.ID, &ballot.OrganizationID, &ballot.Title, &ballot.Description, &ballot.OpensAt, &ballot.ClosesAt,
+		&questions, &eligibility, &ballot.Secret, &ballot.RollSnapshotAt, &ballot.CreatedAt, &ballot.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(questions, &ballot.Questions); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(eligibility, &ballot.Eligibility); err != nil {
+		return nil, err
+	}
+	return &ballot, nil
+}
+
+func ballotUpdated(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read rows affected: %w", err)
+	}
+	if affected == 0 {
+		return apperr.NotFound("ballot not found")
+	}
+	return nil
+}
+
+// ballotConditions limits ballots to one organization unless organizationID
+// is empty.
+func ballotConditions(q *sqldb.Query, organizationID string) []string {
+	if organizationID == "" {
+		return nil
+	}
+	return []string{"organization_id = " + q.Arg(organizationID)}
+}
@@ -0,0 +1,139 @@
+package ballots
+
+import (
+	"context"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/bpalazzi512/easy-ballot/backend/tally"
+)
+
+type EligibilityMode string
+
+const (
+	EligibilityAllMembers EligibilityMode = "all_members"
+	EligibilityRoles      EligibilityMode = "roles"
+	EligibilityList       EligibilityMode = "list"
+)
+
+// Eligibility defines who is placed on a ballot's voter roll when it opens:
+// every member of the organization, members holding one of Roles, or the
+// explicit list of UserIDs.
+type Eligibility struct {
+	Mode    EligibilityMode  `json:"mode" bson:"mode"`
+	Roles   []users.UserRole `json:"roles,omitempty" bson:"roles,omitempty"`
+	UserIDs []string         `json:"user_ids,omitempty" bson:"user_ids,omitempty"`
+}
+
+type Option struct {
+	ID    string `json:"id" bson:"id"`
+	Label string `json:"label" bson:"label"`
+}
+
+type Question struct {
+	ID            string       `json:"id" bson:"id"`
+	Prompt        string       `json:"prompt" bson:"prompt"`
+	Method        tally.Method `json:"method" bson:"method"`
+	Options       []Option     `json:"options" bson:"options"`
+	Seats         int          `json:"seats" bson:"seats"`
+	MaxSelections int          `json:"max_selections" bson:"max_selections"`
+	MinScore      int          `json:"min_score,omitempty" bson:"min_score,omitempty"`
+	MaxScore      int          `json:"max_score,omitempty" bson:"max_score,omitempty"`
+	FullRanking   bool         `json:"full_ranking,omitempty" bson:"full_ranking,omitempty"`
+}
+
+type Ballot struct {
+	ID             string      `json:"id" bson:"_id,omitempty"`
+	OrganizationID string      `json:"organization_id" bson:"organization_id"`
+	Title          string      `json:"title" bson:"title"`
+	Description    string      `json:"description" bson:"description"`
+	OpensAt        time.Time   `json:"opens_at" bson:"opens_at"`
+	ClosesAt       time.Time   `json:"closes_at" bson:"closes_at"`
+	Questions      []Question  `json:"questions" bson:"questions"`
+	Eligibility    Eligibility `json:"eligibility" bson:"eligibility"`
+	Secret         bool        `json:"secret" bson:"secret"`
+	RollSnapshotAt *time.Time  `json:"roll_snapshot_at,omitempty" bson:"roll_snapshot_at,omitempty"`
+	CreatedAt      time.Time   `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at" bson:"updated_at"`
+}
+
+// IsOpen reports whether votes may be cast on the ballot at the given time.
+func (b Ballot) IsOpen(now time.Time) bool {
+	return !now.Before(b.OpensAt) && now.Before(b.ClosesAt)
+}
+
+// HasOpened reports whether the ballot's voting window has started.
+func (b Ballot) HasOpened(now time.Time) bool {
+	return !now.Before(b.OpensAt)
+}
+
+// Question returns the question with the given ID, if present.
+func (b Ballot) Question(id string) (*Question, bool) {
+	for i := range b.Questions {
+		if b.Questions[i].ID == id {
+			return &b.Questions[i], true
+		}
+	}
+	return nil, false
+}
+
+// OptionIDs returns the question's option IDs in ballot order.
+func (q Question) OptionIDs() []string {
+	ids := make([]string, len(q.Options))
+	for i, option := range q.Options {
+		ids[i] = option.ID
+	}
+	return ids
+}
+
+// TallyQuestion describes the question to the tally engine. Ballots stored
+// before methods and seats were configurable are treated as single-seat
+// plurality questions.
+func (q Question) TallyQuestion() tally.Question {
+	method := q.Method
+	if method == "" {
+		method = tally.Plurality
+	}
+	seats := q.Seats
+	if seats == 0 {
+		seats = 1
+	}
+
+	return tally.Question{
+		ID:            q.ID,
+		Method:        method,
+		Options:       q.OptionIDs(),
+		Seats:         seats,
+		MaxSelections: q.MaxSelections,
+		MinScore:      q.MinScore,
+		MaxScore:      q.MaxScore,
+		FullRanking:   q.FullRanking,
+	}
+}
+
+type CreateBallotRequest struct {
+	OrganizationID string      `json:"organization_id"`
+	Title          string      `json:"title"`
+	Description    string      `json:"description"`
+	OpensAt        time.Time   `json:"opens_at"`
+	ClosesAt       time.Time   `json:"closes_at"`
+	Questions      []Question  `json:"questions"`
+	Eligibility    Eligibility `json:"eligibility"`
+	Secret         bool        `json:"secret"`
+}
+
+// VoteCounter counts the votes cast on a ballot.
+type VoteCounter interface {
+	CountVotes(ctx context.Context, ballotID string) (int64, error)
+}
+
+type BallotRepository interface {
+	CreateBallot(ctx context.Context, ballot Ballot) (*Ballot, error)
+	GetBallotByID(ctx context.Context, id string) (*Ballot, error)
+	UpdateBallot(ctx context.Context, id string, ballot Ballot) error
+	SetRollSnapshotAt(ctx context.Context, id string, snapshotAt time.Time) error
+	DeleteBallot(ctx context.Context, id string) error
+	ListBallots(ctx context.Context, organizationID string, page pagination.Page) ([]Ballot, error)
+	CountBallots(ctx context.Context, organizationID string) (int64, error)
+}
@@ -0,0 +1,200 @@
+package invitations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryInvitationRepository keeps invitations in memory, for tests and for
+// running the server without a database. It is safe for concurrent use.
+// Expired invitations are dropped as new ones are created, as the TTL index
+// does in MongoDB.
+type MemoryInvitationRepository struct {
+	mu          sync.Mutex
+	invitations []Invitation
+}
+
+func NewMemoryInvitationRepository() *MemoryInvitationRepository {
+	return &MemoryInvitationRepository{}
+}
+
+func (r *MemoryInvitationRepository) CreateInvitation(ctx context.Context, invitation Invitation) (*Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	invitation.CreatedAt = now
+	invitation.UpdatedAt = now
+
+	if invitation.ID == "" {
+		invitation.ID = primitive.NewObjectID().Hex()
+	}
+
+	r.removeExpired(now)
+	for _, stored := range r.invitations {
+		if stored.ID == invitation.ID || stored.TokenHash == invitation.TokenHash {
+			return nil, fmt.Errorf("failed to create invitation: duplicate invitation")
+		}
+	}
+
+	r.invitations = append(r.invitations, invitation)
+	return &invitation, nil
+}
+
+func (r *MemoryInvitationRepository) GetInvitationByID(ctx context.Context, id string) (*Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.find(func(invitation Invitation) bool {
+		return invitation.ID == id
+	})
+	if i < 0 {
+		return nil, apperr.NotFound("invitation not found")
+	}
+
+	invitation := r.invitations[i]
+	return &invitation, nil
+}
+
+func (r *MemoryInvitationRepository) GetPendingInvitation(ctx context.Context, organizationID, email string, now time.Time) (*Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.find(func(invitation Invitation) bool {
+		return isPending(invitation, now) && invitation.OrganizationID == organizationID && invitation.Email == email
+	})
+	if i < 0 {
+		return nil, apperr.NotFound("invitation not found")
+	}
+
+	invitation := r.invitations[i]
+	return &invitation, nil
+}
+
+func (r *MemoryInvitationRepository) ListPendingInvitations(ctx context.Context, organizationID string, now time.Time) ([]Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var invitations []Invitation
+	for _, invitation := range r.invitations {
+		if isPending(invitation, now) && invitation.OrganizationID == organizationID {
+			invitations = append(invitations, invitation)
+		}
+	}
+
+	// Newest first
+	sort.SliceStable(invitations, func(i, j int) bool {
+		return invitations[i].CreatedAt.After(invitations[j].CreatedAt)
+	})
+	return invitations, nil
+}
+
+func (r *MemoryInvitationRepository) RenewInvitation(ctx context.Context, id, tokenHash string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.find(func(invitation Invitation) bool {
+		return invitation.ID == id && invitation.AcceptedAt == nil
+	})
+	if i < 0 {
+		return apperr.NotFound("invitation not found")
+	}
+
+	r.invitations[i].TokenHash = tokenHash
+	r.invitations[i].ExpiresAt = expiresAt
+	r.invitations[i].UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *MemoryInvitationRepository) ConsumeInvitation(ctx context.Context, tokenHash string, now time.Time) (*Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.find(func(invitation Invitation) bool {
+		return isPending(invitation, now) && invitation.TokenHash == tokenHash
+	})
+	if i < 0 {
+		return nil, ErrInvitationInvalid
+	}
+
+	r.invitations[i].AcceptedAt = &now
+	r.invitations[i].UpdatedAt = now
+
+	invitation := r.invitations[i]
+	return &invitation, nil
+}
+
+func (r *MemoryInvitationRepository) ReleaseInvitation(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.find(func(invitation Invitation) bool {
+		return invitation.ID == id
+	})
+	if i >= 0 {
+		r.invitations[i].AcceptedAt = nil
+		r.invitations[i].UpdatedAt = time.Now()
+	}
+
+	return nil
+}
+
+func (r *MemoryInvitationRepository) DeleteInvitation(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.find(func(invitation Invitation) bool {
+		return invitation.ID == id
+	})
+	if i < 0 {
+		return apperr.NotFound("invitation not found")
+	}
+
+	r.invitations = append(r.invitations[:i], r.invitations[i+1:]...)
+	return nil
+}
+
+func (r *MemoryInvitationRepository) DeleteOrganizationInvitations(ctx context.Context, organizationID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.invitations[:0]
+	for _, invitation := range r.invitations {
+		if invitation.OrganizationID != organizationID {
+			kept = append(kept, invitation)
+		}
+	}
+	deleted := int64(len(r.invitations) - len(kept))
+	r.invitations = kept
+	return deleted, nil
+}
+
+func (r *MemoryInvitationRepository) find(match func(Invitation) bool) int {
+	for i, invitation := range r.invitations {
+		if match(invitation) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *MemoryInvitationRepository) removeExpired(now time.Time) {
+	kept := r.invitations[:0]
+	for _, invitation := range r.invitations {
+		if !invitation.ExpiresAt.Before(now) {
+			kept = append(kept, invitation)
+		}
+	}
+	r.invitations = kept
+}
+
+// isPending mirrors pendingFilter.
+func isPending(invitation Invitation, now time.Time) bool {
+	return invitation.AcceptedAt == nil && invitation.ExpiresAt.After(now)
+}
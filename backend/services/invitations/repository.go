@@ -0,0 +1,201 @@
+package invitations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoDBInvitationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoDBInvitationRepository(collection *mongo.Collection) *MongoDBInvitationRepository {
+	return &MongoDBInvitationRepository{
+		collection: collection,
+	}
+}
+
+func (r *MongoDBInvitationRepository) CreateInvitation(ctx context.Context, invitation Invitation) (*Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	invitation.CreatedAt = now
+	invitation.UpdatedAt = now
+
+	if invitation.ID == "" {
+		invitation.ID = primitive.NewObjectID().Hex()
+	}
+
+	_, err := r.collection.InsertOne(ctx, invitation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	return &invitation, nil
+}
+
+func (r *MongoDBInvitationRepository) GetInvitationByID(ctx context.Context, id string) (*Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var invitation Invitation
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&invitation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperr.NotFound("invitation not found")
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	return &invitation, nil
+}
+
+func (r *MongoDBInvitationRepository) GetPendingInvitation(ctx context.Context, organizationID, email string, now time.Time) (*Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := pendingFilter(now)
+	filter["organization_id"] = organizationID
+	filter["email"] = email
+
+	var invitation Invitation
+	err := r.collection.FindOne(ctx, filter).Decode(&invitation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperr.NotFound("invitation not found")
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	return &invitation, nil
+}
+
+func (r *MongoDBInvitationRepository) ListPendingInvitations(ctx context.Context, organizationID string, now time.Time) ([]Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := pendingFilter(now)
+	filter["organization_id"] = organizationID
+
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var invitations []Invitation
+	if err = cursor.All(ctx, &invitations); err != nil {
+		return nil, fmt.Errorf("failed to decode invitations: %w", err)
+	}
+
+	return invitations, nil
+}
+
+func (r *MongoDBInvitationRepository) RenewInvitation(ctx context.Context, id, tokenHash string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "accepted_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{
+		"token_hash": tokenHash,
+		"expires_at": expiresAt,
+		"updated_at": time.Now(),
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to renew invitation: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return apperr.NotFound("invitation not found")
+	}
+
+	return nil
+}
+
+func (r *MongoDBInvitationRepository) ConsumeInvitation(ctx context.Context, tokenHash string, now time.Time) (*Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := pendingFilter(now)
+	filter["token_hash"] = tokenHash
+	update := bson.M{"$set": bson.M{
+		"accepted_at": now,
+		"updated_at":  now,
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var invitation Invitation
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&invitation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrInvitationInvalid
+		}
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	return &invitation, nil
+}
+
+func (r *MongoDBInvitationRepository) ReleaseInvitation(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$unset": bson.M{"accepted_at": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to release invitation: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MongoDBInvitationRepository) DeleteInvitation(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete invitation: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return apperr.NotFound("invitation not found")
+	}
+
+	return nil
+}
+
+func (r *MongoDBInvitationRepository) DeleteOrganizationInvitations(ctx context.Context, organizationID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"organization_id": organizationID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete invitations: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+func pendingFilter(now time.Time) bson.M {
+	return bson.M{
+		"accepted_at": bson.M{"$exists": false},
+		"expires_at":  bson.M{"$gt": now},
+	}
+}
@@ -0,0 +1,218 @@
+package invitations
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/notifications"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/bpalazzi512/easy-ballot/backend/validation"
+)
+
+type InvitationService struct {
+	repository             InvitationRepository
+	userService            *users.UserService
+	membershipRepository   memberships.MembershipRepository
+	organizationRepository organizations.OrganizationRepository
+	notifier               *notifications.Notifier
+	ttl                    time.Duration
+	baseURL                string
+}
+
+func NewInvitationService(repository InvitationRepository, userService *users.UserService, membershipRepository memberships.MembershipRepository, organizationRepository organizations.OrganizationRepository, notifier *notifications.Notifier, ttl time.Duration, baseURL string) *InvitationService {
+	return &InvitationService{
+		repository:             repository,
+		userService:            userService,
+		membershipRepository:   membershipRepository,
+		organizationRepository: organizationRepository,
+		notifier:               notifier,
+		ttl:                    ttl,
+		baseURL:                baseURL,
+	}
+}
+
+func (s *InvitationService) CreateInvitation(ctx context.Context, organizationID, invitedBy string, request CreateInvitationRequest) (*Invitation, error) {
+	email := strings.TrimSpace(request.Email)
+
+	var v validation.Validator
+	if v.Required("email", email) {
+		v.Email("email", email)
+	}
+	memberships.ValidateMemberRole(&v, request.Role)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.organizationRepository.GetOrganizationByID(ctx, organizationID); err != nil {
+		return nil, err
+	}
+
+	if existingUser, err := s.userService.GetUserByEmail(ctx, email); err == nil {
+		if _, err := s.membershipRepository.GetMembership(ctx, organizationID, existingUser.ID); err == nil {
+			return nil, memberships.ErrAlreadyMember
+		}
+	}
+
+	now := time.Now()
+	if _, err := s.repository.GetPendingInvitation(ctx, organizationID, email, now); err == nil {
+		return nil, ErrAlreadyInvited
+	}
+
+	token, tokenHash, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.repository.CreateInvitation(ctx, Invitation{
+		OrganizationID: organizationID,
+		Email:          email,
+		Role:           request.Role,
+		TokenHash:      tokenHash,
+		InvitedBy:      invitedBy,
+		ExpiresAt:      now.Add(s.ttl),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.deliver(ctx, invitation, token)
+
+	return invitation, nil
+}
+
+func (s *InvitationService) GetInvitationByID(ctx context.Context, id string) (*Invitation, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, apperr.Validation("id", "is required")
+	}
+
+	return s.repository.GetInvitationByID(ctx, id)
+}
+
+func (s *InvitationService) ListPendingInvitations(ctx context.Context, organizationID string) ([]Invitation, error) {
+	return s.repository.ListPendingInvitations(ctx, organizationID, time.Now())
+}
+
+// ResendInvitation emails a fresh token and expiry, invalidating the old link.
+func (s *InvitationService) ResendInvitation(ctx context.Context, id string) (*Invitation, error) {
+	invitation, err := s.GetInvitationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.AcceptedAt != nil {
+		return nil, apperr.Conflict("invitation has already been accepted")
+	}
+
+	token, tokenHash, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.TokenHash = tokenHash
+	invitation.ExpiresAt = time.Now().Add(s.ttl)
+	if err := s.repository.RenewInvitation(ctx, invitation.ID, invitation.TokenHash, invitation.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	s.deliver(ctx, invitation, token)
+
+	return invitation, nil
+}
+
+func (s *InvitationService) RevokeInvitation(ctx context.Context, id string) error {
+	if strings.TrimSpace(id) == "" {
+		return apperr.Validation("id", "is required")
+	}
+
+	return s.repository.DeleteInvitation(ctx, id)
+}
+
+// AcceptInvitation redeems a token. The invited email is linked to its
+// existing account or, if it has none, a new account is created from the
+// request.
+func (s *InvitationService) AcceptInvitation(ctx context.Context, request AcceptInvitationRequest) (*memberships.Member, error) {
+	if strings.TrimSpace(request.Token) == "" {
+		return nil, ErrInvitationInvalid
+	}
+
+	invitation, err := s.repository.ConsumeInvitation(ctx, hashToken(request.Token), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := s.join(ctx, *invitation, request)
+	if err != nil {
+		// Leave the invitation usable so the user can correct the request
+		if releaseErr := s.repository.ReleaseInvitation(ctx, invitation.ID); releaseErr != nil {
+			log.Printf("failed to release invitation %s: %v", invitation.ID, releaseErr)
+		}
+		return nil, err
+	}
+
+	return member, nil
+}
+
+func (s *InvitationService) join(ctx context.Context, invitation Invitation, request AcceptInvitationRequest) (*memberships.Member, error) {
+	user, err := s.userService.GetUserByEmail(ctx, invitation.Email)
+	if err != nil {
+		user, err = s.userService.CreateUser(ctx, users.CreateUserRequest{
+			FirstName: request.FirstName,
+			LastName:  request.LastName,
+			Email:     invitation.Email,
+			Password:  request.Password,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	membership, err := s.membershipRepository.CreateMembership(ctx, memberships.Membership{
+		OrganizationID: invitation.OrganizationID,
+		UserID:         user.ID,
+		Role:           invitation.Role,
+	})
+	if errors.Is(err, memberships.ErrAlreadyMember) {
+		membership, err = s.membershipRepository.GetMembership(ctx, invitation.OrganizationID, user.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &memberships.Member{Membership: *membership, User: user}, nil
+}
+
+// deliver emails the join link, the only place its token is ever sent. A
+// failed delivery is only logged; the inviting admin can resend the
+// invitation.
+func (s *InvitationService) deliver(ctx context.Context, invitation *Invitation, token string) {
+	link := s.baseURL + "/invitations/accept?token=" + url.QueryEscape(token)
+	if err := s.notifier.SendInvitation(ctx, invitation.OrganizationID, invitation.Email, invitation.Role, link, invitation.ExpiresAt); err != nil {
+		log.Printf("failed to email invitation %s: %v", invitation.ID, err)
+	}
+}
+
+func newToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,247 @@
+package invitations_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/services/invitations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/notifications"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var tokenPattern = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+type fixture struct {
+	service        *invitations.InvitationService
+	users          *users.MemoryUserRepository
+	memberships    *memberships.MemoryMembershipRepository
+	mailer         *notifications.MemoryMailer
+	organizationID string
+}
+
+// newFixture builds an invitation service over in-memory storage holding one
+// organization owned by "owner". Invitations expire after ttl.
+func newFixture(t *testing.T, ttl time.Duration) fixture {
+	t.Helper()
+	ctx := context.Background()
+
+	templates, err := notifications.LoadTemplates()
+	if err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+
+	organizationRepository := organizations.NewMemoryOrganizationRepository()
+	f := fixture{
+		memberships: memberships.NewMemoryMembershipRepository(),
+		mailer:      notifications.NewMemoryMailer(),
+	}
+	f.users = users.NewMemoryUserRepository(f.memberships)
+
+	organization, err := organizationRepository.CreateOrganization(ctx, organizations.Organization{Name: "Acme Corporation", OwnerUserID: "owner"})
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	f.organizationID = organization.ID
+
+	notifier := notifications.NewNotifier(f.mailer, templates, organizationRepository, f.users, "http://localhost")
+	userService := users.NewUserService(f.users, users.NewPasswordHasher(bcrypt.MinCost))
+	f.service = invitations.NewInvitationService(invitations.NewMemoryInvitationRepository(), userService, f.memberships, organizationRepository, notifier, ttl, "http://localhost")
+	return f
+}
+
+// invite invites the email as a voter and returns the invitation and the
+// token from the link emailed for it.
+func (f fixture) invite(t *testing.T, email string) (*invitations.Invitation, string) {
+	t.Helper()
+
+	invitation, err := f.service.CreateInvitation(context.Background(), f.organizationID, "owner", invitations.CreateInvitationRequest{Email: email, Role: users.RoleVoter})
+	if err != nil {
+		t.Fatalf("CreateInvitation: %v", err)
+	}
+	return invitation, f.lastToken(t)
+}
+
+// lastToken returns the token from the last emailed link.
+func (f fixture) lastToken(t *testing.T) string {
+	t.Helper()
+
+	messages := f.mailer.Messages()
+	if len(messages) == 0 {
+		t.Fatal("no invitation was emailed")
+	}
+	match := tokenPattern.FindStringSubmatch(messages[len(messages)-1].Text)
+	if match == nil {
+		t.Fatalf("the invitation email has no token: %s", messages[len(messages)-1].Text)
+	}
+	return match[1]
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	newAccount := invitations.AcceptInvitationRequest{FirstName: "Ada", LastName: "Lovelace", Password: "correct horse battery"}
+
+	for _, tc := range []struct {
+		name string
+		ttl  time.Duration
+		// existing creates an account for the invited email beforehand
+		existing bool
+		// before runs between inviting and accepting, and returns the token
+		// to accept with
+		before  func(t *testing.T, f fixture, invitation *invitations.Invitation, token string) string
+		request invitations.AcceptInvitationRequest
+		err     error
+		// members is how many members the organization has afterwards
+		members int64
+	}{
+		{name: "NewAccount", request: newAccount, members: 1},
+		{name: "ExistingAccount", existing: true, members: 1},
+		{name: "Expired", ttl: -time.Minute, request: newAccount, err: invitations.ErrInvitationInvalid},
+		{name: "EmptyToken", request: newAccount, err: invitations.ErrInvitationInvalid,
+			before: func(t *testing.T, f fixture, invitation *invitations.Invitation, token string) string {
+				return ""
+			},
+		},
+		{name: "UnknownToken", request: newAccount, err: invitations.ErrInvitationInvalid,
+			before: func(t *testing.T, f fixture, invitation *invitations.Invitation, token string) string {
+				return "not-a-token"
+			},
+		},
+		{name: "Reused", request: newAccount, err: invitations.ErrInvitationInvalid, members: 1,
+			before: func(t *testing.T, f fixture, invitation *invitations.Invitation, token string) string {
+				if _, err := f.service.AcceptInvitation(context.Background(), invitations.AcceptInvitationRequest{Token: token, FirstName: "Ada", LastName: "Lovelace", Password: "correct horse battery"}); err != nil {
+					t.Fatalf("AcceptInvitation: %v", err)
+				}
+				return token
+			},
+		},
+		{name: "Revoked", request: newAccount, err: invitations.ErrInvitationInvalid,
+			before: func(t *testing.T, f fixture, invitation *invitations.Invitation, token string) string {
+				if err := f.service.RevokeInvitation(context.Background(), invitation.ID); err != nil {
+					t.Fatalf("RevokeInvitation: %v", err)
+				}
+				return token
+			},
+		},
+		{name: "ResentOldToken", request: newAccount, err: invitations.ErrInvitationInvalid,
+			before: func(t *testing.T, f fixture, invitation *invitations.Invitation, token string) string {
+				if _, err := f.service.ResendInvitation(context.Background(), invitation.ID); err != nil {
+					t.Fatalf("ResendInvitation: %v", err)
+				}
+				return token
+			},
+		},
+		{name: "ResentNewToken", request: newAccount, members: 1,
+			before: func(t *testing.T, f fixture, invitation *invitations.Invitation, token string) string {
+				if _, err := f.service.ResendInvitation(context.Background(), invitation.ID); err != nil {
+					t.Fatalf("ResendInvitation: %v", err)
+				}
+				return f.lastToken(t)
+			},
+		},
+		{name: "RetryAfterInvalidAccount", request: newAccount, members: 1,
+			before: func(t *testing.T, f fixture, invitation *invitations.Invitation, token string) string {
+				_, err := f.service.AcceptInvitation(context.Background(), invitations.AcceptInvitationRequest{Token: token, FirstName: "Ada", LastName: "Lovelace"})
+				if !errors.Is(err, apperr.ErrValidation) {
+					t.Fatalf("AcceptInvitation without a password returned %v, want a validation error", err)
+				}
+				return token
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			ttl := tc.ttl
+			if ttl == 0 {
+				ttl = time.Hour
+			}
+			f := newFixture(t, ttl)
+
+			const email = "ada@example.com"
+			var existing *users.User
+			if tc.existing {
+				var err error
+				existing, err = f.users.CreateUser(ctx, users.User{FirstName: "Ada", LastName: "Lovelace", Email: email})
+				if err != nil {
+					t.Fatalf("CreateUser: %v", err)
+				}
+			}
+
+			invitation, token := f.invite(t, email)
+			if tc.before != nil {
+				token = tc.before(t, f, invitation, token)
+			}
+
+			request := tc.request
+			request.Token = token
+			member, err := f.service.AcceptInvitation(ctx, request)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("AcceptInvitation returned %v, want %v", err, tc.err)
+			}
+			if count, err := f.memberships.CountMembers(ctx, f.organizationID); err != nil || count != tc.members {
+				t.Errorf("CountMembers returned %d and error %v, want %d", count, err, tc.members)
+			}
+			if tc.err != nil {
+				return
+			}
+
+			if member.Role != users.RoleVoter || member.User == nil || member.User.Email != email {
+				t.Errorf("AcceptInvitation returned %+v, want a voter membership for %s", member, email)
+			}
+			if existing != nil && member.User.ID != existing.ID {
+				t.Errorf("AcceptInvitation joined user %s, want the existing account %s", member.User.ID, existing.ID)
+			}
+			if _, err := f.memberships.GetMembership(ctx, f.organizationID, member.User.ID); err != nil {
+				t.Errorf("GetMembership after accepting returned %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateInvitation(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		email string
+		role  users.UserRole
+		err   error
+	}{
+		{name: "Valid", email: "grace@example.com", role: users.RoleOfficer},
+		{name: "AlreadyInvited", email: "ada@example.com", role: users.RoleVoter, err: invitations.ErrAlreadyInvited},
+		{name: "AlreadyMember", email: "member@example.com", role: users.RoleVoter, err: memberships.ErrAlreadyMember},
+		{name: "InvalidEmail", email: "not an email", role: users.RoleVoter, err: apperr.ErrValidation},
+		{name: "OwnerRole", email: "grace@example.com", role: users.RoleOwner, err: apperr.ErrValidation},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newFixture(t, time.Hour)
+			f.invite(t, "ada@example.com")
+
+			member, err := f.users.CreateUser(ctx, users.User{FirstName: "Mia", LastName: "Member", Email: "member@example.com"})
+			if err != nil {
+				t.Fatalf("CreateUser: %v", err)
+			}
+			if _, err := f.memberships.CreateMembership(ctx, memberships.Membership{OrganizationID: f.organizationID, UserID: member.ID, Role: users.RoleVoter}); err != nil {
+				t.Fatalf("CreateMembership: %v", err)
+			}
+
+			invitation, err := f.service.CreateInvitation(ctx, f.organizationID, "owner", invitations.CreateInvitationRequest{Email: tc.email, Role: tc.role})
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("CreateInvitation returned %v, want %v", err, tc.err)
+			}
+			if tc.err != nil {
+				return
+			}
+			if invitation.Email != tc.email || invitation.Role != tc.role || invitation.InvitedBy != "owner" {
+				t.Errorf("CreateInvitation returned %+v", invitation)
+			}
+			if invitation.TokenHash == "" || invitation.TokenHash == f.lastToken(t) {
+				t.Error("CreateInvitation should store a hash of the emailed token, not the token")
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package invitations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SQLInvitationRepository stores invitations in the invitations table of a
+// SQL database. Expired invitations are dropped as new ones are created, as
+// the TTL index does in MongoDB.
+type SQLInvitationRepository struct {
+	db *sqldb.DB
+}
+
+func NewSQLInvitationRepository(db *sqldb.DB) *SQLInvitationRepository {
+	return &SQLInvitationRepository{
+		db: db,
+	}
+}
+
+const invitationColumns = "id, organization_id, email, role, token_hash, invited_by, expires_at, accepted_at, created_at, updated_at"
+
+func (r *SQLInvitationRepository) CreateInvitation(ctx context.Context, invitation Invitation) (*Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	invitation.CreatedAt = now
+	invitation.UpdatedAt = now
+
+	if invitation.ID == "" {
+		invitation.ID = primitive.NewObjectID().Hex()
+	}
+
+	q := r.db.Query()
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM invitations WHERE expires_at < "+q.Arg(now), q.Args...); err != nil {
+		return nil, fmt.Errorf("failed to delete expired invitations: %w", err)
+	}
+
+	q = r.db.Query()
+	values := q.Values(invitation.ID, invitation.OrganizationID, invitation.Email, invitation.Role, invitation.TokenHash,
+		invitation.InvitedBy, invitation.ExpiresAt, invitation.AcceptedAt, invitation.CreatedAt, invitation.UpdatedAt)
+	_, err := r.db.ExecContext(ctx, "INSERT INTO invitations ("+invitationColumns+") VALUES "+values, q.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	return &invitation, nil
+}
+
+func (r *SQLInvitationRepository) GetInvitationByID(ctx context.Context, id string) (*Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	invitation, err := scanInvitation(r.db.QueryRowContext(ctx, "SELECT "+invitationColumns+" FROM invitations WHERE id = "+q.Arg(id), q.Args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperr.NotFound("invitation not found")
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+func (r *SQLInvitationRepository) GetPendingInvitation(ctx context.Context, organizationID, email string, now time.Time) (*Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	conditions := append(pendingConditions(q, now), "organization_id = "+q.Arg(organizationID), "email = "+q.Arg(email))
+	invitation, err := scanInvitation(r.db.QueryRowContext(ctx, "SELECT "+invitationColumns+" FROM invitations"+sqldb.Where(conditions), q.Args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperr.NotFound("invitation not found")
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+func (r *SQLInvitationRepository) ListPendingInvitations(ctx context.Context, organizationID string, now time.Time) ([]Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	conditions := append(pendingConditions(q, now), "organization_id = "+q.Arg(organizationID))
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+invitationColumns+" FROM invitations"+sqldb.Where(conditions)+" ORDER BY created_at DESC", q.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []Invitation
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode invitations: %w", err)
+		}
+		invitations = append(invitations, *invitation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+
+	return invitations, nil
+}
+
+func (r *SQLInvitationRepository) RenewInvitation(ctx context.Context, id, tokenHash string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	result, err := r.db.ExecContext(ctx, "UPDATE invitations SET token_hash = "+q.Arg(tokenHash)+
+		", expires_at = "+q.Arg(expiresAt)+
+		", updated_at = "+q.Arg(time.Now())+
+		" WHERE id = "+q.Arg(id)+" AND accepted_at IS NULL", q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to renew invitation: %w", err)
+	}
+
+	return invitationUpdated(result)
+}
+
+func (r *SQLInvitationRepository) ConsumeInvitation(ctx context.Context, tokenHash string, now time.Time) (*Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	set := "accepted_at = " + q.Arg(now) + ", updated_at = " + q.Arg(now)
+	conditions := append(pendingConditions(q, now), "token_hash = "+q.Arg(tokenHash))
+	query := "UPDATE invitations SET " + set + sqldb.Where(conditions) + " RETURNING " + invitationColumns
+
+	invitation, err := scanInvitation(r.db.QueryRowContext(ctx, query, q.Args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvitationInvalid
+		}
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+func (r *SQLInvitationRepository) ReleaseInvitation(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	_, err := r.db.ExecContext(ctx, "UPDATE invitations SET accepted_at = NULL, updated_at = "+q.Arg(time.Now())+" WHERE id = "+q.Arg(id), q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to release invitation: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLInvitationRepository) DeleteInvitation(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	result, err := r.db.ExecContext(ctx, "DELETE FROM invitations WHERE id = "+q.Arg(id), q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete invitation: %w", err)
+	}
+
+	return invitationUpdated(result)
+}
+
+func (r *SQLInvitationRepository) DeleteOrganizationInvitations(ctx context.Context, organizationID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	result, err := r.db.ExecContext(ctx, "DELETE FROM invitations WHERE organization_id = "+q.Arg(organizationID), q.Args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete invitations: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read rows affected: %w", err)
+	}
+	return deleted, nil
+}
+
+func scanInvitation(row sqldb.Scanner) (*Invitation, error) {
+	var invitation Invitation
+	err := row.Scan(&invitation.ID, &invitation.OrganizationID, &invitation.Email, &invitation.Role, &invitation.TokenHash,
+		&invitation.InvitedBy, &invitation.ExpiresAt, &invitation.AcceptedAt, &invitation.CreatedAt, &invitation.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func invitationUpdated(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read rows affected: %w", err)
+	}
+	if affected == 0 {
+		return apperr.NotFound("invitation not found")
+	}
+	return nil
+}
+
+// pendingConditions mirrors pendingFilter.
+func pendingConditions(q *sqldb.Query, now time.Time) []string {
+	return []string{"accepted_at IS NULL", "expires_at > " + q.Arg(now)}
+}
@@ -0,0 +1,63 @@
+package invitations
+
+import (
+	"context"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+)
+
+var (
+	ErrInvitationInvalid = apperr.NotFound("invitation is invalid or has expired")
+	ErrAlreadyInvited    = apperr.Conflict("this email already has a pending invitation; resend it instead")
+)
+
+// Invitation offers an email address membership of an organization. Only a
+// hash of its token is stored; the token itself is only ever sent to the
+// invited email, in the join link.
+type Invitation struct {
+	ID             string         `json:"id" bson:"_id,omitempty"`
+	OrganizationID string         `json:"organization_id" bson:"organization_id"`
+	Email          string         `json:"email" bson:"email"`
+	Role           users.UserRole `json:"role" bson:"role"`
+	TokenHash      string         `json:"-" bson:"token_hash"`
+	InvitedBy      string         `json:"invited_by" bson:"invited_by"`
+	ExpiresAt      time.Time      `json:"expires_at" bson:"expires_at"`
+	AcceptedAt     *time.Time     `json:"accepted_at,omitempty" bson:"accepted_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" bson:"updated_at"`
+}
+
+type CreateInvitationRequest struct {
+	Email string         `json:"email"`
+	Role  users.UserRole `json:"role"`
+}
+
+// AcceptInvitationRequest carries the token and, when the invited email has no
+// account yet, the details needed to create one.
+type AcceptInvitationRequest struct {
+	Token     string `json:"token"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Password  string `json:"password"`
+}
+
+type InvitationRepository interface {
+	CreateInvitation(ctx context.Context, invitation Invitation) (*Invitation, error)
+	GetInvitationByID(ctx context.Context, id string) (*Invitation, error)
+	GetPendingInvitation(ctx context.Context, organizationID, email string, now time.Time) (*Invitation, error)
+	ListPendingInvitations(ctx context.Context, organizationID string, now time.Time) ([]Invitation, error)
+	// RenewInvitation replaces a pending invitation's token and expiry.
+	RenewInvitation(ctx context.Context, id, tokenHash string, expiresAt time.Time) error
+	// ConsumeInvitation atomically marks the pending, unexpired invitation with
+	// the token hash as accepted, so each token can be used only once.
+	ConsumeInvitation(ctx context.Context, tokenHash string, now time.Time) (*Invitation, error)
+	// ReleaseInvitation reverts ConsumeInvitation when accepting fails.
+	ReleaseInvitation(ctx context.Context, id string) error
+	DeleteInvitation(ctx context.Context, id string) error
+	// DeleteOrganizationInvitations deletes every invitation to the
+	// organization, whether pending, accepted or expired, and returns how
+	// many it deleted.
+	DeleteOrganizationInvitations(ctx context.Context, organizationID string) (int64, error)
+}
@@ -0,0 +1,150 @@
+package memberships
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryMembershipRepository keeps memberships in memory, for tests and for
+// running the server without a database. It is safe for concurrent use.
+type MemoryMembershipRepository struct {
+	mu          sync.RWMutex
+	memberships []Membership
+}
+
+func NewMemoryMembershipRepository() *MemoryMembershipRepository {
+	return &MemoryMembershipRepository{}
+}
+
+func (r *MemoryMembershipRepository) CreateMembership(ctx context.Context, membership Membership) (*Membership, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.find(membership.OrganizationID, membership.UserID) >= 0 {
+		return nil, ErrAlreadyMember
+	}
+
+	now := time.Now()
+	membership.CreatedAt = now
+	membership.UpdatedAt = now
+
+	if membership.ID == "" {
+		membership.ID = primitive.NewObjectID().Hex()
+	}
+
+	r.memberships = append(r.memberships, membership)
+	return &membership, nil
+}
+
+func (r *MemoryMembershipRepository) GetMembership(ctx context.Context, organizationID, userID string) (*Membership, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.find(organizationID, userID)
+	if i < 0 {
+		return nil, ErrNotMember
+	}
+
+	membership := r.memberships[i]
+	return &membership, nil
+}
+
+func (r *MemoryMembershipRepository) UpdateRole(ctx context.Context, organizationID, userID string, role users.UserRole) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.find(organizationID, userID)
+	if i < 0 {
+		return ErrNotMember
+	}
+
+	r.memberships[i].Role = role
+	r.memberships[i].UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *MemoryMembershipRepository) DeleteMembership(ctx context.Context, organizationID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.find(organizationID, userID)
+	if i < 0 {
+		return ErrNotMember
+	}
+
+	r.memberships = append(r.memberships[:i], r.memberships[i+1:]...)
+	return nil
+}
+
+func (r *MemoryMembershipRepository) ListMembers(ctx context.Context, organizationID string, page pagination.Page) ([]Membership, error) {
+	memberships := r.filter(func(membership Membership) bool {
+		return membership.OrganizationID == organizationID
+	})
+
+	return pagination.Slice(memberships, page, func(membership Membership) (any, string) {
+		return membership.CreatedAt, membership.ID
+	}), nil
+}
+
+func (r *MemoryMembershipRepository) CountMembers(ctx context.Context, organizationID string) (int64, error) {
+	memberships := r.filter(func(membership Membership) bool {
+		return membership.OrganizationID == organizationID
+	})
+
+	return int64(len(memberships)), nil
+}
+
+func (r *MemoryMembershipRepository) ListMemberUserIDs(ctx context.Context, organizationID string, role users.UserRole) ([]string, error) {
+	memberships := r.filter(func(membership Membership) bool {
+		return membership.OrganizationID == organizationID && (role == "" || membership.Role == role)
+	})
+
+	userIDs := make([]string, len(memberships))
+	for i, membership := range memberships {
+		userIDs[i] = membership.UserID
+	}
+
+	return userIDs, nil
+}
+
+func (r *MemoryMembershipRepository) ListMembershipsByUser(ctx context.Context, userID string) ([]Membership, error) {
+	memberships := r.filter(func(membership Membership) bool {
+		return membership.UserID == userID
+	})
+
+	// Oldest first
+	sort.SliceStable(memberships, func(i, j int) bool {
+		return memberships[i].CreatedAt.Before(memberships[j].CreatedAt)
+	})
+	return memberships, nil
+}
+
+// find returns the index of the membership linking the user to the
+// organization, or -1.
+func (r *MemoryMembershipRepository) find(organizationID, userID string) int {
+	for i, membership := range r.memberships {
+		if membership.OrganizationID == organizationID && membership.UserID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *MemoryMembershipRepository) filter(keep func(Membership) bool) []Membership {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var memberships []Membership
+	for _, membership := range r.memberships {
+		if keep(membership) {
+			memberships = append(memberships, membership)
+		}
+	}
+	return memberships
+}
@@ -0,0 +1,188 @@
+package memberships
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoDBMembershipRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoDBMembershipRepository(collection *mongo.Collection) *MongoDBMembershipRepository {
+	return &MongoDBMembershipRepository{
+		collection: collection,
+	}
+}
+
+func (r *MongoDBMembershipRepository) CreateMembership(ctx context.Context, membership Membership) (*Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	membership.CreatedAt = now
+	membership.UpdatedAt = now
+
+	if membership.ID == "" {
+		membership.ID = primitive.NewObjectID().Hex()
+	}
+
+	_, err := r.collection.InsertOne(ctx, membership)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrAlreadyMember
+		}
+		return nil, fmt.Errorf("failed to create membership: %w", err)
+	}
+
+	return &membership, nil
+}
+
+func (r *MongoDBMembershipRepository) GetMembership(ctx context.Context, organizationID, userID string) (*Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var membership Membership
+	filter := bson.M{"organization_id": organizationID, "user_id": userID}
+
+	err := r.collection.FindOne(ctx, filter).Decode(&membership)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotMember
+		}
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+
+	return &membership, nil
+}
+
+func (r *MongoDBMembershipRepository) UpdateRole(ctx context.Context, organizationID, userID string, role users.UserRole) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"organization_id": organizationID, "user_id": userID}
+	update := bson.M{"$set": bson.M{
+		"role":       role,
+		"updated_at": time.Now(),
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update membership: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotMember
+	}
+
+	return nil
+}
+
+func (r *MongoDBMembershipRepository) DeleteMembership(ctx context.Context, organizationID, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"organization_id": organizationID, "user_id": userID}
+
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete membership: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrNotMember
+	}
+
+	return nil
+}
+
+func (r *MongoDBMembershipRepository) ListMembers(ctx context.Context, organizationID string, page pagination.Page) ([]Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := pagination.MongoFilter(bson.M{"organization_id": organizationID}, page, "created_at")
+	opts := pagination.MongoFindOptions(page, "created_at")
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var memberships []Membership
+	if err = cursor.All(ctx, &memberships); err != nil {
+		return nil, fmt.Errorf("failed to decode members: %w", err)
+	}
+
+	return memberships, nil
+}
+
+func (r *MongoDBMembershipRepository) CountMembers(ctx context.Context, organizationID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"organization_id": organizationID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count members: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *MongoDBMembershipRepository) ListMemberUserIDs(ctx context.Context, organizationID string, role users.UserRole) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"organization_id": organizationID}
+	if role != "" {
+		filter["role"] = role
+	}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 0, "user_id": 1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list member IDs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var memberships []Membership
+	if err = cursor.All(ctx, &memberships); err != nil {
+		return nil, fmt.Errorf("failed to decode member IDs: %w", err)
+	}
+
+	userIDs := make([]string, len(memberships))
+	for i, membership := range memberships {
+		userIDs[i] = membership.UserID
+	}
+
+	return userIDs, nil
+}
+
+func (r *MongoDBMembershipRepository) ListMembershipsByUser(ctx context.Context, userID string) ([]Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memberships: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var memberships []Membership
+	if err = cursor.All(ctx, &memberships); err != nil {
+		return nil, fmt.Errorf("failed to decode memberships: %w", err)
+	}
+
+	return memberships, nil
+}
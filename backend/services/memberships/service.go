@@ -0,0 +1,218 @@
+package memberships
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/bpalazzi512/easy-ballot/backend/validation"
+)
+
+type MembershipService struct {
+	repository             MembershipRepository
+	userRepository         users.UserRepository
+	organizationRepository organizations.OrganizationRepository
+}
+
+func NewMembershipService(repository MembershipRepository, userRepository users.UserRepository, organizationRepository organizations.OrganizationRepository) *MembershipService {
+	return &MembershipService{
+		repository:             repository,
+		userRepository:         userRepository,
+		organizationRepository: organizationRepository,
+	}
+}
+
+func (s *MembershipService) AddMember(ctx context.Context, organizationID string, request AddMemberRequest) (*Member, error) {
+	if strings.TrimSpace(organizationID) == "" {
+		return nil, apperr.Validation("organization_id", "is required")
+	}
+
+	var v validation.Validator
+	v.Check(strings.TrimSpace(request.UserID) != "" || strings.TrimSpace(request.Email) != "", "user_id", "is required unless email is given")
+	v.Email("email", request.Email)
+	ValidateMemberRole(&v, request.Role)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.organizationRepository.GetOrganizationByID(ctx, organizationID); err != nil {
+		return nil, err
+	}
+
+	var user *users.User
+	var err error
+	switch {
+	case strings.TrimSpace(request.UserID) != "":
+		user, err = s.userRepository.GetUserByID(ctx, request.UserID)
+	default:
+		user, err = s.userRepository.GetUserByEmail(ctx, request.Email)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	membership, err := s.repository.CreateMembership(ctx, Membership{
+		OrganizationID: organizationID,
+		UserID:         user.ID,
+		Role:           request.Role,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Member{Membership: *membership, User: user}, nil
+}
+
+// AddOwner records the owner of a newly created organization as its first
+// member.
+func (s *MembershipService) AddOwner(ctx context.Context, organization organizations.Organization) error {
+	_, err := s.repository.CreateMembership(ctx, Membership{
+		OrganizationID: organization.ID,
+		UserID:         organization.OwnerUserID,
+		Role:           users.RoleOwner,
+	})
+	return err
+}
+
+func (s *MembershipService) UpdateMemberRole(ctx context.Context, organizationID, userID string, role users.UserRole) error {
+	var v validation.Validator
+	ValidateMemberRole(&v, role)
+	if err := v.Err(); err != nil {
+		return err
+	}
+	if err := s.ensureNotOwner(ctx, organizationID, userID); err != nil {
+		return err
+	}
+
+	return s.repository.UpdateRole(ctx, organizationID, userID, role)
+}
+
+// TransferOwnership gives an existing member the owner role and makes the
+// previous owner an admin. The organization's owner_user_id has to be
+// changed to match.
+func (s *MembershipService) TransferOwnership(ctx context.Context, organization organizations.Organization, userID string) error {
+	if _, err := s.repository.GetMembership(ctx, organization.ID, userID); err != nil {
+		if errors.Is(err, ErrNotMember) {
+			return apperr.Validation("owner_user_id", "must be a member of the organization")
+		}
+		return err
+	}
+
+	if err := s.repository.UpdateRole(ctx, organization.ID, userID, users.RoleOwner); err != nil {
+		return err
+	}
+
+	return s.repository.UpdateRole(ctx, organization.ID, organization.OwnerUserID, users.RoleAdmin)
+}
+
+func (s *MembershipService) RemoveMember(ctx context.Context, organizationID, userID string) error {
+	if err := s.ensureNotOwner(ctx, organizationID, userID); err != nil {
+		return err
+	}
+
+	return s.repository.DeleteMembership(ctx, organizationID, userID)
+}
+
+// RoleIn returns the user's role in the organization, or an empty role if they
+// are not a member.
+func (s *MembershipService) RoleIn(ctx context.Context, organizationID, userID string) (users.UserRole, error) {
+	membership, err := s.repository.GetMembership(ctx, organizationID, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotMember) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return membership.Role, nil
+}
+
+func (s *MembershipService) ListMembers(ctx context.Context, organizationID string, page pagination.Page) (*pagination.List[Member], error) {
+	// Members are listed oldest first
+	page = page.Normalize(pagination.Sort{Field: "created_at"})
+
+	memberships, err := s.repository.ListMembers(ctx, organizationID, page.Lookahead())
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.CountMembers(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cursor comes from the memberships, so the page continues correctly
+	// even when users are skipped below
+	memberships, nextCursor := pagination.NextPage(memberships, page, func(membership Membership) (any, string) {
+		return membership.CreatedAt, membership.ID
+	})
+
+	userIDs := make([]string, len(memberships))
+	for i, membership := range memberships {
+		userIDs[i] = membership.UserID
+	}
+
+	memberUsers, err := s.userRepository.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	usersByID := make(map[string]*users.User, len(memberUsers))
+	for i := range memberUsers {
+		usersByID[memberUsers[i].ID] = &memberUsers[i]
+	}
+
+	// Memberships whose user has since been deleted are skipped
+	members := make([]Member, 0, len(memberships))
+	for _, membership := range memberships {
+		if user, ok := usersByID[membership.UserID]; ok {
+			members = append(members, Member{Membership: membership, User: user})
+		}
+	}
+
+	return &pagination.List[Member]{
+		Items:      members,
+		NextCursor: nextCursor,
+		Total:      total,
+	}, nil
+}
+
+// CountMembers counts the members whose user still exists, so it agrees with
+// the members ListMembers returns.
+func (s *MembershipService) CountMembers(ctx context.Context, organizationID string) (int64, error) {
+	return s.userRepository.CountUsers(ctx, users.UserFilter{OrganizationID: organizationID})
+}
+
+func (s *MembershipService) ListMemberships(ctx context.Context, userID string) ([]Membership, error) {
+	if strings.TrimSpace(userID) == "" {
+		return nil, apperr.Validation("user_id", "is required")
+	}
+
+	return s.repository.ListMembershipsByUser(ctx, userID)
+}
+
+// ensureNotOwner protects the owner's membership, which only changes when the
+// organization itself does.
+func (s *MembershipService) ensureNotOwner(ctx context.Context, organizationID, userID string) error {
+	organization, err := s.organizationRepository.GetOrganizationByID(ctx, organizationID)
+	if err != nil {
+		return err
+	}
+	if organization.OwnerUserID == userID {
+		return apperr.Conflict("the organization owner's membership cannot be changed")
+	}
+
+	return nil
+}
+
+// ValidateMemberRole checks that the role may be granted through a
+// membership. Every role but owner can be.
+func ValidateMemberRole(v *validation.Validator, role users.UserRole) {
+	if v.Required("role", string(role)) {
+		validation.OneOf(v, "role", role, users.RoleAdmin, users.RoleOfficer, users.RoleVoter, users.RoleObserver)
+	}
+}
@@ -0,0 +1,231 @@
+package memberships_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+)
+
+type fixture struct {
+	service        *memberships.MembershipService
+	users          *users.MemoryUserRepository
+	organizationID string
+	// userIDs holds the members in the order they joined; the first owns the
+	// organization.
+	userIDs []string
+}
+
+// newFixture builds a membership service over in-memory storage holding one
+// organization with the given number of members.
+func newFixture(t *testing.T, members int) fixture {
+	t.Helper()
+	ctx := context.Background()
+
+	membershipRepository := memberships.NewMemoryMembershipRepository()
+	organizationRepository := organizations.NewMemoryOrganizationRepository()
+	f := fixture{users: users.NewMemoryUserRepository(membershipRepository)}
+	f.service = memberships.NewMembershipService(membershipRepository, f.users, organizationRepository)
+
+	for i := 0; i < members; i++ {
+		user, err := f.users.CreateUser(ctx, users.User{FirstName: "Member", LastName: string(rune('A' + i)), Email: string(rune('a'+i)) + "@example.com"})
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		f.userIDs = append(f.userIDs, user.ID)
+	}
+
+	organization, err := organizationRepository.CreateOrganization(ctx, organizations.Organization{Name: "Acme Corporation", OwnerUserID: f.userIDs[0]})
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	f.organizationID = organization.ID
+
+	for i, userID := range f.userIDs {
+		role := users.RoleVoter
+		if i == 0 {
+			role = users.RoleOwner
+		}
+		if _, err := membershipRepository.CreateMembership(ctx, memberships.Membership{OrganizationID: organization.ID, UserID: userID, Role: role}); err != nil {
+			t.Fatalf("CreateMembership: %v", err)
+		}
+	}
+
+	return f
+}
+
+func TestListMembers(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		members int
+		// deleted lists the indexes of members whose user is deleted
+		deleted []int
+		limit   int
+		items   int
+		total   int64
+	}{
+		{name: "AllMembers", members: 3, items: 3, total: 3},
+		{name: "Limit", members: 3, limit: 2, items: 2, total: 3},
+		{name: "DeletedUser", members: 3, deleted: []int{1}, items: 2, total: 2},
+		{name: "AllButOwnerDeleted", members: 3, deleted: []int{1, 2}, items: 1, total: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newFixture(t, tc.members)
+			for _, i := range tc.deleted {
+				if err := f.users.DeleteUser(ctx, f.userIDs[i]); err != nil {
+					t.Fatalf("DeleteUser: %v", err)
+				}
+			}
+
+			list, err := f.service.ListMembers(ctx, f.organizationID, pagination.Page{Limit: tc.limit})
+			if err != nil {
+				t.Fatalf("ListMembers: %v", err)
+			}
+			if len(list.Items) != tc.items {
+				t.Errorf("ListMembers returned %d members, want %d", len(list.Items), tc.items)
+			}
+			if list.Total != tc.total {
+				t.Errorf("ListMembers returned a total of %d, want %d", list.Total, tc.total)
+			}
+
+			count, err := f.service.CountMembers(ctx, f.organizationID)
+			if err != nil || count != tc.total {
+				t.Errorf("CountMembers returned %d and error %v, want %d", count, err, tc.total)
+			}
+		})
+	}
+}
+
+func TestAddMember(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// organizationID overrides the fixture's organization
+		organizationID string
+		// request builds the request from the fixture's members and a user
+		// who is not one
+		request func(f fixture, outsider *users.User) memberships.AddMemberRequest
+		err     error
+	}{
+		{
+			name: "ByUserID",
+			request: func(f fixture, outsider *users.User) memberships.AddMemberRequest {
+				return memberships.AddMemberRequest{UserID: outsider.ID, Role: users.RoleOfficer}
+			},
+		},
+		{
+			name: "ByEmail",
+			request: func(f fixture, outsider *users.User) memberships.AddMemberRequest {
+				return memberships.AddMemberRequest{Email: outsider.Email, Role: users.RoleOfficer}
+			},
+		},
+		{
+			name: "AlreadyMember", err: memberships.ErrAlreadyMember,
+			request: func(f fixture, outsider *users.User) memberships.AddMemberRequest {
+				return memberships.AddMemberRequest{UserID: f.userIDs[1], Role: users.RoleOfficer}
+			},
+		},
+		{
+			name: "UnknownUser", err: apperr.ErrNotFound,
+			request: func(f fixture, outsider *users.User) memberships.AddMemberRequest {
+				return memberships.AddMemberRequest{UserID: "missing", Role: users.RoleOfficer}
+			},
+		},
+		{
+			name: "UnknownOrganization", organizationID: "missing", err: apperr.ErrNotFound,
+			request: func(f fixture, outsider *users.User) memberships.AddMemberRequest {
+				return memberships.AddMemberRequest{UserID: outsider.ID, Role: users.RoleOfficer}
+			},
+		},
+		{
+			name: "OwnerRole", err: apperr.ErrValidation,
+			request: func(f fixture, outsider *users.User) memberships.AddMemberRequest {
+				return memberships.AddMemberRequest{UserID: outsider.ID, Role: users.RoleOwner}
+			},
+		},
+		{
+			name: "NoUser", err: apperr.ErrValidation,
+			request: func(f fixture, outsider *users.User) memberships.AddMemberRequest {
+				return memberships.AddMemberRequest{Role: users.RoleOfficer}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newFixture(t, 2)
+			outsider, err := f.users.CreateUser(ctx, users.User{FirstName: "Grace", LastName: "Hopper", Email: "grace@example.com"})
+			if err != nil {
+				t.Fatalf("CreateUser: %v", err)
+			}
+
+			organizationID := f.organizationID
+			if tc.organizationID != "" {
+				organizationID = tc.organizationID
+			}
+			member, err := f.service.AddMember(ctx, organizationID, tc.request(f, outsider))
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("AddMember returned %v, want %v", err, tc.err)
+			}
+			if tc.err != nil {
+				return
+			}
+
+			if member.UserID != outsider.ID || member.Role != users.RoleOfficer || member.User == nil {
+				t.Errorf("AddMember returned %+v, want an officer membership for %s", member, outsider.ID)
+			}
+			if role, err := f.service.RoleIn(ctx, f.organizationID, outsider.ID); err != nil || role != users.RoleOfficer {
+				t.Errorf("RoleIn returned %q and error %v, want %q", role, err, users.RoleOfficer)
+			}
+		})
+	}
+}
+
+func TestChangeMember(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// member is the index of the member changed, or -1 for a user who is
+		// not one
+		member int
+		// remove removes the member instead of making them an admin
+		remove bool
+		err    error
+		// role is the user's role afterwards
+		role users.UserRole
+	}{
+		{name: "UpdateRole", member: 1, role: users.RoleAdmin},
+		{name: "UpdateOwner", member: 0, err: apperr.ErrConflict, role: users.RoleOwner},
+		{name: "UpdateNonMember", member: -1, err: memberships.ErrNotMember},
+		{name: "Remove", member: 1, remove: true},
+		{name: "RemoveOwner", member: 0, remove: true, err: apperr.ErrConflict, role: users.RoleOwner},
+		{name: "RemoveNonMember", member: -1, remove: true, err: memberships.ErrNotMember},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newFixture(t, 2)
+
+			userID := "stranger"
+			if tc.member >= 0 {
+				userID = f.userIDs[tc.member]
+			}
+
+			var err error
+			if tc.remove {
+				err = f.service.RemoveMember(ctx, f.organizationID, userID)
+			} else {
+				err = f.service.UpdateMemberRole(ctx, f.organizationID, userID, users.RoleAdmin)
+			}
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("changing the member returned %v, want %v", err, tc.err)
+			}
+
+			if role, err := f.service.RoleIn(ctx, f.organizationID, userID); err != nil || role != tc.role {
+				t.Errorf("RoleIn returned %q and error %v, want %q", role, err, tc.role)
+			}
+		})
+	}
+}
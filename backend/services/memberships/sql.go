@@ -0,0 +1,217 @@
+package memberships
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SQLMembershipRepository stores memberships in the memberships table of a
+// SQL database.
+type SQLMembershipRepository struct {
+	db *sqldb.DB
+}
+
+func NewSQLMembershipRepository(db *sqldb.DB) *SQLMembershipRepository {
+	return &SQLMembershipRepository{
+		db: db,
+	}
+}
+
+const membershipColumns = "id, organization_id, user_id, role, created_at, updated_at"
+
+func (r *SQLMembershipRepository) CreateMembership(ctx context.Context, membership Membership) (*Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	membership.CreatedAt = now
+	membership.UpdatedAt = now
+
+	if membership.ID == "" {
+		membership.ID = primitive.NewObjectID().Hex()
+	}
+
+	q := r.db.Query()
+	values := q.Values(membership.ID, membership.OrganizationID, membership.UserID, membership.Role,
+		membership.CreatedAt, membership.UpdatedAt)
+	_, err := r.db.ExecContext(ctx, "INSERT INTO memberships ("+membershipColumns+") VALUES "+values, q.Args...)
+	if err != nil {
+		if r.db.IsUniqueViolation(err) {
+			return nil, ErrAlreadyMember
+		}
+		return nil, fmt.Errorf("failed to create membership: %w", err)
+	}
+
+	return &membership, nil
+}
+
+func (r *SQLMembershipRepository) GetMembership(ctx context.Context, organizationID, userID string) (*Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	query := "SELECT " + membershipColumns + " FROM memberships WHERE organization_id = " + q.Arg(organizationID) + " AND user_id = " + q.Arg(userID)
+	membership, err := scanMembership(r.db.QueryRowContext(ctx, query, q.Args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotMember
+		}
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+
+	return membership, nil
+}
+
+func (r *SQLMembershipRepository) UpdateRole(ctx context.Context, organizationID, userID string, role users.UserRole) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	result, err := r.db.ExecContext(ctx, "UPDATE memberships SET role = "+q.Arg(role)+", updated_at = "+q.Arg(time.Now())+
+		" WHERE organization_id = "+q.Arg(organizationID)+" AND user_id = "+q.Arg(userID), q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to update membership: %w", err)
+	}
+
+	return membershipUpdated(result)
+}
+
+func (r *SQLMembershipRepository) DeleteMembership(ctx context.Context, organizationID, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	result, err := r.db.ExecContext(ctx, "DELETE FROM memberships WHERE organization_id = "+q.Arg(organizationID)+" AND user_id = "+q.Arg(userID), q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete membership: %w", err)
+	}
+
+	return membershipUpdated(result)
+}
+
+func (r *SQLMembershipRepository) ListMembers(ctx context.Context, organizationID string, page pagination.Page) ([]Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	conditions := []string{"organization_id = " + q.Arg(organizationID)}
+	if after := pagination.SQLAfter(q, page, "created_at"); after != "" {
+		conditions = append(conditions, after)
+	}
+
+	query := "SELECT " + membershipColumns + " FROM memberships" + sqldb.Where(conditions) + pagination.SQLOrderBy(q, page, "created_at")
+	memberships, err := r.queryMemberships(ctx, query, q.Args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+
+	return memberships, nil
+}
+
+func (r *SQLMembershipRepository) CountMembers(ctx context.Context, organizationID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	var count int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM memberships WHERE organization_id = "+q.Arg(organizationID), q.Args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count members: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *SQLMembershipRepository) ListMemberUserIDs(ctx context.Context, organizationID string, role users.UserRole) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	conditions := []string{"organization_id = " + q.Arg(organizationID)}
+	if role != "" {
+		conditions = append(conditions, "role = "+q.Arg(role))
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT user_id FROM memberships"+sqldb.Where(conditions)+" ORDER BY created_at", q.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list member IDs: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to decode member IDs: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list member IDs: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+func (r *SQLMembershipRepository) ListMembershipsByUser(ctx context.Context, userID string) ([]Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	query := "SELECT " + membershipColumns + " FROM memberships WHERE user_id = " + q.Arg(userID) + " ORDER BY created_at"
+	memberships, err := r.queryMemberships(ctx, query, q.Args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memberships: %w", err)
+	}
+
+	return memberships, nil
+}
+
+func (r *SQLMembershipRepository) queryMemberships(ctx context.Context, query string, args []any) ([]Membership, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memberships []Membership
+	for rows.Next() {
+		membership, err := scanMembership(rows)
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, *membership)
+	}
+
+	return memberships, rows.Err()
+}
+
+func scanMembership(row sqldb.Scanner) (*Membership, error) {
+	var membership Membership
+	err := row.Scan(&membership.ID, &membership.OrganizationID, &membership.UserID, &membership.Role,
+		&membership.CreatedAt, &membership.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// membershipUpdated reports ErrNotMember if the statement matched no rows.
+func membershipUpdated(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read rows affected: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotMember
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package memberships
+
+import (
+	"context"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+)
+
+var (
+	ErrAlreadyMember = apperr.Conflict("user is already a member of this organization")
+	ErrNotMember     = apperr.NotFound("user is not a member of this organization")
+)
+
+// Membership links a user to an organization with the role they hold there.
+// A user may belong to any number of organizations.
+type Membership struct {
+	ID             string         `json:"id" bson:"_id,omitempty"`
+	OrganizationID string         `json:"organization_id" bson:"organization_id"`
+	UserID         string         `json:"user_id" bson:"user_id"`
+	Role           users.UserRole `json:"role" bson:"role"`
+	CreatedAt      time.Time      `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" bson:"updated_at"`
+}
+
+// Member is a membership together with the user it belongs to.
+type Member struct {
+	Membership
+	User *users.User `json:"user"`
+}
+
+// AddMemberRequest identifies the user to add by ID or by email.
+type AddMemberRequest struct {
+	UserID string         `json:"user_id"`
+	Email  string         `json:"email"`
+	Role   users.UserRole `json:"role"`
+}
+
+type UpdateMemberRequest struct {
+	Role users.UserRole `json:"role"`
+}
+
+type MembershipRepository interface {
+	CreateMembership(ctx context.Context, membership Membership) (*Membership, error)
+	GetMembership(ctx context.Context, organizationID, userID string) (*Membership, error)
+	UpdateRole(ctx context.Context, organizationID, userID string, role users.UserRole) error
+	DeleteMembership(ctx context.Context, organizationID, userID string) error
+	ListMembers(ctx context.Context, organizationID string, page pagination.Page) ([]Membership, error)
+	CountMembers(ctx context.Context, organizationID string) (int64, error)
+	// ListMemberUserIDs returns the IDs of an organization's members, limited
+	// to one role unless role is empty.
+	ListMemberUserIDs(ctx context.Context, organizationID string, role users.UserRole) ([]string, error)
+	ListMembershipsByUser(ctx context.Context, userID string) ([]Membership, error)
+}
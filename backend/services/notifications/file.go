@@ -0,0 +1,47 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer writes each email to an .eml file instead of sending it, for
+// local development. The files open in any mail client.
+type FileMailer struct {
+	directory string
+	from      string
+	sequence  atomic.Uint64
+}
+
+func NewFileMailer(directory, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	return &FileMailer{
+		directory: directory,
+		from:      from,
+	}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, message Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	body, err := encodeMessage(m.from, message)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%04d.eml", time.Now().Format("20060102-150405.000"), m.sequence.Add(1))
+	if err := os.WriteFile(filepath.Join(m.directory, name), body, 0o644); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+)
+
+// defaultSender names the sender of emails that don't come from an
+// organization.
+const defaultSender = "Easy Ballot"
+
+// Notifier renders and sends the application's emails.
+type Notifier struct {
+	mailer                 Mailer
+	templates              *Templates
+	organizationRepository organizations.OrganizationRepository
+	userRepository         users.UserRepository
+	baseURL                string
+}
+
+func NewNotifier(mailer Mailer, templates *Templates, organizationRepository organizations.OrganizationRepository, userRepository users.UserRepository, baseURL string) *Notifier {
+	return &Notifier{
+		mailer:                 mailer,
+		templates:              templates,
+		organizationRepository: organizationRepository,
+		userRepository:         userRepository,
+		baseURL:                baseURL,
+	}
+}
+
+func (n *Notifier) SendInvitation(ctx context.Context, organizationID, email string, role users.UserRole, url string, expiresAt time.Time) error {
+	branding, err := n.branding(ctx, organizationID)
+	if err != nil {
+		return err
+	}
+
+	return n.send(ctx, []string{email}, TemplateInvitation, InvitationEmail{
+		Branding:  branding,
+		Role:      string(role),
+		URL:       url,
+		ExpiresAt: expiresAt,
+	})
+}
+
+func (n *Notifier) SendPasswordReset(ctx context.Context, user users.User, url string, expiresAt time.Time) error {
+	return n.sendAccountEmail(ctx, user, TemplatePasswordReset, url, expiresAt)
+}
+
+func (n *Notifier) SendEmailVerification(ctx context.Context, user users.User, url string, expiresAt time.Time) error {
+	return n.sendAccountEmail(ctx, user, TemplateEmailVerification, url, expiresAt)
+}
+
+// sendAccountEmail sends account emails with the application's own branding,
+// since accounts are not tied to a single organization.
+func (n *Notifier) sendAccountEmail(ctx context.Context, user users.User, template, url string, expiresAt time.Time) error {
+	branding, err := n.branding(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	return n.send(ctx, []string{user.Email}, template, AccountEmail{
+		Branding:  branding,
+		FirstName: user.FirstName,
+		URL:       url,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// AnnounceBallotOpened tells the given users that they can vote.
+func (n *Notifier) AnnounceBallotOpened(ctx context.Context, ballot ballots.Ballot, userIDs []string) error {
+	return n.announce(ctx, ballot, userIDs, TemplateBallotOpened, "/ballots/"+ballot.ID)
+}
+
+// AnnounceResults tells the given users that a ballot's results are available.
+func (n *Notifier) AnnounceResults(ctx context.Context, ballot ballots.Ballot, userIDs []string) error {
+	return n.announce(ctx, ballot, userIDs, TemplateResultsPublished, "/ballots/"+ballot.ID+"/results")
+}
+
+func (n *Notifier) announce(ctx context.Context, ballot ballots.Ballot, userIDs []string, template, path string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	branding, err := n.branding(ctx, ballot.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	recipients, err := n.userRepository.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	emails := make([]string, len(recipients))
+	for i, recipient := range recipients {
+		emails[i] = recipient.Email
+	}
+
+	return n.send(ctx, emails, template, BallotEmail{
+		Branding: branding,
+		Title:    ballot.Title,
+		URL:      n.baseURL + path,
+		ClosesAt: ballot.ClosesAt,
+	})
+}
+
+// send renders the template once and emails each recipient separately so
+// addresses are never shared between members.
+func (n *Notifier) send(ctx context.Context, recipients []string, template string, data any) error {
+	message, err := n.templates.Render(template, data)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, recipient := range recipients {
+		message.To = recipient
+		if err := n.mailer.Send(ctx, message); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", recipient, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) branding(ctx context.Context, organizationID string) (Branding, error) {
+	branding := Branding{
+		OrganizationName: defaultSender,
+		AppURL:           n.baseURL,
+	}
+	if organizationID == "" {
+		return branding, nil
+	}
+
+	organization, err := n.organizationRepository.GetOrganizationByID(ctx, organizationID)
+	if err != nil {
+		return Branding{}, err
+	}
+
+	branding.OrganizationName = organization.Name
+	branding.OrganizationLogo = organization.Logo
+	return branding, nil
+}
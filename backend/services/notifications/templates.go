@@ -0,0 +1,116 @@
+package notifications
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+	"time"
+)
+
+const (
+	TemplateInvitation        = "invitation"
+	TemplateBallotOpened      = "ballot_opened"
+	TemplateResultsPublished  = "results_published"
+	TemplatePasswordReset     = "password_reset"
+	TemplateEmailVerification = "email_verification"
+)
+
+var templateNames = []string{
+	TemplateInvitation,
+	TemplateBallotOpened,
+	TemplateResultsPublished,
+	TemplatePasswordReset,
+	TemplateEmailVerification,
+}
+
+// Each template has a text version, which also defines the "subject", and an
+// HTML version that fills the "content" block of layout.html.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// Branding is available to every template so messages carry the sending
+// organization's name and logo.
+type Branding struct {
+	OrganizationName string
+	OrganizationLogo string
+	AppURL           string
+}
+
+type InvitationEmail struct {
+	Branding
+	Role      string
+	URL       string
+	ExpiresAt time.Time
+}
+
+type BallotEmail struct {
+	Branding
+	Title    string
+	URL      string
+	ClosesAt time.Time
+}
+
+// AccountEmail carries a single-use link for the recipient's own account.
+type AccountEmail struct {
+	Branding
+	FirstName string
+	URL       string
+	ExpiresAt time.Time
+}
+
+type Templates struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+func LoadTemplates() (*Templates, error) {
+	templates := &Templates{
+		text: make(map[string]*texttemplate.Template),
+		html: make(map[string]*htmltemplate.Template),
+	}
+
+	for _, name := range templateNames {
+		text, err := texttemplate.ParseFS(templateFS, "templates/"+name+".txt")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s text template: %w", name, err)
+		}
+		html, err := htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s HTML template: %w", name, err)
+		}
+
+		templates.text[name] = text
+		templates.html[name] = html
+	}
+
+	return templates, nil
+}
+
+// Render produces the subject and both bodies of a message. The recipient is
+// left for the caller to fill in.
+func (t *Templates) Render(name string, data any) (Message, error) {
+	text, ok := t.text[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %s", name)
+	}
+
+	var subject, textBody, htmlBody bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := text.ExecuteTemplate(&textBody, name+".txt", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+	if err := t.html[name].ExecuteTemplate(&htmlBody, "layout.html", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s HTML: %w", name, err)
+	}
+
+	return Message{
+		Subject: subject.String(),
+		Text:    textBody.String(),
+		HTML:    htmlBody.String(),
+	}, nil
+}
@@ -0,0 +1,207 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryOrganizationRepository keeps organizations in memory, for tests and
+// for running the server without a database. It is safe for concurrent use.
+type MemoryOrganizationRepository struct {
+	mu            sync.RWMutex
+	organizations map[string]Organization
+}
+
+func NewMemoryOrganizationRepository() *MemoryOrganizationRepository {
+	return &MemoryOrganizationRepository{
+		organizations: make(map[string]Organization),
+	}
+}
+
+func (r *MemoryOrganizationRepository) CreateOrganization(ctx context.Context, organization Organization) (*Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	organization.CreatedAt = now
+	organization.UpdatedAt = now
+	organization.NameLower = strings.ToLower(organization.Name)
+
+	if organization.ID == "" {
+		organization.ID = primitive.NewObjectID().Hex()
+	}
+
+	if _, ok := r.organizations[organization.ID]; ok {
+		return nil, fmt.Errorf("failed to create organization: duplicate ID %s", organization.ID)
+	}
+
+	r.organizations[organization.ID] = organization
+	return &organization, nil
+}
+
+func (r *MemoryOrganizationRepository) GetOrganizationByID(ctx context.Context, id string) (*Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	organization, ok := r.organizations[id]
+	if !ok || organization.DeletedAt != nil {
+		return nil, apperr.NotFound("organization not found")
+	}
+
+	return &organization, nil
+}
+
+func (r *MemoryOrganizationRepository) GetOrganizationsByOwner(ctx context.Context, ownerUserID string) ([]Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var organizations []Organization
+	for _, organization := range r.organizations {
+		if organization.OwnerUserID == ownerUserID && organization.DeletedAt == nil {
+			organizations = append(organizations, organization)
+		}
+	}
+
+	// Newest first
+	sort.Slice(organizations, func(i, j int) bool {
+		return organizations[i].CreatedAt.After(organizations[j].CreatedAt)
+	})
+	return organizations, nil
+}
+
+func (r *MemoryOrganizationRepository) UpdateOrganization(ctx context.Context, id string, organization Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.organizations[id]; !ok || existing.DeletedAt != nil {
+		return apperr.NotFound("organization not found")
+	}
+
+	organization.UpdatedAt = time.Now()
+	organization.ID = id
+	organization.NameLower = strings.ToLower(organization.Name)
+
+	r.organizations[id] = organization
+	return nil
+}
+
+func (r *MemoryOrganizationRepository) PatchOrganization(ctx context.Context, id string, changes OrganizationChanges) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	organization, ok := r.organizations[id]
+	if !ok || organization.DeletedAt != nil {
+		return apperr.NotFound("organization not found")
+	}
+
+	if changes.Name != nil {
+		organization.Name = *changes.Name
+		organization.NameLower = strings.ToLower(*changes.Name)
+	}
+	if changes.Logo != nil {
+		organization.Logo = *changes.Logo
+	}
+	if changes.OwnerUserID != nil {
+		organization.OwnerUserID = *changes.OwnerUserID
+	}
+	organization.UpdatedAt = time.Now()
+
+	r.organizations[id] = organization
+	return nil
+}
+
+func (r *MemoryOrganizationRepository) DeleteOrganization(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.organizations[id]; !ok {
+		return apperr.NotFound("organization not found")
+	}
+
+	delete(r.organizations, id)
+	return nil
+}
+
+func (r *MemoryOrganizationRepository) SoftDeleteOrganization(ctx context.Context, id string, deletedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	organization, ok := r.organizations[id]
+	if !ok || organization.DeletedAt != nil {
+		return apperr.NotFound("organization not found")
+	}
+
+	organization.DeletedAt = &deletedAt
+	r.organizations[id] = organization
+	return nil
+}
+
+func (r *MemoryOrganizationRepository) ListOrganizations(ctx context.Context, filter OrganizationFilter, page pagination.Page) ([]Organization, error) {
+	if _, ok := sortFields[page.Sort.Field]; !ok {
+		return nil, fmt.Errorf("cannot sort organizations by %s", page.Sort.Field)
+	}
+
+	organizations := r.matching(filter)
+	return pagination.Slice(organizations, page, func(organization Organization) (any, string) {
+		if page.Sort.Field == "name" {
+			return organization.NameLower, organization.ID
+		}
+		return organization.CreatedAt, organization.ID
+	}), nil
+}
+
+func (r *MemoryOrganizationRepository) CountOrganizations(ctx context.Context, filter OrganizationFilter) (int64, error) {
+	return int64(len(r.matching(filter))), nil
+}
+
+func (r *MemoryOrganizationRepository) matching(filter OrganizationFilter) []Organization {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var organizations []Organization
+	for _, organization := range r.organizations {
+		if organization.DeletedAt == nil && organizationMatches(organization, filter) {
+			organizations = append(organizations, organization)
+		}
+	}
+	return organizations
+}
+
+// organizationMatches mirrors organizationQuery. Text search matches whole
+// words of the name regardless of case, without the stemming MongoDB applies.
+func organizationMatches(organization Organization, filter OrganizationFilter) bool {
+	if filter.Search != "" && !matchesWords(filter.Search, organization.Name) {
+		return false
+	}
+	if filter.NamePrefix != "" && !strings.HasPrefix(organization.NameLower, strings.ToLower(filter.NamePrefix)) {
+		return false
+	}
+	if filter.CreatedAfter != nil && organization.CreatedAt.Before(*filter.CreatedAfter) {
+		return false
+	}
+	if filter.CreatedBefore != nil && !organization.CreatedAt.Before(*filter.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// matchesWords reports whether any word of the search appears in the name.
+func matchesWords(search, name string) bool {
+	words := strings.Fields(strings.ToLower(name))
+	for _, term := range strings.Fields(strings.ToLower(search)) {
+		for _, word := range words {
+			if term == word {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package organizations_test
+
+import (
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/repotest"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+)
+
+func TestMemoryOrganizationRepository(t *testing.T) {
+	repotest.TestOrganizationRepository(t, func(t *testing.T) organizations.OrganizationRepository {
+		return organizations.NewMemoryOrganizationRepository()
+	})
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -22,13 +26,21 @@ func NewMongoDBOrganizationRepository(collection *mongo.Collection) *MongoDBOrga
 	}
 }
 
-func (r *MongoDBOrganizationRepository) CreateOrganization(ctx context.Context, organization Organization) error {
+// sortFields maps the fields organizations can be sorted by to the stored
+// fields that back them. Names sort case-insensitively.
+var sortFields = map[string]string{
+	"created_at": "created_at",
+	"name":       "name_lower",
+}
+
+func (r *MongoDBOrganizationRepository) CreateOrganization(ctx context.Context, organization Organization) (*Organization, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	now := time.Now()
 	organization.CreatedAt = now
 	organization.UpdatedAt = now
+	organization.NameLower = strings.ToLower(organization.Name)
 
 	if organization.ID == "" {
 		organization.ID = primitive.NewObjectID().Hex()
@@ -36,10 +48,10 @@ func (r *MongoDBOrganizationRepository) CreateOrganization(ctx context.Context,
 
 	_, err := r.collection.InsertOne(ctx, organization)
 	if err != nil {
-		return fmt.Errorf("failed to create organization: %w", err)
+		return nil, fmt.Errorf("failed to create organization: %w", err)
 	}
 
-	return nil
+	return &organization, nil
 }
 
 func (r *MongoDBOrganizationRepository) GetOrganizationByID(ctx context.Context, id string) (*Organization, error) {
@@ -48,12 +60,12 @@ func (r *MongoDBOrganizationRepository) GetOrganizationByID(ctx context.Context,
 
 	var organization Organization
 	log.Println(id)
-	filter := bson.M{"_id": id}
+	filter := bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}
 
 	err := r.collection.FindOne(ctx, filter).Decode(&organization)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("organization not found")
+			return nil, apperr.NotFound("organization not found")
 		}
 		return nil, fmt.Errorf("failed to get organization: %w", err)
 	}
@@ -65,7 +77,7 @@ func (r *MongoDBOrganizationRepository) GetOrganizationsByOwner(ctx context.Cont
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{"owner_user_id": ownerUserID}
+	filter := bson.M{"owner_user_id": ownerUserID, "deleted_at": bson.M{"$exists": false}}
 	opts := options.Find().SetSort(bson.M{"created_at": -1})
 
 	cursor, err := r.collection.Find(ctx, filter, opts)
@@ -88,8 +100,9 @@ func (r *MongoDBOrganizationRepository) UpdateOrganization(ctx context.Context,
 
 	organization.UpdatedAt = time.Now()
 	organization.ID = id
+	organization.NameLower = strings.ToLower(organization.Name)
 
-	filter := bson.M{"id": id}
+	filter := bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}
 	update := bson.M{"$set": organization}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
@@ -98,7 +111,37 @@ func (r *MongoDBOrganizationRepository) UpdateOrganization(ctx context.Context,
 	}
 
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("organization not found")
+		return apperr.NotFound("organization not found")
+	}
+
+	return nil
+}
+
+// PatchOrganization sets only the given fields, so concurrent changes to the
+// others are kept.
+func (r *MongoDBOrganizationRepository) PatchOrganization(ctx context.Context, id string, changes OrganizationChanges) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	set := bson.M{"updated_at": time.Now()}
+	if changes.Name != nil {
+		set["name"] = *changes.Name
+		set["name_lower"] = strings.ToLower(*changes.Name)
+	}
+	if changes.Logo != nil {
+		set["logo"] = *changes.Logo
+	}
+	if changes.OwnerUserID != nil {
+		set["owner_user_id"] = *changes.OwnerUserID
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}, bson.M{"$set": set})
+	if err != nil {
+		return fmt.Errorf("failed to patch organization: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return apperr.NotFound("organization not found")
 	}
 
 	return nil
@@ -116,22 +159,46 @@ func (r *MongoDBOrganizationRepository) DeleteOrganization(ctx context.Context,
 	}
 
 	if result.DeletedCount == 0 {
-		return fmt.Errorf("organization not found")
+		return apperr.NotFound("organization not found")
 	}
 
 	return nil
 }
 
-func (r *MongoDBOrganizationRepository) ListOrganizations(ctx context.Context, limit, offset int) ([]Organization, error) {
+// SoftDeleteOrganization marks an organization deleted; every other method
+// skips documents with deleted_at set.
+func (r *MongoDBOrganizationRepository) SoftDeleteOrganization(ctx context.Context, id string, deletedAt time.Time) error {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	opts := options.Find().
-		SetLimit(int64(limit)).
-		SetSkip(int64(offset)).
-		SetSort(bson.M{"created_at": -1})
+	filter := bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"deleted_at": deletedAt}}
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to delete organization: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return apperr.NotFound("organization not found")
+	}
+
+	return nil
+}
+
+func (r *MongoDBOrganizationRepository) ListOrganizations(ctx context.Context, filter OrganizationFilter, page pagination.Page) ([]Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	sortField, ok := sortFields[page.Sort.Field]
+	if !ok {
+		return nil, fmt.Errorf("cannot sort organizations by %s", page.Sort.Field)
+	}
+
+	query := pagination.MongoFilter(organizationQuery(filter), page, sortField)
+	opts := pagination.MongoFindOptions(page, sortField)
+
+	cursor, err := r.collection.Find(ctx, query, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list organizations: %w", err)
 	}
@@ -145,14 +212,38 @@ func (r *MongoDBOrganizationRepository) ListOrganizations(ctx context.Context, l
 	return organizations, nil
 }
 
-func (r *MongoDBOrganizationRepository) CountOrganizations(ctx context.Context) (int64, error) {
+func (r *MongoDBOrganizationRepository) CountOrganizations(ctx context.Context, filter OrganizationFilter) (int64, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	count, err := r.collection.CountDocuments(ctx, bson.M{})
+	count, err := r.collection.CountDocuments(ctx, organizationQuery(filter))
 	if err != nil {
 		return 0, fmt.Errorf("failed to count organizations: %w", err)
 	}
 
 	return count, nil
 }
+
+func organizationQuery(filter OrganizationFilter) bson.M {
+	query := bson.M{"deleted_at": bson.M{"$exists": false}}
+	if filter.Search != "" {
+		query["$text"] = bson.M{"$search": filter.Search}
+	}
+	if filter.NamePrefix != "" {
+		// An anchored, case-sensitive regex on the lowercased name can use its index
+		query["name_lower"] = bson.M{"$regex": "^" + regexp.QuoteMeta(strings.ToLower(filter.NamePrefix))}
+	}
+
+	created := bson.M{}
+	if filter.CreatedAfter != nil {
+		created["$gte"] = *filter.CreatedAfter
+	}
+	if filter.CreatedBefore != nil {
+		created["$lt"] = *filter.CreatedBefore
+	}
+	if len(created) > 0 {
+		query["created_at"] = created
+	}
+
+	return query
+}
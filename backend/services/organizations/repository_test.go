@@ -0,0 +1,17 @@
+package organizations_test
+
+import (
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/repotest"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+)
+
+func TestMongoDBOrganizationRepository(t *testing.T) {
+	client := repotest.MongoClient(t)
+
+	repotest.TestOrganizationRepository(t, func(t *testing.T) organizations.OrganizationRepository {
+		database := repotest.MongoDatabase(t, client)
+		return organizations.NewMongoDBOrganizationRepository(database.Collection("organizations"))
+	})
+}
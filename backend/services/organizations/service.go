@@ -2,24 +2,31 @@ package organizations
 
 import (
 	"context"
-	"fmt"
 	"strings"
 	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/validation"
 )
 
+const maxNameLength = 100
+
 type OrganizationService struct {
 	repository OrganizationRepository
+	deletions  DeletionRepository
 }
 
-func NewOrganizationService(repository OrganizationRepository) *OrganizationService {
+func NewOrganizationService(repository OrganizationRepository, deletions DeletionRepository) *OrganizationService {
 	return &OrganizationService{
 		repository: repository,
+		deletions:  deletions,
 	}
 }
 
-func (s *OrganizationService) CreateOrganization(ctx context.Context, organization CreateOrganizationRequest) error {
+func (s *OrganizationService) CreateOrganization(ctx context.Context, organization CreateOrganizationRequest) (*Organization, error) {
 	if err := s.validateCreateOrganizationRequest(organization); err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+		return nil, err
 	}
 
 	return s.repository.CreateOrganization(ctx, Organization{
@@ -31,7 +38,7 @@ func (s *OrganizationService) CreateOrganization(ctx context.Context, organizati
 
 func (s *OrganizationService) GetOrganizationByID(ctx context.Context, id string) (*Organization, error) {
 	if strings.TrimSpace(id) == "" {
-		return nil, fmt.Errorf("organization ID cannot be empty")
+		return nil, apperr.Validation("id", "is required")
 	}
 
 	return s.repository.GetOrganizationByID(ctx, id)
@@ -39,24 +46,35 @@ func (s *OrganizationService) GetOrganizationByID(ctx context.Context, id string
 
 func (s *OrganizationService) GetOrganizationsByOwner(ctx context.Context, ownerUserID string) ([]Organization, error) {
 	if strings.TrimSpace(ownerUserID) == "" {
-		return nil, fmt.Errorf("owner user ID cannot be empty")
+		return nil, apperr.Validation("owner_user_id", "is required")
 	}
 
 	return s.repository.GetOrganizationsByOwner(ctx, ownerUserID)
 }
 
+// UpdateOrganization replaces an organization's details. The owner stays the
+// same: owner_user_id may be left out, but a different owner is rejected,
+// since only the owner may transfer the organization through
+// PatchOrganization.
 func (s *OrganizationService) UpdateOrganization(ctx context.Context, id string, organization Organization) error {
 	if strings.TrimSpace(id) == "" {
-		return fmt.Errorf("organization ID cannot be empty")
-	}
-
-	if err := s.validateOrganization(organization); err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+		return apperr.Validation("id", "is required")
 	}
 
 	existingOrganization, err := s.repository.GetOrganizationByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("organization not found: %w", err)
+		return err
+	}
+
+	if organization.OwnerUserID == "" {
+		organization.OwnerUserID = existingOrganization.OwnerUserID
+	}
+	if organization.OwnerUserID != existingOrganization.OwnerUserID {
+		return apperr.Validation("owner_user_id", "can only be changed by the owner with PATCH")
+	}
+
+	if err := s.validateOrganization(organization); err != nil {
+		return err
 	}
 
 	organization.CreatedAt = existingOrganization.CreatedAt
@@ -65,50 +83,113 @@ func (s *OrganizationService) UpdateOrganization(ctx context.Context, id string,
 	return s.repository.UpdateOrganization(ctx, id, organization)
 }
 
-func (s *OrganizationService) DeleteOrganization(ctx context.Context, id string) error {
+// PatchOrganization applies a merge patch to an organization and returns the
+// result. Changing owner_user_id only records the new owner; memberships
+// must be updated to match, as memberships.TransferOwnership does.
+func (s *OrganizationService) PatchOrganization(ctx context.Context, id string, patch OrganizationPatch) (*Organization, error) {
 	if strings.TrimSpace(id) == "" {
-		return fmt.Errorf("organization ID cannot be empty")
+		return nil, apperr.Validation("id", "is required")
+	}
+
+	if err := ValidatePatch(patch); err != nil {
+		return nil, err
+	}
+
+	var changes OrganizationChanges
+	if patch.Name.Set {
+		changes.Name = &patch.Name.Value
+	}
+	if patch.Logo.Set {
+		changes.Logo = &patch.Logo.Value
+	}
+	if patch.OwnerUserID.Set {
+		changes.OwnerUserID = &patch.OwnerUserID.Value
+	}
+
+	if err := s.repository.PatchOrganization(ctx, id, changes); err != nil {
+		return nil, err
 	}
 
-	return s.repository.DeleteOrganization(ctx, id)
+	return s.repository.GetOrganizationByID(ctx, id)
 }
 
-func (s *OrganizationService) ListOrganizations(ctx context.Context, limit, offset int) ([]Organization, error) {
-	if limit <= 0 {
-		limit = 10
+// DeleteOrganization soft-deletes an organization and archives its members
+// and ballots. It is refused while a ballot is open or scheduled, since
+// deleting the organization would cut the vote short or silently cancel it.
+func (s *OrganizationService) DeleteOrganization(ctx context.Context, id string) (*DeletionReport, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, apperr.Validation("id", "is required")
 	}
-	if limit > 100 {
-		limit = 100
+
+	return s.deletions.DeleteOrganization(ctx, id, time.Now())
+}
+
+func (s *OrganizationService) ListOrganizations(ctx context.Context, filter OrganizationFilter, page pagination.Page) (*pagination.List[Organization], error) {
+	page = page.Normalize(pagination.Sort{Field: "created_at", Descending: true})
+
+	organizations, err := s.repository.ListOrganizations(ctx, filter, page.Lookahead())
+	if err != nil {
+		return nil, err
 	}
-	if offset < 0 {
-		offset = 0
+
+	total, err := s.repository.CountOrganizations(ctx, filter)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.repository.ListOrganizations(ctx, limit, offset)
+	organizations, nextCursor := pagination.NextPage(organizations, page, func(organization Organization) (any, string) {
+		if page.Sort.Field == "name" {
+			return organization.NameLower, organization.ID
+		}
+		return organization.CreatedAt, organization.ID
+	})
+	return &pagination.List[Organization]{
+		Items:      organizations,
+		NextCursor: nextCursor,
+		Total:      total,
+	}, nil
 }
 
 func (s *OrganizationService) CountOrganizations(ctx context.Context) (int64, error) {
-	return s.repository.CountOrganizations(ctx)
+	return s.repository.CountOrganizations(ctx, OrganizationFilter{})
 }
 
 func (s *OrganizationService) validateOrganization(organization Organization) error {
-	if strings.TrimSpace(organization.Name) == "" {
-		return fmt.Errorf("name is required")
-	}
-	if strings.TrimSpace(organization.OwnerUserID) == "" {
-		return fmt.Errorf("owner user ID is required")
-	}
+	var v validation.Validator
+	validateDetails(&v, organization.Name, organization.Logo)
+	v.Required("owner_user_id", organization.OwnerUserID)
 
-	return nil
+	return v.Err()
 }
 
 func (s *OrganizationService) validateCreateOrganizationRequest(organization CreateOrganizationRequest) error {
-	if strings.TrimSpace(organization.Name) == "" {
-		return fmt.Errorf("name is required")
+	var v validation.Validator
+	validateDetails(&v, organization.Name, organization.Logo)
+	v.Required("owner_user_id", organization.OwnerUserID)
+
+	return v.Err()
+}
+
+func validateDetails(v *validation.Validator, name, logo string) {
+	if v.Required("name", name) {
+		v.Length("name", name, 0, maxNameLength)
+	}
+	v.URL("logo", logo)
+}
+
+// ValidatePatch checks the fields a patch sets. Null clears a field, so it is
+// rejected for required ones.
+func ValidatePatch(patch OrganizationPatch) error {
+	var v validation.Validator
+	if patch.Name.Set && v.Required("name", patch.Name.Value) {
+		v.Length("name", patch.Name.Value, 0, maxNameLength)
+	}
+	if patch.Logo.Set {
+		v.URL("logo", patch.Logo.Value)
 	}
-	if strings.TrimSpace(organization.OwnerUserID) == "" {
-		return fmt.Errorf("owner user ID is required")
+	if patch.OwnerUserID.Set {
+		v.Required("owner_user_id", patch.OwnerUserID.Value)
 	}
 
-	return nil
+	return v.Err()
 }
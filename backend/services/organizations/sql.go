@@ -0,0 +1,257 @@
+package organizations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SQLOrganizationRepository stores organizations in the organizations table
+// of a SQL database.
+type SQLOrganizationRepository struct {
+	db *sqldb.DB
+}
+
+func NewSQLOrganizationRepository(db *sqldb.DB) *SQLOrganizationRepository {
+	return &SQLOrganizationRepository{
+		db: db,
+	}
+}
+
+const organizationColumns = "id, name, name_lower, logo, owner_user_id, created_at, updated_at"
+
+func (r *SQLOrganizationRepository) CreateOrganization(ctx context.Context, organization Organization) (*Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	organization.CreatedAt = now
+	organization.UpdatedAt = now
+	organization.NameLower = strings.ToLower(organization.Name)
+
+	if organization.ID == "" {
+		organization.ID = primitive.NewObjectID().Hex()
+	}
+
+	q := r.db.Query()
+	values := q.Values(organization.ID, organization.Name, organization.NameLower, organization.Logo,
+		organization.OwnerUserID, organization.CreatedAt, organization.UpdatedAt)
+	_, err := r.db.ExecContext(ctx, "INSERT INTO organizations ("+organizationColumns+") VALUES "+values, q.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	return &organization, nil
+}
+
+func (r *SQLOrganizationRepository) GetOrganizationByID(ctx context.Context, id string) (*Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	organization, err := scanOrganization(r.db.QueryRowContext(ctx, "SELECT "+organizationColumns+" FROM organizations WHERE id = "+q.Arg(id)+" AND deleted_at IS NULL", q.Args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperr.NotFound("organization not found")
+		}
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	return organization, nil
+}
+
+func (r *SQLOrganizationRepository) GetOrganizationsByOwner(ctx context.Context, ownerUserID string) ([]Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	query := "SELECT " + organizationColumns + " FROM organizations WHERE owner_user_id = " + q.Arg(ownerUserID) + " AND deleted_at IS NULL ORDER BY created_at DESC"
+	organizations, err := r.queryOrganizations(ctx, query, q.Args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organizations by owner: %w", err)
+	}
+
+	return organizations, nil
+}
+
+func (r *SQLOrganizationRepository) UpdateOrganization(ctx context.Context, id string, organization Organization) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	organization.UpdatedAt = time.Now()
+	organization.ID = id
+	organization.NameLower = strings.ToLower(organization.Name)
+
+	q := r.db.Query()
+	result, err := r.db.ExecContext(ctx, "UPDATE organizations SET "+
+		"name = "+q.Arg(organization.Name)+
+		", name_lower = "+q.Arg(organization.NameLower)+
+		", logo = "+q.Arg(organization.Logo)+
+		", owner_user_id = "+q.Arg(organization.OwnerUserID)+
+		", created_at = "+q.Arg(organization.CreatedAt)+
+		", updated_at = "+q.Arg(organization.UpdatedAt)+
+		" WHERE id = "+q.Arg(id)+" AND deleted_at IS NULL", q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to update organization: %w", err)
+	}
+
+	return organizationUpdated(result)
+}
+
+// PatchOrganization sets only the given fields, so concurrent changes to the
+// others are kept.
+func (r *SQLOrganizationRepository) PatchOrganization(ctx context.Context, id string, changes OrganizationChanges) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	set := []string{"updated_at = " + q.Arg(time.Now())}
+	if changes.Name != nil {
+		set = append(set, "name = "+q.Arg(*changes.Name), "name_lower = "+q.Arg(strings.ToLower(*changes.Name)))
+	}
+	if changes.Logo != nil {
+		set = append(set, "logo = "+q.Arg(*changes.Logo))
+	}
+	if changes.OwnerUserID != nil {
+		set = append(set, "owner_user_id = "+q.Arg(*changes.OwnerUserID))
+	}
+
+	result, err := r.db.ExecContext(ctx, "UPDATE organizations SET "+strings.Join(set, ", ")+" WHERE id = "+q.Arg(id)+" AND deleted_at IS NULL", q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to patch organization: %w", err)
+	}
+
+	return organizationUpdated(result)
+}
+
+func (r *SQLOrganizationRepository) DeleteOrganization(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	result, err := r.db.ExecContext(ctx, "DELETE FROM organizations WHERE id = "+q.Arg(id), q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete organization: %w", err)
+	}
+
+	return organizationUpdated(result)
+}
+
+func (r *SQLOrganizationRepository) SoftDeleteOrganization(ctx context.Context, id string, deletedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	result, err := r.db.ExecContext(ctx, "UPDATE organizations SET deleted_at = "+q.Arg(deletedAt)+" WHERE id = "+q.Arg(id)+" AND deleted_at IS NULL", q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete organization: %w", err)
+	}
+
+	return organizationUpdated(result)
+}
+
+func (r *SQLOrganizationRepository) ListOrganizations(ctx context.Context, filter OrganizationFilter, page pagination.Page) ([]Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	sortColumn, ok := sortFields[page.Sort.Field]
+	if !ok {
+		return nil, fmt.Errorf("cannot sort organizations by %s", page.Sort.Field)
+	}
+
+	q := r.db.Query()
+	conditions := organizationConditions(q, filter)
+	if after := pagination.SQLAfter(q, page, sortColumn); after != "" {
+		conditions = append(conditions, after)
+	}
+
+	query := "SELECT " + organizationColumns + " FROM organizations" + sqldb.Where(conditions) + pagination.SQLOrderBy(q, page, sortColumn)
+	organizations, err := r.queryOrganizations(ctx, query, q.Args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	return organizations, nil
+}
+
+func (r *SQLOrganizationRepository) CountOrganizations(ctx context.Context, filter OrganizationFilter) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	var count int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM organizations"+sqldb.Where(organizationConditions(q, filter)), q.Args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count organizations: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *SQLOrganizationRepository) queryOrganizations(ctx context.Context, query string, args []any) ([]Organization, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var organizations []Organization
+	for rows.Next() {
+		organization, err := scanOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+		organizations = append(organizations, *organization)
+	}
+
+	return organizations, rows.Err()
+}
+
+func scanOrganization(row sqldb.Scanner) (*Organization, error) {
+	var organization Organization
+	err := row.Scan(&organization.ID, &organization.Name, &organization.NameLower, &organization.Logo,
+		&organization.OwnerUserID, &organization.CreatedAt, &organization.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// organizationUpdated reports an organization as not found if the statement
+// matched no rows.
+func organizationUpdated(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read rows affected: %w", err)
+	}
+	if affected == 0 {
+		return apperr.NotFound("organization not found")
+	}
+	return nil
+}
+
+// organizationConditions mirrors organizationQuery.
+func organizationConditions(q *sqldb.Query, filter OrganizationFilter) []string {
+	conditions := []string{"deleted_at IS NULL"}
+	if filter.Search != "" {
+		conditions = append(conditions, q.MatchesAnyWord("name", filter.Search))
+	}
+	if filter.NamePrefix != "" {
+		conditions = append(conditions, q.HasPrefix("name_lower", strings.ToLower(filter.NamePrefix)))
+	}
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, "created_at >= "+q.Arg(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, "created_at < "+q.Arg(*filter.CreatedBefore))
+	}
+	return conditions
+}
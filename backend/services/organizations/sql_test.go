@@ -0,0 +1,22 @@
+package organizations_test
+
+import (
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/repotest"
+	"github.com/bpalazzi512/easy-ballot/backend/services/organizations"
+)
+
+func TestPostgresOrganizationRepository(t *testing.T) {
+	serverURL := repotest.PostgresURL(t)
+
+	repotest.TestOrganizationRepository(t, func(t *testing.T) organizations.OrganizationRepository {
+		return organizations.NewSQLOrganizationRepository(repotest.PostgresDB(t, serverURL))
+	})
+}
+
+func TestSQLiteOrganizationRepository(t *testing.T) {
+	repotest.TestOrganizationRepository(t, func(t *testing.T) organizations.OrganizationRepository {
+		return organizations.NewSQLOrganizationRepository(repotest.SQLiteDB(t))
+	})
+}
@@ -3,15 +3,22 @@ package organizations
 import (
 	"context"
 	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/mergepatch"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
 )
 
 type Organization struct {
 	ID          string    `json:"id" bson:"_id,omitempty"`
 	Name        string    `json:"name" bson:"name"`
+	NameLower   string    `json:"-" bson:"name_lower"`
 	Logo        string    `json:"logo" bson:"logo"`
 	OwnerUserID string    `json:"owner_user_id" bson:"owner_user_id"`
 	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
+	// DeletedAt is set when the organization is soft-deleted, after which
+	// the repository no longer returns it
+	DeletedAt *time.Time `json:"-" bson:"deleted_at,omitempty"`
 }
 
 type CreateOrganizationRequest struct {
@@ -20,12 +27,65 @@ type CreateOrganizationRequest struct {
 	OwnerUserID string `json:"owner_user_id"`
 }
 
+// OrganizationPatch is a JSON Merge Patch of an organization: members it
+// leaves out are unchanged.
+type OrganizationPatch struct {
+	Name        mergepatch.Field[string] `json:"name"`
+	Logo        mergepatch.Field[string] `json:"logo"`
+	OwnerUserID mergepatch.Field[string] `json:"owner_user_id"`
+}
+
+// OrganizationChanges lists the fields PatchOrganization stores. Nil fields
+// are left alone.
+type OrganizationChanges struct {
+	Name        *string
+	Logo        *string
+	OwnerUserID *string
+}
+
+// OrganizationFilter narrows a list of organizations. Zero fields match
+// everything.
+type OrganizationFilter struct {
+	// Search matches whole words of the name using the text index
+	Search        string
+	NamePrefix    string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
 type OrganizationRepository interface {
-	CreateOrganization(ctx context.Context, organization Organization) error
+	CreateOrganization(ctx context.Context, organization Organization) (*Organization, error)
 	GetOrganizationByID(ctx context.Context, id string) (*Organization, error)
 	GetOrganizationsByOwner(ctx context.Context, ownerUserID string) ([]Organization, error)
 	UpdateOrganization(ctx context.Context, id string, organization Organization) error
+	PatchOrganization(ctx context.Context, id string, changes OrganizationChanges) error
 	DeleteOrganization(ctx context.Context, id string) error
-	ListOrganizations(ctx context.Context, limit, offset int) ([]Organization, error)
-	CountOrganizations(ctx context.Context) (int64, error)
+	// SoftDeleteOrganization hides an organization from every other method
+	// while keeping its record.
+	SoftDeleteOrganization(ctx context.Context, id string, deletedAt time.Time) error
+	ListOrganizations(ctx context.Context, filter OrganizationFilter, page pagination.Page) ([]Organization, error)
+	CountOrganizations(ctx context.Context, filter OrganizationFilter) (int64, error)
+}
+
+// DeletionReport lists what deleting an organization affected.
+type DeletionReport struct {
+	OrganizationID     string    `json:"organization_id"`
+	DeletedAt          time.Time `json:"deleted_at"`
+	MembersArchived    int64     `json:"members_archived"`
+	BallotsArchived    int64     `json:"ballots_archived"`
+	InvitationsDeleted int64     `json:"invitations_deleted"`
+	// Retained names the collections left as they are, keyed by the
+	// archived ballots' IDs
+	Retained []string `json:"retained"`
+}
+
+// DeletionRepository deletes organizations together with what belongs to
+// them. The archives package implements it for each storage backend.
+type DeletionRepository interface {
+	// DeleteOrganization soft-deletes an organization, moves its memberships
+	// and ballots to the archive and deletes all of its invitations, all or
+	// nothing. It returns a conflict while any of the organization's ballots
+	// is open or scheduled to open. The MongoDB implementation runs in a
+	// transaction and so needs a replica set.
+	DeleteOrganization(ctx context.Context, id string, now time.Time) (*DeletionReport, error)
 }
@@ -0,0 +1,78 @@
+package rolls
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryRollRepository keeps voter rolls in memory, for tests and for running
+// the server without a database. It is safe for concurrent use.
+type MemoryRollRepository struct {
+	mu      sync.RWMutex
+	entries []Entry
+}
+
+func NewMemoryRollRepository() *MemoryRollRepository {
+	return &MemoryRollRepository{}
+}
+
+func (r *MemoryRollRepository) CreateEntries(ctx context.Context, entries []Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, entry := range entries {
+		if r.isOnRoll(entry.BallotID, entry.UserID) {
+			continue
+		}
+		if entry.ID == "" {
+			entry.ID = primitive.NewObjectID().Hex()
+		}
+		entry.CreatedAt = now
+		r.entries = append(r.entries, entry)
+	}
+
+	return nil
+}
+
+func (r *MemoryRollRepository) IsOnRoll(ctx context.Context, ballotID, userID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.isOnRoll(ballotID, userID), nil
+}
+
+func (r *MemoryRollRepository) ListEntries(ctx context.Context, ballotID string) ([]Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []Entry
+	for _, entry := range r.entries {
+		if entry.BallotID == ballotID {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].UserID < entries[j].UserID
+	})
+
+	return entries, nil
+}
+
+func (r *MemoryRollRepository) CountEntries(ctx context.Context, ballotID string) (int64, error) {
+	entries, _ := r.ListEntries(ctx, ballotID)
+	return int64(len(entries)), nil
+}
+
+func (r *MemoryRollRepository) isOnRoll(ballotID, userID string) bool {
+	for _, entry := range r.entries {
+		if entry.BallotID == ballotID && entry.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
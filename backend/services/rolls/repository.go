@@ -0,0 +1,112 @@
+package rolls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoDBRollRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoDBRollRepository(collection *mongo.Collection) *MongoDBRollRepository {
+	return &MongoDBRollRepository{
+		collection: collection,
+	}
+}
+
+func (r *MongoDBRollRepository) CreateEntries(ctx context.Context, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	documents := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		if entry.ID == "" {
+			entry.ID = primitive.NewObjectID().Hex()
+		}
+		entry.CreatedAt = now
+		documents[i] = entry
+	}
+
+	_, err := r.collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	if err != nil && !onlyDuplicateKeyErrors(err) {
+		return fmt.Errorf("failed to create voter roll: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MongoDBRollRepository) IsOnRoll(ctx context.Context, ballotID, userID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"ballot_id": ballotID, "user_id": userID}
+
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("failed to check voter roll: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *MongoDBRollRepository) ListEntries(ctx context.Context, ballotID string) ([]Entry, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"ballot_id": ballotID}
+	opts := options.Find().SetSort(bson.M{"user_id": 1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list voter roll: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []Entry
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode voter roll: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (r *MongoDBRollRepository) CountEntries(ctx context.Context, ballotID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"ballot_id": ballotID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count voter roll: %w", err)
+	}
+
+	return count, nil
+}
+
+func onlyDuplicateKeyErrors(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) {
+		return false
+	}
+	if bulkErr.WriteConcernError != nil {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != 11000 {
+			return false
+		}
+	}
+	return true
+}
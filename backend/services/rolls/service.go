@@ -0,0 +1,179 @@
+package rolls
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+)
+
+const memberPageSize = 100
+
+type RollService struct {
+	repository           RollRepository
+	ballotRepository     ballots.BallotRepository
+	membershipRepository memberships.MembershipRepository
+}
+
+func NewRollService(repository RollRepository, ballotRepository ballots.BallotRepository, membershipRepository memberships.MembershipRepository) *RollService {
+	return &RollService{
+		repository:           repository,
+		ballotRepository:     ballotRepository,
+		membershipRepository: membershipRepository,
+	}
+}
+
+// Snapshot freezes the ballot's voter roll from its eligibility rules and the
+// members who had joined by the time it opened. It is a no-op once a snapshot
+// has been taken, so membership changes after a ballot opens never affect who
+// may vote on it.
+func (s *RollService) Snapshot(ctx context.Context, ballot *ballots.Ballot) error {
+	if ballot.RollSnapshotAt != nil {
+		return nil
+	}
+
+	eligible, err := s.eligibleUsers(ctx, *ballot)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]Entry, len(eligible))
+	for i, member := range eligible {
+		entries[i] = Entry{
+			BallotID: ballot.ID,
+			UserID:   member.UserID,
+			Role:     member.Role,
+		}
+	}
+
+	if err := s.repository.CreateEntries(ctx, entries); err != nil {
+		return err
+	}
+
+	snapshotAt := time.Now()
+	if err := s.ballotRepository.SetRollSnapshotAt(ctx, ballot.ID, snapshotAt); err != nil {
+		return err
+	}
+	ballot.RollSnapshotAt = &snapshotAt
+
+	return nil
+}
+
+// IsEligible reports whether the user is on the ballot's voter roll. Ballots
+// that opened on schedule are snapshotted on first use, leaving out members
+// who joined after opens_at.
+func (s *RollService) IsEligible(ctx context.Context, ballot *ballots.Ballot, userID string) (bool, error) {
+	if err := s.ensureSnapshot(ctx, ballot); err != nil {
+		return false, err
+	}
+
+	return s.repository.IsOnRoll(ctx, ballot.ID, userID)
+}
+
+func (s *RollService) ListEntries(ctx context.Context, ballot *ballots.Ballot) ([]Entry, error) {
+	if err := s.ensureSnapshot(ctx, ballot); err != nil {
+		return nil, err
+	}
+
+	return s.repository.ListEntries(ctx, ballot.ID)
+}
+
+// Turnout compares the number of voters against the size of the roll.
+func (s *RollService) Turnout(ctx context.Context, ballot *ballots.Ballot, voted int64) (*Turnout, error) {
+	if err := s.ensureSnapshot(ctx, ballot); err != nil {
+		return nil, err
+	}
+
+	eligible, err := s.repository.CountEntries(ctx, ballot.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	turnout := &Turnout{
+		BallotID: ballot.ID,
+		Eligible: eligible,
+		Voted:    voted,
+	}
+	if eligible > 0 {
+		turnout.Percent = float64(voted) / float64(eligible) * 100
+	}
+
+	return turnout, nil
+}
+
+func (s *RollService) ensureSnapshot(ctx context.Context, ballot *ballots.Ballot) error {
+	if ballot.RollSnapshotAt != nil || !ballot.HasOpened(time.Now()) {
+		return nil
+	}
+	return s.Snapshot(ctx, ballot)
+}
+
+func (s *RollService) eligibleUsers(ctx context.Context, ballot ballots.Ballot) ([]memberships.Membership, error) {
+	members, err := s.organizationMembers(ctx, ballot.OrganizationID, ballot.OpensAt)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ballot.Eligibility.Mode {
+	case ballots.EligibilityAllMembers, "":
+		return members, nil
+	case ballots.EligibilityRoles:
+		var eligible []memberships.Membership
+		for _, member := range members {
+			for _, role := range ballot.Eligibility.Roles {
+				if member.Role == role {
+					eligible = append(eligible, member)
+					break
+				}
+			}
+		}
+		return eligible, nil
+	case ballots.EligibilityList:
+		// Listed users who are not members of the organization are ignored.
+		listed := make(map[string]bool, len(ballot.Eligibility.UserIDs))
+		for _, userID := range ballot.Eligibility.UserIDs {
+			listed[userID] = true
+		}
+
+		var eligible []memberships.Membership
+		for _, member := range members {
+			if listed[member.UserID] {
+				eligible = append(eligible, member)
+			}
+		}
+		return eligible, nil
+	default:
+		return nil, fmt.Errorf("unsupported eligibility mode %s", ballot.Eligibility.Mode)
+	}
+}
+
+// organizationMembers lists the members who joined the organization no later
+// than joinedBy.
+func (s *RollService) organizationMembers(ctx context.Context, organizationID string, joinedBy time.Time) ([]memberships.Membership, error) {
+	var members []memberships.Membership
+	page := pagination.Page{Limit: memberPageSize, Sort: pagination.Sort{Field: "created_at"}}
+	for {
+		batch, err := s.membershipRepository.ListMembers(ctx, organizationID, page)
+		if err != nil {
+			return nil, err
+		}
+
+		// Members are listed in the order they joined
+		for i, member := range batch {
+			if member.CreatedAt.After(joinedBy) {
+				return append(members, batch[:i]...), nil
+			}
+		}
+
+		members = append(members, batch...)
+		if len(batch) < memberPageSize {
+			return members, nil
+		}
+
+		last := batch[len(batch)-1]
+		page.After = &pagination.Cursor{Field: "created_at", Value: last.CreatedAt, ID: last.ID}
+	}
+}
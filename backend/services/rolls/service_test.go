@@ -0,0 +1,188 @@
+package rolls_test
+
+import (
+	"context"
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/rolls"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+)
+
+type fixture struct {
+	service     *rolls.RollService
+	ballots     *ballots.MemoryBallotRepository
+	memberships *memberships.MemoryMembershipRepository
+	// opensAt falls between the members who joined early and "late"
+	opensAt time.Time
+}
+
+// newFixture builds a roll service over in-memory storage where "owner",
+// "admin" and "voter" joined the organization before opensAt and "late"
+// joined after it as a voter.
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	ctx := context.Background()
+
+	f := fixture{
+		ballots:     ballots.NewMemoryBallotRepository(),
+		memberships: memberships.NewMemoryMembershipRepository(),
+	}
+	f.service = rolls.NewRollService(rolls.NewMemoryRollRepository(), f.ballots, f.memberships)
+
+	join := func(userID string, role users.UserRole) {
+		t.Helper()
+		if _, err := f.memberships.CreateMembership(ctx, memberships.Membership{OrganizationID: "organization", UserID: userID, Role: role}); err != nil {
+			t.Fatalf("CreateMembership: %v", err)
+		}
+	}
+	join("owner", users.RoleOwner)
+	join("admin", users.RoleAdmin)
+	join("voter", users.RoleVoter)
+	time.Sleep(2 * time.Millisecond)
+	f.opensAt = time.Now()
+	time.Sleep(2 * time.Millisecond)
+	join("late", users.RoleVoter)
+
+	return f
+}
+
+// createBallot stores a ballot with the eligibility rules that opened at
+// opensAt, or opens an hour from now if scheduled.
+func (f fixture) createBallot(t *testing.T, eligibility ballots.Eligibility, scheduled bool) *ballots.Ballot {
+	t.Helper()
+
+	opensAt := f.opensAt
+	if scheduled {
+		opensAt = time.Now().Add(time.Hour)
+	}
+	ballot, err := f.ballots.CreateBallot(context.Background(), ballots.Ballot{
+		OrganizationID: "organization",
+		Title:          "Committee chair",
+		OpensAt:        opensAt,
+		ClosesAt:       opensAt.Add(2 * time.Hour),
+		Eligibility:    eligibility,
+	})
+	if err != nil {
+		t.Fatalf("CreateBallot: %v", err)
+	}
+	return ballot
+}
+
+func TestRoll(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		eligibility ballots.Eligibility
+		scheduled   bool
+		roll        []string
+	}{
+		{name: "AllMembers", eligibility: ballots.Eligibility{Mode: ballots.EligibilityAllMembers}, roll: []string{"admin", "owner", "voter"}},
+		{name: "Roles", eligibility: ballots.Eligibility{Mode: ballots.EligibilityRoles, Roles: []users.UserRole{users.RoleVoter, users.RoleAdmin}}, roll: []string{"admin", "voter"}},
+		{name: "List", eligibility: ballots.Eligibility{Mode: ballots.EligibilityList, UserIDs: []string{"voter", "late", "stranger"}}, roll: []string{"voter"}},
+		{name: "Scheduled", eligibility: ballots.Eligibility{Mode: ballots.EligibilityAllMembers}, scheduled: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newFixture(t)
+			ballot := f.createBallot(t, tc.eligibility, tc.scheduled)
+
+			onRoll := make(map[string]bool)
+			for _, userID := range tc.roll {
+				onRoll[userID] = true
+			}
+			for _, userID := range []string{"owner", "admin", "voter", "late", "stranger"} {
+				eligible, err := f.service.IsEligible(ctx, ballot, userID)
+				if err != nil || eligible != onRoll[userID] {
+					t.Errorf("IsEligible(%s) returned %t and error %v, want %t", userID, eligible, err, onRoll[userID])
+				}
+			}
+
+			// Scheduled ballots are snapshotted when they open, not before
+			if tc.scheduled {
+				if ballot.RollSnapshotAt != nil {
+					t.Error("a scheduled ballot's roll was snapshotted before it opened")
+				}
+				return
+			}
+
+			entries, err := f.service.ListEntries(ctx, ballot)
+			if err != nil {
+				t.Fatalf("ListEntries: %v", err)
+			}
+			var userIDs []string
+			for _, entry := range entries {
+				userIDs = append(userIDs, entry.UserID)
+			}
+			sort.Strings(userIDs)
+			if len(userIDs) != len(tc.roll) {
+				t.Fatalf("ListEntries returned %v, want %v", userIDs, tc.roll)
+			}
+			for i := range userIDs {
+				if userIDs[i] != tc.roll[i] {
+					t.Fatalf("ListEntries returned %v, want %v", userIDs, tc.roll)
+				}
+			}
+
+			stored, err := f.ballots.GetBallotByID(ctx, ballot.ID)
+			if err != nil || stored.RollSnapshotAt == nil {
+				t.Errorf("GetBallotByID returned %v and error %v, want the snapshot time recorded", stored, err)
+			}
+		})
+	}
+}
+
+func TestRollIsFrozen(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	ballot := f.createBallot(t, ballots.Eligibility{Mode: ballots.EligibilityRoles, Roles: []users.UserRole{users.RoleVoter}}, false)
+
+	if err := f.service.Snapshot(ctx, ballot); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	// Later membership changes don't affect the roll, nor does snapshotting again
+	if err := f.memberships.UpdateRole(ctx, "organization", "admin", users.RoleVoter); err != nil {
+		t.Fatalf("UpdateRole: %v", err)
+	}
+	if err := f.memberships.DeleteMembership(ctx, "organization", "voter"); err != nil {
+		t.Fatalf("DeleteMembership: %v", err)
+	}
+	if err := f.service.Snapshot(ctx, ballot); err != nil {
+		t.Fatalf("Snapshot again: %v", err)
+	}
+
+	for userID, want := range map[string]bool{"admin": false, "voter": true} {
+		if eligible, err := f.service.IsEligible(ctx, ballot, userID); err != nil || eligible != want {
+			t.Errorf("IsEligible(%s) returned %t and error %v, want %t", userID, eligible, err, want)
+		}
+	}
+}
+
+func TestTurnout(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		voted   int64
+		percent float64
+	}{
+		{name: "None", voted: 0, percent: 0},
+		{name: "Some", voted: 1, percent: 100.0 / 3},
+		{name: "All", voted: 3, percent: 100},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t)
+			ballot := f.createBallot(t, ballots.Eligibility{Mode: ballots.EligibilityAllMembers}, false)
+
+			turnout, err := f.service.Turnout(context.Background(), ballot, tc.voted)
+			if err != nil {
+				t.Fatalf("Turnout: %v", err)
+			}
+			if turnout.Eligible != 3 || turnout.Voted != tc.voted || math.Abs(turnout.Percent-tc.percent) > 1e-9 {
+				t.Errorf("Turnout returned %+v, want 3 eligible, %d voted, %v%%", turnout, tc.voted, tc.percent)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package rolls
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SQLRollRepository stores voter rolls in the voter_rolls table of a SQL
+// database.
+type SQLRollRepository struct {
+	db *sqldb.DB
+}
+
+func NewSQLRollRepository(db *sqldb.DB) *SQLRollRepository {
+	return &SQLRollRepository{
+		db: db,
+	}
+}
+
+const entryColumns = "id, ballot_id, user_id, role, created_at"
+
+func (r *SQLRollRepository) CreateEntries(ctx context.Context, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	err := r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, entry := range entries {
+			if entry.ID == "" {
+				entry.ID = primitive.NewObjectID().Hex()
+			}
+
+			q := r.db.Query()
+			values := q.Values(entry.ID, entry.BallotID, entry.UserID, entry.Role, now)
+			_, err := tx.ExecContext(ctx, "INSERT INTO voter_rolls ("+entryColumns+") VALUES "+values+" ON CONFLICT (ballot_id, user_id) DO NOTHING", q.Args...)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create voter roll: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLRollRepository) IsOnRoll(ctx context.Context, ballotID, userID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	var onRoll bool
+	query := "SELECT EXISTS (SELECT 1 FROM voter_rolls WHERE ballot_id = " + q.Arg(ballotID) + " AND user_id = " + q.Arg(userID) + ")"
+	if err := r.db.QueryRowContext(ctx, query, q.Args...).Scan(&onRoll); err != nil {
+		return false, fmt.Errorf("failed to check voter roll: %w", err)
+	}
+
+	return onRoll, nil
+}
+
+func (r *SQLRollRepository) ListEntries(ctx context.Context, ballotID string) ([]Entry, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	rows, err := r.db.QueryContext(ctx, "SELECT "+entryColumns+" FROM voter_rolls WHERE ballot_id = "+q.Arg(ballotID)+" ORDER BY user_id", q.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list voter roll: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var entry Entry
+		if err := rows.Scan(&entry.ID, &entry.BallotID, &entry.UserID, &entry.Role, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to decode voter roll: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list voter roll: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (r *SQLRollRepository) CountEntries(ctx context.Context, ballotID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	var count int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM voter_rolls WHERE ballot_id = "+q.Arg(ballotID), q.Args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count voter roll: %w", err)
+	}
+
+	return count, nil
+}
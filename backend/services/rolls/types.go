@@ -0,0 +1,33 @@
+package rolls
+
+import (
+	"context"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+)
+
+// Entry records that a user was eligible to vote on a ballot when its roll
+// was snapshotted.
+type Entry struct {
+	ID        string         `json:"id" bson:"_id,omitempty"`
+	BallotID  string         `json:"ballot_id" bson:"ballot_id"`
+	UserID    string         `json:"user_id" bson:"user_id"`
+	Role      users.UserRole `json:"role" bson:"role"`
+	CreatedAt time.Time      `json:"created_at" bson:"created_at"`
+}
+
+type Turnout struct {
+	BallotID string  `json:"ballot_id"`
+	Eligible int64   `json:"eligible"`
+	Voted    int64   `json:"voted"`
+	Percent  float64 `json:"percent"`
+}
+
+type RollRepository interface {
+	// CreateEntries adds entries to a roll, skipping users already on it.
+	CreateEntries(ctx context.Context, entries []Entry) error
+	IsOnRoll(ctx context.Context, ballotID, userID string) (bool, error)
+	ListEntries(ctx context.Context, ballotID string) ([]Entry, error)
+	CountEntries(ctx context.Context, ballotID string) (int64, error)
+}
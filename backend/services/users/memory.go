@@ -0,0 +1,318 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryUserRepository keeps users in memory, for tests and for running the
+// server without a database. It is safe for concurrent use.
+type MemoryUserRepository struct {
+	mu      sync.RWMutex
+	users   map[string]User
+	members MemberLister
+}
+
+// NewMemoryUserRepository creates an empty repository. members answers
+// filters by organization.
+func NewMemoryUserRepository(members MemberLister) *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:   make(map[string]User),
+		members: members,
+	}
+}
+
+func (r *MemoryUserRepository) CreateUser(ctx context.Context, user User) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	setSearchFields(&user)
+
+	if user.ID == "" {
+		user.ID = primitive.NewObjectID().Hex()
+	}
+
+	if _, ok := r.users[user.ID]; ok {
+		return nil, fmt.Errorf("failed to create user: duplicate ID %s", user.ID)
+	}
+	if r.emailTaken(user.EmailLower, "") {
+		return nil, apperr.Conflict("user with email %s already exists", user.Email)
+	}
+
+	r.users[user.ID] = user
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) GetUserByID(ctx context.Context, id string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, apperr.NotFound("user not found")
+	}
+
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Emails match regardless of case
+	emailLower := strings.ToLower(email)
+	for _, user := range r.users {
+		if user.EmailLower == emailLower {
+			return &user, nil
+		}
+	}
+
+	return nil, apperr.NotFound("user not found")
+}
+
+func (r *MemoryUserRepository) GetUsersByIDs(ctx context.Context, ids []string) ([]User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []User
+	for _, user := range r.users {
+		if contains(ids, user.ID) {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+func (r *MemoryUserRepository) UpdateUser(ctx context.Context, id string, user User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return apperr.NotFound("user not found")
+	}
+
+	user.UpdatedAt = time.Now()
+	user.ID = id
+	setSearchFields(&user)
+
+	if r.emailTaken(user.EmailLower, id) {
+		return apperr.Conflict("user with email %s already exists", user.Email)
+	}
+
+	r.users[id] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) PatchUser(ctx context.Context, id string, changes UserChanges) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return apperr.NotFound("user not found")
+	}
+
+	if changes.FirstName != nil {
+		user.FirstName = *changes.FirstName
+	}
+	if changes.LastName != nil {
+		user.LastName = *changes.LastName
+	}
+	if changes.Email != nil {
+		user.Email = *changes.Email
+		user.EmailVerifiedAt = nil
+	}
+	if changes.PasswordHash != nil {
+		now := time.Now()
+		user.Password = *changes.PasswordHash
+		user.PasswordChangedAt = &now
+	}
+	if changes.ProfilePicture != nil {
+		user.ProfilePicture = *changes.ProfilePicture
+	}
+	user.UpdatedAt = time.Now()
+	setSearchFields(&user)
+
+	if r.emailTaken(user.EmailLower, id) {
+		return apperr.Conflict("user with email %s already exists", user.Email)
+	}
+
+	r.users[id] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) UpdatePassword(ctx context.Context, id string, passwordHash string) error {
+	return r.update(id, func(user *User) {
+		now := time.Now()
+		user.Password = passwordHash
+		user.PasswordChangedAt = &now
+	})
+}
+
+func (r *MemoryUserRepository) MarkEmailVerified(ctx context.Context, id string, verifiedAt time.Time) error {
+	return r.update(id, func(user *User) {
+		user.EmailVerifiedAt = &verifiedAt
+	})
+}
+
+func (r *MemoryUserRepository) DeleteUser(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return apperr.NotFound("user not found")
+	}
+
+	delete(r.users, id)
+	return nil
+}
+
+func (r *MemoryUserRepository) ListUsers(ctx context.Context, filter UserFilter, page pagination.Page) ([]User, error) {
+	if _, ok := sortFields[page.Sort.Field]; !ok {
+		return nil, fmt.Errorf("cannot sort users by %s", page.Sort.Field)
+	}
+
+	users, err := r.matching(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.Slice(users, page, func(user User) (any, string) {
+		switch page.Sort.Field {
+		case "first_name":
+			return user.FirstNameLower, user.ID
+		case "last_name":
+			return user.LastNameLower, user.ID
+		case "email":
+			return user.EmailLower, user.ID
+		default:
+			return user.CreatedAt, user.ID
+		}
+	}), nil
+}
+
+func (r *MemoryUserRepository) CountUsers(ctx context.Context, filter UserFilter) (int64, error) {
+	users, err := r.matching(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(users)), nil
+}
+
+// update applies a change to a stored user and bumps its updated_at.
+func (r *MemoryUserRepository) update(id string, change func(*User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return apperr.NotFound("user not found")
+	}
+
+	change(&user)
+	user.UpdatedAt = time.Now()
+	r.users[id] = user
+	return nil
+}
+
+// emailTaken reports whether a user other than exceptID has the email, as
+// the unique index on email_lower would.
+func (r *MemoryUserRepository) emailTaken(emailLower, exceptID string) bool {
+	for _, user := range r.users {
+		if user.EmailLower == emailLower && user.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryUserRepository) matching(ctx context.Context, filter UserFilter) ([]User, error) {
+	var memberIDs []string
+	if filter.OrganizationID != "" {
+		var err error
+		memberIDs, err = r.members.ListMemberUserIDs(ctx, filter.OrganizationID, filter.Role)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []User
+	for _, user := range r.users {
+		if filter.OrganizationID != "" && !contains(memberIDs, user.ID) {
+			continue
+		}
+		if userMatches(user, filter) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// userMatches mirrors userQuery, apart from the organization, which matching
+// checks. Text search matches whole words of either name regardless of case,
+// without the stemming MongoDB applies.
+func userMatches(user User, filter UserFilter) bool {
+	if filter.Search != "" && !matchesWords(filter.Search, user.FirstName, user.LastName) {
+		return false
+	}
+	if filter.NamePrefix != "" {
+		prefix := strings.ToLower(filter.NamePrefix)
+		if !strings.HasPrefix(user.FirstNameLower, prefix) && !strings.HasPrefix(user.LastNameLower, prefix) {
+			return false
+		}
+	}
+	if filter.EmailPrefix != "" && !strings.HasPrefix(user.EmailLower, strings.ToLower(filter.EmailPrefix)) {
+		return false
+	}
+	if filter.CreatedAfter != nil && user.CreatedAt.Before(*filter.CreatedAfter) {
+		return false
+	}
+	if filter.CreatedBefore != nil && !user.CreatedAt.Before(*filter.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// matchesWords reports whether any word of the search appears among the
+// words of the fields.
+func matchesWords(search string, fields ...string) bool {
+	words := make(map[string]bool)
+	for _, field := range fields {
+		for _, word := range strings.Fields(strings.ToLower(field)) {
+			words[word] = true
+		}
+	}
+
+	for _, term := range strings.Fields(strings.ToLower(search)) {
+		if words[term] {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
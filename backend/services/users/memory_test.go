@@ -0,0 +1,22 @@
+package users_test
+
+import (
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/repotest"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+)
+
+func TestMemoryUserRepository(t *testing.T) {
+	repotest.TestUserRepository(t, func(t *testing.T) users.UserRepository {
+		return users.NewMemoryUserRepository(memberships.NewMemoryMembershipRepository())
+	})
+
+	t.Run("MemberFilter", func(t *testing.T) {
+		repotest.TestUserMemberFilter(t, func(t *testing.T) (users.UserRepository, memberships.MembershipRepository) {
+			membershipRepository := memberships.NewMemoryMembershipRepository()
+			return users.NewMemoryUserRepository(membershipRepository), membershipRepository
+		})
+	})
+}
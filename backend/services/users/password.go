@@ -0,0 +1,60 @@
+package users
+
+import (
+	"crypto/subtle"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const minPasswordLength = 6
+
+// maxPasswordLength is the longest password bcrypt hashes without silently
+// truncating the input.
+const maxPasswordLength = 72
+
+type PasswordHasher struct {
+	cost int
+}
+
+func NewPasswordHasher(cost int) *PasswordHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &PasswordHasher{
+		cost: cost,
+	}
+}
+
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// Verify reports whether password matches the stored hash. Values that are not
+// bcrypt hashes are treated as legacy plaintext passwords so accounts created
+// before hashing was introduced can still sign in and be upgraded.
+func (h *PasswordHasher) Verify(hash, password string) bool {
+	if !isBcryptHash(hash) {
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(password)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NeedsRehash reports whether the stored hash was produced with different
+// parameters than the hasher's current configuration.
+func (h *PasswordHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+	return cost != h.cost
+}
+
+func isBcryptHash(hash string) bool {
+	_, err := bcrypt.Cost([]byte(hash))
+	return err == nil
+}
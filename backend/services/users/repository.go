@@ -3,12 +3,15 @@ package users
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type MongoDBUserRepository struct {
@@ -21,13 +24,23 @@ func NewMongoDBUserRepository(collection *mongo.Collection) *MongoDBUserReposito
 	}
 }
 
-func (r *MongoDBUserRepository) CreateUser(ctx context.Context, user User) error {
+// sortFields maps the fields users can be sorted by to the stored fields that
+// back them. Names and emails sort case-insensitively.
+var sortFields = map[string]string{
+	"created_at": "created_at",
+	"first_name": "first_name_lower",
+	"last_name":  "last_name_lower",
+	"email":      "email_lower",
+}
+
+func (r *MongoDBUserRepository) CreateUser(ctx context.Context, user User) (*User, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	now := time.Now()
 	user.CreatedAt = now
 	user.UpdatedAt = now
+	setSearchFields(&user)
 
 	if user.ID == "" {
 		user.ID = primitive.NewObjectID().Hex()
@@ -35,10 +48,14 @@ func (r *MongoDBUserRepository) CreateUser(ctx context.Context, user User) error
 
 	_, err := r.collection.InsertOne(ctx, user)
 	if err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
+		// The unique email index catches signups racing past the service's check
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, apperr.Conflict("user with email %s already exists", user.Email)
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	return nil
+	return &user, nil
 }
 
 func (r *MongoDBUserRepository) GetUserByID(ctx context.Context, id string) (*User, error) {
@@ -46,12 +63,12 @@ func (r *MongoDBUserRepository) GetUserByID(ctx context.Context, id string) (*Us
 	defer cancel()
 
 	var user User
-	filter := bson.M{"id": id}
+	filter := bson.M{"_id": id}
 
 	err := r.collection.FindOne(ctx, filter).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperr.NotFound("user not found")
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -63,13 +80,14 @@ func (r *MongoDBUserRepository) GetUserByEmail(ctx context.Context, email string
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
+	// Emails match regardless of case
 	var user User
-	filter := bson.M{"email": email}
+	filter := bson.M{"email_lower": strings.ToLower(email)}
 
 	err := r.collection.FindOne(ctx, filter).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperr.NotFound("user not found")
 		}
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
@@ -83,17 +101,111 @@ func (r *MongoDBUserRepository) UpdateUser(ctx context.Context, id string, user
 
 	user.UpdatedAt = time.Now()
 	user.ID = id
+	setSearchFields(&user)
 
-	filter := bson.M{"id": id}
+	filter := bson.M{"_id": id}
 	update := bson.M{"$set": user}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return apperr.Conflict("user with email %s already exists", user.Email)
+		}
 		return fmt.Errorf("failed to update user: %w", err)
 	}
 
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("user not found")
+		return apperr.NotFound("user not found")
+	}
+
+	return nil
+}
+
+// PatchUser sets only the given fields, so concurrent changes to the others
+// are kept.
+func (r *MongoDBUserRepository) PatchUser(ctx context.Context, id string, changes UserChanges) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	set := bson.M{"updated_at": now}
+	if changes.FirstName != nil {
+		set["first_name"] = *changes.FirstName
+		set["first_name_lower"] = strings.ToLower(*changes.FirstName)
+	}
+	if changes.LastName != nil {
+		set["last_name"] = *changes.LastName
+		set["last_name_lower"] = strings.ToLower(*changes.LastName)
+	}
+	if changes.Email != nil {
+		set["email"] = *changes.Email
+		set["email_lower"] = strings.ToLower(*changes.Email)
+		set["email_verified_at"] = nil
+	}
+	if changes.PasswordHash != nil {
+		set["password"] = *changes.PasswordHash
+		set["password_changed_at"] = now
+	}
+	if changes.ProfilePicture != nil {
+		set["profile_picture"] = *changes.ProfilePicture
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return apperr.Conflict("user with email %s already exists", *changes.Email)
+		}
+		return fmt.Errorf("failed to patch user: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return apperr.NotFound("user not found")
+	}
+
+	return nil
+}
+
+func (r *MongoDBUserRepository) UpdatePassword(ctx context.Context, id string, passwordHash string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{
+		"password":            passwordHash,
+		"password_changed_at": now,
+		"updated_at":          now,
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return apperr.NotFound("user not found")
+	}
+
+	return nil
+}
+
+func (r *MongoDBUserRepository) MarkEmailVerified(ctx context.Context, id string, verifiedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{
+		"email_verified_at": verifiedAt,
+		"updated_at":        time.Now(),
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return apperr.NotFound("user not found")
 	}
 
 	return nil
@@ -111,27 +223,67 @@ func (r *MongoDBUserRepository) DeleteUser(ctx context.Context, id string) error
 	}
 
 	if result.DeletedCount == 0 {
-		return fmt.Errorf("user not found")
+		return apperr.NotFound("user not found")
 	}
 
 	return nil
 }
 
-func (r *MongoDBUserRepository) ListUsers(ctx context.Context, organizationID string, limit, offset int) ([]User, error) {
+func (r *MongoDBUserRepository) GetUsersByIDs(ctx context.Context, ids []string) ([]User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{}
-	if organizationID != "" {
-		filter["organization_id"] = organizationID
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
 	}
+	defer cursor.Close(ctx)
 
-	opts := options.Find().
-		SetLimit(int64(limit)).
-		SetSkip(int64(offset)).
-		SetSort(bson.M{"created_at": -1})
+	var users []User
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("failed to decode users: %w", err)
+	}
 
-	cursor, err := r.collection.Find(ctx, filter, opts)
+	return users, nil
+}
+
+func (r *MongoDBUserRepository) ListUsers(ctx context.Context, filter UserFilter, page pagination.Page) ([]User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	sortField, ok := sortFields[page.Sort.Field]
+	if !ok {
+		return nil, fmt.Errorf("cannot sort users by %s", page.Sort.Field)
+	}
+
+	query := pagination.MongoFilter(userQuery(filter), page, sortField)
+
+	var cursor *mongo.Cursor
+	var err error
+	if filter.OrganizationID == "" {
+		cursor, err = r.collection.Find(ctx, query, pagination.MongoFindOptions(page, sortField))
+	} else {
+		direction := 1
+		if page.Sort.Descending {
+			direction = -1
+		}
+		pipeline := bson.A{
+			bson.M{"$match": query},
+			bson.M{"$sort": bson.D{{Key: sortField, Value: direction}, {Key: "_id", Value: direction}}},
+		}
+		pipeline = append(pipeline, memberStages(filter)...)
+		if page.Offset > 0 {
+			pipeline = append(pipeline, bson.M{"$skip": page.Offset})
+		}
+		if page.Limit > 0 {
+			pipeline = append(pipeline, bson.M{"$limit": page.Limit})
+		}
+		cursor, err = r.collection.Aggregate(ctx, pipeline)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
@@ -145,19 +297,100 @@ func (r *MongoDBUserRepository) ListUsers(ctx context.Context, organizationID st
 	return users, nil
 }
 
-func (r *MongoDBUserRepository) CountUsers(ctx context.Context, organizationID string) (int64, error) {
+func (r *MongoDBUserRepository) CountUsers(ctx context.Context, filter UserFilter) (int64, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{}
-	if organizationID != "" {
-		filter["organization_id"] = organizationID
+	if filter.OrganizationID == "" {
+		count, err := r.collection.CountDocuments(ctx, userQuery(filter))
+		if err != nil {
+			return 0, fmt.Errorf("failed to count users: %w", err)
+		}
+		return count, nil
 	}
 
-	count, err := r.collection.CountDocuments(ctx, filter)
+	pipeline := append(bson.A{bson.M{"$match": userQuery(filter)}}, memberStages(filter)...)
+	pipeline = append(pipeline, bson.M{"$count": "count"})
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count users: %w", err)
 	}
+	defer cursor.Close(ctx)
+
+	// $count returns no document at all when nothing matched
+	var result struct {
+		Count int64 `bson:"count"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return 0, fmt.Errorf("failed to count users: %w", err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return result.Count, nil
+}
+
+// memberStages are the aggregation stages that keep only the users who
+// belong to filter.OrganizationID, holding filter.Role if it is set. They look
+// up the memberships collection of the same database, which is indexed by
+// organization and user.
+func memberStages(filter UserFilter) bson.A {
+	conditions := bson.A{
+		bson.M{"$eq": bson.A{"$organization_id", filter.OrganizationID}},
+		bson.M{"$eq": bson.A{"$user_id", "$$user_id"}},
+	}
+	if filter.Role != "" {
+		conditions = append(conditions, bson.M{"$eq": bson.A{"$role", filter.Role}})
+	}
+
+	return bson.A{
+		bson.M{"$lookup": bson.M{
+			"from":     "memberships",
+			"let":      bson.M{"user_id": "$_id"},
+			"pipeline": bson.A{bson.M{"$match": bson.M{"$expr": bson.M{"$and": conditions}}}, bson.M{"$limit": 1}},
+			"as":       "membership",
+		}},
+		bson.M{"$match": bson.M{"membership": bson.M{"$ne": bson.A{}}}},
+		bson.M{"$project": bson.M{"membership": 0}},
+	}
+}
+
+func setSearchFields(user *User) {
+	user.FirstNameLower = strings.ToLower(user.FirstName)
+	user.LastNameLower = strings.ToLower(user.LastName)
+	user.EmailLower = strings.ToLower(user.Email)
+}
+
+func userQuery(filter UserFilter) bson.M {
+	query := bson.M{}
+	if filter.Search != "" {
+		query["$text"] = bson.M{"$search": filter.Search}
+	}
+	// Anchored, case-sensitive regexes on the lowercased fields can use their indexes
+	if filter.NamePrefix != "" {
+		prefix := bson.M{"$regex": "^" + regexp.QuoteMeta(strings.ToLower(filter.NamePrefix))}
+		query["$or"] = bson.A{
+			bson.M{"first_name_lower": prefix},
+			bson.M{"last_name_lower": prefix},
+		}
+	}
+	if filter.EmailPrefix != "" {
+		query["email_lower"] = bson.M{"$regex": "^" + regexp.QuoteMeta(strings.ToLower(filter.EmailPrefix))}
+	}
+
+	created := bson.M{}
+	if filter.CreatedAfter != nil {
+		created["$gte"] = *filter.CreatedAfter
+	}
+	if filter.CreatedBefore != nil {
+		created["$lt"] = *filter.CreatedBefore
+	}
+	if len(created) > 0 {
+		query["created_at"] = created
+	}
 
-	return count, nil
+	return query
 }
@@ -0,0 +1,25 @@
+package users_test
+
+import (
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/repotest"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+)
+
+func TestMongoDBUserRepository(t *testing.T) {
+	client := repotest.MongoClient(t)
+
+	repotest.TestUserRepository(t, func(t *testing.T) users.UserRepository {
+		database := repotest.MongoDatabase(t, client)
+		return users.NewMongoDBUserRepository(database.Collection("users"))
+	})
+
+	t.Run("MemberFilter", func(t *testing.T) {
+		repotest.TestUserMemberFilter(t, func(t *testing.T) (users.UserRepository, memberships.MembershipRepository) {
+			database := repotest.MongoDatabase(t, client)
+			return users.NewMongoDBUserRepository(database.Collection("users")), memberships.NewMongoDBMembershipRepository(database.Collection("memberships"))
+		})
+	})
+}
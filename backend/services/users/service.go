@@ -2,43 +2,59 @@ package users
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"log"
 	"strings"
 	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/validation"
 )
 
+const maxNameLength = 100
+
 type UserService struct {
 	repository UserRepository
+	hasher     *PasswordHasher
 }
 
-func NewUserService(repository UserRepository) *UserService {
+func NewUserService(repository UserRepository, hasher *PasswordHasher) *UserService {
 	return &UserService{
 		repository: repository,
+		hasher:     hasher,
 	}
 }
 
-func (s *UserService) CreateUser(ctx context.Context, user CreateUserRequest) error {
+func (s *UserService) CreateUser(ctx context.Context, user CreateUserRequest) (*User, error) {
 	if err := s.validateCreateUserRequest(user); err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+		return nil, err
 	}
 
 	existingUser, err := s.repository.GetUserByEmail(ctx, user.Email)
-	if err == nil && existingUser != nil {
-		return fmt.Errorf("user with email %s already exists", user.Email)
+	if err != nil && !errors.Is(err, apperr.ErrNotFound) {
+		return nil, err
+	}
+	if existingUser != nil {
+		return nil, apperr.Conflict("user with email %s already exists", user.Email)
+	}
+
+	passwordHash, err := s.hasher.Hash(user.Password)
+	if err != nil {
+		return nil, err
 	}
 
 	return s.repository.CreateUser(ctx, User{
-		FirstName:      user.FirstName,
-		LastName:       user.LastName,
-		Email:          user.Email,
-		Password:       user.Password,
-		OrganizationID: user.OrganizationID,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		Password:  passwordHash,
 	})
 }
 
 func (s *UserService) GetUserByID(ctx context.Context, id string) (*User, error) {
 	if strings.TrimSpace(id) == "" {
-		return nil, fmt.Errorf("user ID cannot be empty")
+		return nil, apperr.Validation("id", "is required")
 	}
 
 	return s.repository.GetUserByID(ctx, id)
@@ -46,31 +62,89 @@ func (s *UserService) GetUserByID(ctx context.Context, id string) (*User, error)
 
 func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*User, error) {
 	if strings.TrimSpace(email) == "" {
-		return nil, fmt.Errorf("email cannot be empty")
+		return nil, apperr.Validation("email", "is required")
 	}
 
 	return s.repository.GetUserByEmail(ctx, email)
 }
 
-func (s *UserService) UpdateUser(ctx context.Context, id string, user User) error {
+// Authenticate verifies the email/password pair and returns the matching
+// user. Hashes created with outdated parameters are upgraded on success.
+func (s *UserService) Authenticate(ctx context.Context, email, password string) (*User, error) {
+	user, err := s.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if !s.hasher.Verify(user.Password, password) {
+		return nil, ErrInvalidCredentials
+	}
+
+	if s.hasher.NeedsRehash(user.Password) {
+		if passwordHash, err := s.hasher.Hash(password); err == nil {
+			if err := s.repository.UpdatePassword(ctx, user.ID, passwordHash); err != nil {
+				log.Printf("failed to rehash password for user %s: %v", user.ID, err)
+			} else {
+				user.Password = passwordHash
+			}
+		}
+	}
+
+	return user, nil
+}
+
+func (s *UserService) UpdateUser(ctx context.Context, id string, request UpdateUserRequest) error {
 	if strings.TrimSpace(id) == "" {
-		return fmt.Errorf("user ID cannot be empty")
+		return apperr.Validation("id", "is required")
 	}
 
-	if err := s.validateUser(user); err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+	if err := s.validateUpdateUserRequest(request); err != nil {
+		return err
 	}
 
 	existingUser, err := s.repository.GetUserByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("user not found: %w", err)
+		return err
+	}
+
+	if request.Email != existingUser.Email || request.Password != "" {
+		if err := s.checkCurrentPassword(existingUser, request.CurrentPassword); err != nil {
+			return err
+		}
+	}
+
+	if request.Email != existingUser.Email {
+		userWithEmail, err := s.repository.GetUserByEmail(ctx, request.Email)
+		if err != nil && !errors.Is(err, apperr.ErrNotFound) {
+			return err
+		}
+		if userWithEmail != nil && userWithEmail.ID != id {
+			return apperr.Conflict("user with email %s already exists", request.Email)
+		}
+	}
+
+	user := User{
+		FirstName:         request.FirstName,
+		LastName:          request.LastName,
+		Email:             request.Email,
+		Password:          existingUser.Password,
+		ProfilePicture:    request.ProfilePicture,
+		PasswordChangedAt: existingUser.PasswordChangedAt,
 	}
 
-	if user.Email != existingUser.Email {
-		userWithEmail, err := s.repository.GetUserByEmail(ctx, user.Email)
-		if err == nil && userWithEmail != nil {
-			return fmt.Errorf("user with email %s already exists", user.Email)
+	if request.Password != "" {
+		passwordHash, err := s.hasher.Hash(request.Password)
+		if err != nil {
+			return err
 		}
+		now := time.Now()
+		user.Password = passwordHash
+		user.PasswordChangedAt = &now
+	}
+
+	// A new address has to be verified again
+	if request.Email == existingUser.Email {
+		user.EmailVerifiedAt = existingUser.EmailVerifiedAt
 	}
 
 	user.CreatedAt = existingUser.CreatedAt
@@ -79,87 +153,199 @@ func (s *UserService) UpdateUser(ctx context.Context, id string, user User) erro
 	return s.repository.UpdateUser(ctx, id, user)
 }
 
-func (s *UserService) DeleteUser(ctx context.Context, id string) error {
+// PatchUser applies a merge patch to a user and returns the result. Fields
+// the patch leaves out keep their current values, and changing the email
+// means the new address has to be verified.
+func (s *UserService) PatchUser(ctx context.Context, id string, patch UserPatch) (*User, error) {
 	if strings.TrimSpace(id) == "" {
-		return fmt.Errorf("user ID cannot be empty")
+		return nil, apperr.Validation("id", "is required")
 	}
 
-	return s.repository.DeleteUser(ctx, id)
-}
+	if err := validateUserPatch(patch); err != nil {
+		return nil, err
+	}
 
-func (s *UserService) ListUsers(ctx context.Context, organizationID string, limit, offset int) ([]User, error) {
-	if limit <= 0 {
-		limit = 10
+	existingUser, err := s.repository.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if (patch.Email.Set && patch.Email.Value != existingUser.Email) || patch.Password.Set {
+		if err := s.checkCurrentPassword(existingUser, patch.CurrentPassword.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	var changes UserChanges
+	if patch.FirstName.Set {
+		changes.FirstName = &patch.FirstName.Value
 	}
-	if limit > 100 {
-		limit = 100
+	if patch.LastName.Set {
+		changes.LastName = &patch.LastName.Value
 	}
-	if offset < 0 {
-		offset = 0
+	if patch.Email.Set && patch.Email.Value != existingUser.Email {
+		userWithEmail, err := s.repository.GetUserByEmail(ctx, patch.Email.Value)
+		if err != nil && !errors.Is(err, apperr.ErrNotFound) {
+			return nil, err
+		}
+		if userWithEmail != nil && userWithEmail.ID != id {
+			return nil, apperr.Conflict("user with email %s already exists", patch.Email.Value)
+		}
+		changes.Email = &patch.Email.Value
+	}
+	if patch.Password.Set {
+		passwordHash, err := s.hasher.Hash(patch.Password.Value)
+		if err != nil {
+			return nil, err
+		}
+		changes.PasswordHash = &passwordHash
+	}
+	if patch.ProfilePicture.Set {
+		changes.ProfilePicture = &patch.ProfilePicture.Value
 	}
 
-	return s.repository.ListUsers(ctx, organizationID, limit, offset)
-}
+	if err := s.repository.PatchUser(ctx, id, changes); err != nil {
+		return nil, err
+	}
 
-func (s *UserService) CountUsers(ctx context.Context, organizationID string) (int64, error) {
-	return s.repository.CountUsers(ctx, organizationID)
+	return s.repository.GetUserByID(ctx, id)
 }
 
-func (s *UserService) validateUser(user User) error {
-	if strings.TrimSpace(user.FirstName) == "" {
-		return fmt.Errorf("first name is required")
+// checkCurrentPassword guards changes to the credentials of an account, so a
+// stolen session alone cannot take it over.
+func (s *UserService) checkCurrentPassword(user *User, password string) error {
+	if password == "" {
+		return apperr.Validation("current_password", "is required to change the email or password")
 	}
-	if strings.TrimSpace(user.LastName) == "" {
-		return fmt.Errorf("last name is required")
+	if !s.hasher.Verify(user.Password, password) {
+		return apperr.Validation("current_password", "is incorrect")
 	}
-	if strings.TrimSpace(user.Email) == "" {
-		return fmt.Errorf("email is required")
+	return nil
+}
+
+// SetPassword replaces a user's password without checking the current one.
+// Callers must have verified the user some other way, such as a reset token.
+func (s *UserService) SetPassword(ctx context.Context, id, password string) error {
+	if err := ValidatePassword(password); err != nil {
+		return err
 	}
-	if !isValidEmail(user.Email) {
-		return fmt.Errorf("invalid email format")
+
+	passwordHash, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
 	}
-	if strings.TrimSpace(user.Password) == "" {
-		return fmt.Errorf("password is required")
+
+	return s.repository.UpdatePassword(ctx, id, passwordHash)
+}
+
+func (s *UserService) MarkEmailVerified(ctx context.Context, id string) error {
+	return s.repository.MarkEmailVerified(ctx, id, time.Now())
+}
+
+func (s *UserService) DeleteUser(ctx context.Context, id string) error {
+	if strings.TrimSpace(id) == "" {
+		return apperr.Validation("id", "is required")
 	}
-	if len(user.Password) < 6 {
-		return fmt.Errorf("password must be at least 6 characters long")
+
+	return s.repository.DeleteUser(ctx, id)
+}
+
+func (s *UserService) ListUsers(ctx context.Context, filter UserFilter, page pagination.Page) (*pagination.List[User], error) {
+	page = page.Normalize(pagination.Sort{Field: "created_at", Descending: true})
+
+	users, err := s.repository.ListUsers(ctx, filter, page.Lookahead())
+	if err != nil {
+		return nil, err
 	}
-	if strings.TrimSpace(user.OrganizationID) == "" {
-		return fmt.Errorf("organization ID is required")
+
+	total, err := s.repository.CountUsers(ctx, filter)
+	if err != nil {
+		return nil, err
 	}
-	if strings.TrimSpace(string(user.Role)) == "" {
-		return fmt.Errorf("role is required")
+
+	users, nextCursor := pagination.NextPage(users, page, func(user User) (any, string) {
+		switch page.Sort.Field {
+		case "first_name":
+			return user.FirstNameLower, user.ID
+		case "last_name":
+			return user.LastNameLower, user.ID
+		case "email":
+			return user.EmailLower, user.ID
+		default:
+			return user.CreatedAt, user.ID
+		}
+	})
+	return &pagination.List[User]{
+		Items:      users,
+		NextCursor: nextCursor,
+		Total:      total,
+	}, nil
+}
+
+func (s *UserService) CountUsers(ctx context.Context) (int64, error) {
+	return s.repository.CountUsers(ctx, UserFilter{})
+}
+
+func (s *UserService) validateUpdateUserRequest(user UpdateUserRequest) error {
+	var v validation.Validator
+	validateProfile(&v, user.FirstName, user.LastName, user.Email)
+	if user.Password != "" {
+		validatePassword(&v, user.Password)
 	}
 
-	return nil
+	return v.Err()
 }
 
 func (s *UserService) validateCreateUserRequest(user CreateUserRequest) error {
-	if strings.TrimSpace(user.FirstName) == "" {
-		return fmt.Errorf("first name is required")
+	var v validation.Validator
+	validateProfile(&v, user.FirstName, user.LastName, user.Email)
+	validatePassword(&v, user.Password)
+
+	return v.Err()
+}
+
+// validateUserPatch checks the fields a patch sets. Null clears a field, so
+// it is rejected for required ones.
+func validateUserPatch(patch UserPatch) error {
+	var v validation.Validator
+	if patch.FirstName.Set && v.Required("first_name", patch.FirstName.Value) {
+		v.Length("first_name", patch.FirstName.Value, 0, maxNameLength)
 	}
-	if strings.TrimSpace(user.LastName) == "" {
-		return fmt.Errorf("last name is required")
+	if patch.LastName.Set && v.Required("last_name", patch.LastName.Value) {
+		v.Length("last_name", patch.LastName.Value, 0, maxNameLength)
 	}
-	if strings.TrimSpace(user.Email) == "" {
-		return fmt.Errorf("email is required")
+	if patch.Email.Set && v.Required("email", patch.Email.Value) {
+		v.Email("email", patch.Email.Value)
 	}
-	if !isValidEmail(user.Email) {
-		return fmt.Errorf("invalid email format")
+	if patch.Password.Set {
+		validatePassword(&v, patch.Password.Value)
 	}
-	if strings.TrimSpace(user.Password) == "" {
-		return fmt.Errorf("password is required")
+
+	return v.Err()
+}
+
+func validateProfile(v *validation.Validator, firstName, lastName, email string) {
+	if v.Required("first_name", firstName) {
+		v.Length("first_name", firstName, 0, maxNameLength)
 	}
-	if len(user.Password) < 6 {
-		return fmt.Errorf("password must be at least 6 characters long")
+	if v.Required("last_name", lastName) {
+		v.Length("last_name", lastName, 0, maxNameLength)
 	}
-	if strings.TrimSpace(user.OrganizationID) == "" {
-		return fmt.Errorf("organization ID is required")
+	if v.Required("email", email) {
+		v.Email("email", email)
 	}
+}
 
-	return nil
+// ValidatePassword checks a new password against the length requirements.
+func ValidatePassword(password string) error {
+	var v validation.Validator
+	validatePassword(&v, password)
+	return v.Err()
 }
 
-func isValidEmail(email string) bool {
-	return strings.Contains(email, "@") && strings.Contains(email, ".")
+func validatePassword(v *validation.Validator, password string) {
+	if v.Required("password", password) {
+		v.Length("password", password, minPasswordLength, 0)
+		v.MaxBytes("password", password, maxPasswordLength)
+	}
 }
@@ -0,0 +1,316 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SQLUserRepository stores users in the users table of a SQL database.
+type SQLUserRepository struct {
+	db *sqldb.DB
+}
+
+func NewSQLUserRepository(db *sqldb.DB) *SQLUserRepository {
+	return &SQLUserRepository{
+		db: db,
+	}
+}
+
+const userColumns = "id, first_name, last_name, email, password, profile_picture, first_name_lower, last_name_lower, email_lower, email_verified_at, password_changed_at, created_at, updated_at"
+
+func (r *SQLUserRepository) CreateUser(ctx context.Context, user User) (*User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	setSearchFields(&user)
+
+	if user.ID == "" {
+		user.ID = primitive.NewObjectID().Hex()
+	}
+
+	q := r.db.Query()
+	values := q.Values(user.ID, user.FirstName, user.LastName, user.Email, user.Password, user.ProfilePicture,
+		user.FirstNameLower, user.LastNameLower, user.EmailLower, user.EmailVerifiedAt, user.PasswordChangedAt, user.CreatedAt, user.UpdatedAt)
+	_, err := r.db.ExecContext(ctx, "INSERT INTO users ("+userColumns+") VALUES "+values, q.Args...)
+	if err != nil {
+		// The unique email index catches signups racing past the service's check
+		if r.db.IsUniqueViolation(err) {
+			return nil, apperr.Conflict("user with email %s already exists", user.Email)
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return &user, nil
+}
+
+func (r *SQLUserRepository) GetUserByID(ctx context.Context, id string) (*User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	user, err := scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = "+q.Arg(id), q.Args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperr.NotFound("user not found")
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *SQLUserRepository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Emails match regardless of case
+	q := r.db.Query()
+	user, err := scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE email_lower = "+q.Arg(strings.ToLower(email)), q.Args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperr.NotFound("user not found")
+		}
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *SQLUserRepository) UpdateUser(ctx context.Context, id string, user User) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	user.UpdatedAt = time.Now()
+	user.ID = id
+	setSearchFields(&user)
+
+	q := r.db.Query()
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET "+
+		"first_name = "+q.Arg(user.FirstName)+
+		", last_name = "+q.Arg(user.LastName)+
+		", email = "+q.Arg(user.Email)+
+		", password = "+q.Arg(user.Password)+
+		", profile_picture = "+q.Arg(user.ProfilePicture)+
+		", first_name_lower = "+q.Arg(user.FirstNameLower)+
+		", last_name_lower = "+q.Arg(user.LastNameLower)+
+		", email_lower = "+q.Arg(user.EmailLower)+
+		", email_verified_at = "+q.Arg(user.EmailVerifiedAt)+
+		", password_changed_at = "+q.Arg(user.PasswordChangedAt)+
+		", created_at = "+q.Arg(user.CreatedAt)+
+		", updated_at = "+q.Arg(user.UpdatedAt)+
+		" WHERE id = "+q.Arg(id), q.Args...)
+	if err != nil {
+		if r.db.IsUniqueViolation(err) {
+			return apperr.Conflict("user with email %s already exists", user.Email)
+		}
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return userUpdated(result)
+}
+
+// PatchUser sets only the given fields, so concurrent changes to the others
+// are kept.
+func (r *SQLUserRepository) PatchUser(ctx context.Context, id string, changes UserChanges) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	q := r.db.Query()
+	set := []string{"updated_at = " + q.Arg(now)}
+	if changes.FirstName != nil {
+		set = append(set, "first_name = "+q.Arg(*changes.FirstName), "first_name_lower = "+q.Arg(strings.ToLower(*changes.FirstName)))
+	}
+	if changes.LastName != nil {
+		set = append(set, "last_name = "+q.Arg(*changes.LastName), "last_name_lower = "+q.Arg(strings.ToLower(*changes.LastName)))
+	}
+	if changes.Email != nil {
+		set = append(set, "email = "+q.Arg(*changes.Email), "email_lower = "+q.Arg(strings.ToLower(*changes.Email)), "email_verified_at = NULL")
+	}
+	if changes.PasswordHash != nil {
+		set = append(set, "password = "+q.Arg(*changes.PasswordHash), "password_changed_at = "+q.Arg(now))
+	}
+	if changes.ProfilePicture != nil {
+		set = append(set, "profile_picture = "+q.Arg(*changes.ProfilePicture))
+	}
+
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET "+strings.Join(set, ", ")+" WHERE id = "+q.Arg(id), q.Args...)
+	if err != nil {
+		if r.db.IsUniqueViolation(err) {
+			return apperr.Conflict("user with email %s already exists", *changes.Email)
+		}
+		return fmt.Errorf("failed to patch user: %w", err)
+	}
+
+	return userUpdated(result)
+}
+
+func (r *SQLUserRepository) UpdatePassword(ctx context.Context, id string, passwordHash string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	q := r.db.Query()
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET password = "+q.Arg(passwordHash)+", password_changed_at = "+q.Arg(now)+", updated_at = "+q.Arg(now)+" WHERE id = "+q.Arg(id), q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	return userUpdated(result)
+}
+
+func (r *SQLUserRepository) MarkEmailVerified(ctx context.Context, id string, verifiedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET email_verified_at = "+q.Arg(verifiedAt)+", updated_at = "+q.Arg(time.Now())+" WHERE id = "+q.Arg(id), q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	return userUpdated(result)
+}
+
+func (r *SQLUserRepository) DeleteUser(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	result, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = "+q.Arg(id), q.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	return userUpdated(result)
+}
+
+func (r *SQLUserRepository) GetUsersByIDs(ctx context.Context, ids []string) ([]User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	return r.queryUsers(ctx, "SELECT "+userColumns+" FROM users WHERE "+q.In("id", ids), q.Args)
+}
+
+func (r *SQLUserRepository) ListUsers(ctx context.Context, filter UserFilter, page pagination.Page) ([]User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	sortColumn, ok := sortFields[page.Sort.Field]
+	if !ok {
+		return nil, fmt.Errorf("cannot sort users by %s", page.Sort.Field)
+	}
+
+	q := r.db.Query()
+	conditions := userConditions(q, filter)
+	if after := pagination.SQLAfter(q, page, sortColumn); after != "" {
+		conditions = append(conditions, after)
+	}
+
+	query := "SELECT " + userColumns + " FROM users" + sqldb.Where(conditions) + pagination.SQLOrderBy(q, page, sortColumn)
+	return r.queryUsers(ctx, query, q.Args)
+}
+
+func (r *SQLUserRepository) CountUsers(ctx context.Context, filter UserFilter) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	var count int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+sqldb.Where(userConditions(q, filter)), q.Args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *SQLUserRepository) queryUsers(ctx context.Context, query string, args []any) ([]User, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode users: %w", err)
+		}
+		users = append(users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return users, nil
+}
+
+func scanUser(row sqldb.Scanner) (*User, error) {
+	var user User
+	err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.ProfilePicture,
+		&user.FirstNameLower, &user.LastNameLower, &user.EmailLower, &user.EmailVerifiedAt, &user.PasswordChangedAt, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// userUpdated reports a user as not found if the statement matched no rows.
+func userUpdated(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read rows affected: %w", err)
+	}
+	if affected == 0 {
+		return apperr.NotFound("user not found")
+	}
+	return nil
+}
+
+// userConditions mirrors userQuery.
+func userConditions(q *sqldb.Query, filter UserFilter) []string {
+	var conditions []string
+	if filter.OrganizationID != "" {
+		members := "SELECT user_id FROM memberships WHERE organization_id = " + q.Arg(filter.OrganizationID)
+		if filter.Role != "" {
+			members += " AND role = " + q.Arg(string(filter.Role))
+		}
+		conditions = append(conditions, "id IN ("+members+")")
+	}
+	if filter.Search != "" {
+		conditions = append(conditions, q.MatchesAnyWord("first_name || ' ' || last_name", filter.Search))
+	}
+	if filter.NamePrefix != "" {
+		prefix := strings.ToLower(filter.NamePrefix)
+		conditions = append(conditions, "("+q.HasPrefix("first_name_lower", prefix)+" OR "+q.HasPrefix("last_name_lower", prefix)+")")
+	}
+	if filter.EmailPrefix != "" {
+		conditions = append(conditions, q.HasPrefix("email_lower", strings.ToLower(filter.EmailPrefix)))
+	}
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, "created_at >= "+q.Arg(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, "created_at < "+q.Arg(*filter.CreatedBefore))
+	}
+	return conditions
+}
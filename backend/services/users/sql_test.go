@@ -0,0 +1,37 @@
+package users_test
+
+import (
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/repotest"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+)
+
+func TestPostgresUserRepository(t *testing.T) {
+	serverURL := repotest.PostgresURL(t)
+
+	repotest.TestUserRepository(t, func(t *testing.T) users.UserRepository {
+		return users.NewSQLUserRepository(repotest.PostgresDB(t, serverURL))
+	})
+
+	t.Run("MemberFilter", func(t *testing.T) {
+		repotest.TestUserMemberFilter(t, func(t *testing.T) (users.UserRepository, memberships.MembershipRepository) {
+			db := repotest.PostgresDB(t, serverURL)
+			return users.NewSQLUserRepository(db), memberships.NewSQLMembershipRepository(db)
+		})
+	})
+}
+
+func TestSQLiteUserRepository(t *testing.T) {
+	repotest.TestUserRepository(t, func(t *testing.T) users.UserRepository {
+		return users.NewSQLUserRepository(repotest.SQLiteDB(t))
+	})
+
+	t.Run("MemberFilter", func(t *testing.T) {
+		repotest.TestUserMemberFilter(t, func(t *testing.T) (users.UserRepository, memberships.MembershipRepository) {
+			db := repotest.SQLiteDB(t)
+			return users.NewSQLUserRepository(db), memberships.NewSQLMembershipRepository(db)
+		})
+	})
+}
@@ -2,38 +2,130 @@ package users
 
 import (
 	"context"
+	"errors"
 	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/mergepatch"
+	"github.com/bpalazzi512/easy-ballot/backend/pagination"
 )
 
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
 type UserRole string
 
+const (
+	RoleOwner    UserRole = "owner"
+	RoleAdmin    UserRole = "admin"
+	RoleOfficer  UserRole = "election_officer"
+	RoleVoter    UserRole = "voter"
+	RoleObserver UserRole = "observer"
+)
+
+// Roles lists every role a user can hold within an organization, from most to
+// least privileged.
+var Roles = []UserRole{RoleOwner, RoleAdmin, RoleOfficer, RoleVoter, RoleObserver}
+
+func (r UserRole) IsValid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 type User struct {
-	ID             string    `json:"id" bson:"_id,omitempty"`
-	FirstName      string    `json:"first_name" bson:"first_name"`
-	LastName       string    `json:"last_name" bson:"last_name"`
-	Email          string    `json:"email" bson:"email"`
-	Password       string    `json:"password" bson:"password"`
-	OrganizationID string    `json:"organization_id" bson:"organization_id"`
-	ProfilePicture string    `json:"profile_picture" bson:"profile_picture"`
-	Role           UserRole  `json:"role" bson:"role"`
-	CreatedAt      time.Time `json:"created_at" bson:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at" bson:"updated_at"`
+	ID              string     `json:"id" bson:"_id,omitempty"`
+	FirstName       string     `json:"first_name" bson:"first_name"`
+	LastName        string     `json:"last_name" bson:"last_name"`
+	Email           string     `json:"email" bson:"email"`
+	Password        string     `json:"-" bson:"password"`
+	ProfilePicture  string     `json:"profile_picture" bson:"profile_picture"`
+	FirstNameLower  string     `json:"-" bson:"first_name_lower"`
+	LastNameLower   string     `json:"-" bson:"last_name_lower"`
+	EmailLower      string     `json:"-" bson:"email_lower"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" bson:"email_verified_at"`
+	// PasswordChangedAt is set whenever the password changes, revoking every
+	// token issued before it.
+	PasswordChangedAt *time.Time `json:"-" bson:"password_changed_at"`
+	CreatedAt         time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" bson:"updated_at"`
 }
 
 type CreateUserRequest struct {
-	FirstName      string `json:"first_name"`
-	LastName       string `json:"last_name"`
-	Email          string `json:"email"`
-	Password       string `json:"password"`
-	OrganizationID string `json:"organization_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+}
+
+// UpdateUserRequest replaces a user's profile. CurrentPassword is required
+// when the email or password changes.
+type UpdateUserRequest struct {
+	FirstName       string `json:"first_name"`
+	LastName        string `json:"last_name"`
+	Email           string `json:"email"`
+	Password        string `json:"password"`
+	CurrentPassword string `json:"current_password"`
+	ProfilePicture  string `json:"profile_picture"`
+}
+
+// UserPatch is a JSON Merge Patch of a user: members it leaves out are
+// unchanged. Roles belong to memberships and are changed there.
+// CurrentPassword is not stored; it is required when the patch changes the
+// email or password.
+type UserPatch struct {
+	FirstName       mergepatch.Field[string] `json:"first_name"`
+	LastName        mergepatch.Field[string] `json:"last_name"`
+	Email           mergepatch.Field[string] `json:"email"`
+	Password        mergepatch.Field[string] `json:"password"`
+	CurrentPassword mergepatch.Field[string] `json:"current_password"`
+	ProfilePicture  mergepatch.Field[string] `json:"profile_picture"`
+}
+
+// UserChanges lists the fields PatchUser stores. Nil fields are left alone,
+// a new Email clears the verification of the previous address, and a new
+// PasswordHash sets PasswordChangedAt.
+type UserChanges struct {
+	FirstName      *string
+	LastName       *string
+	Email          *string
+	PasswordHash   *string
+	ProfilePicture *string
+}
+
+// UserFilter narrows a list of users. Zero fields match everything.
+type UserFilter struct {
+	// OrganizationID limits the list to members of the organization, and
+	// Role further to members holding that role
+	OrganizationID string
+	Role           UserRole
+	// Search matches whole words of first and last names using the text index
+	Search        string
+	NamePrefix    string
+	EmailPrefix   string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// MemberLister lists the IDs of an organization's members, limited to one
+// role unless role is empty. In-memory storage filters users by organization
+// with it, where the database backends join the memberships instead.
+type MemberLister interface {
+	ListMemberUserIDs(ctx context.Context, organizationID string, role UserRole) ([]string, error)
 }
 
 type UserRepository interface {
-	CreateUser(ctx context.Context, user User) error
+	CreateUser(ctx context.Context, user User) (*User, error)
 	GetUserByID(ctx context.Context, id string) (*User, error)
 	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	GetUsersByIDs(ctx context.Context, ids []string) ([]User, error)
 	UpdateUser(ctx context.Context, id string, user User) error
+	PatchUser(ctx context.Context, id string, changes UserChanges) error
+	// UpdatePassword replaces the password hash and sets PasswordChangedAt.
+	UpdatePassword(ctx context.Context, id string, passwordHash string) error
+	MarkEmailVerified(ctx context.Context, id string, verifiedAt time.Time) error
 	DeleteUser(ctx context.Context, id string) error
-	ListUsers(ctx context.Context, organizationID string, limit, offset int) ([]User, error)
-	CountUsers(ctx context.Context, organizationID string) (int64, error)
+	ListUsers(ctx context.Context, filter UserFilter, page pagination.Page) ([]User, error)
+	CountUsers(ctx context.Context, filter UserFilter) (int64, error)
 }
@@ -0,0 +1,196 @@
+package votes
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ballotBoxBuckets is the number of documents each secret ballot's votes are
+// spread across, keeping every document well below Mongo's size limit.
+const ballotBoxBuckets = 16
+
+// MongoDBBallotBoxRepository keeps secret ballots in two collections. The
+// participation collection knows who voted; the ballot box holds the votes
+// with random IDs, day-granular timestamps and no voter ID. Votes are inserted
+// at a random position inside bucket documents rather than as documents of
+// their own, so the box's natural order reveals nothing about when each vote
+// was cast.
+type MongoDBBallotBoxRepository struct {
+	participations *mongo.Collection
+	box            *mongo.Collection
+}
+
+func NewMongoDBBallotBoxRepository(participations, box *mongo.Collection) *MongoDBBallotBoxRepository {
+	return &MongoDBBallotBoxRepository{
+		participations: participations,
+		box:            box,
+	}
+}
+
+type ballotBoxBucket struct {
+	ID       string `bson:"_id"`
+	BallotID string `bson:"ballot_id"`
+	Votes    []Vote `bson:"votes"`
+}
+
+func (r *MongoDBBallotBoxRepository) CastSecretVote(ctx context.Context, participation Participation, vote Vote) (*Vote, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	castOn := coarseTime(time.Now())
+
+	participationID, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	participation.ID = participationID
+	participation.CreatedAt = castOn
+
+	voteID, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	vote.ID = voteID
+	vote.VoterID = ""
+	vote.CreatedAt = castOn
+
+	// The unique ballot/voter index on participations enforces one vote per
+	// voter before anything is added to the box.
+	if _, err := r.participations.InsertOne(ctx, participation); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrAlreadyVoted
+		}
+		return nil, fmt.Errorf("failed to record participation: %w", err)
+	}
+
+	if err := r.insertIntoBox(ctx, vote); err != nil {
+		// Without a vote in the box the voter must be free to try again
+		if _, deleteErr := r.participations.DeleteOne(ctx, bson.M{"_id": participation.ID}); deleteErr != nil {
+			return nil, fmt.Errorf("failed to store vote: %w (and failed to roll back participation: %v)", err, deleteErr)
+		}
+		return nil, fmt.Errorf("failed to store vote: %w", err)
+	}
+
+	return &vote, nil
+}
+
+// insertIntoBox splices the vote into a random bucket at a random position.
+// The position is picked by the server inside a single atomic update, so
+// concurrent votes cannot fall back to insertion order.
+func (r *MongoDBBallotBoxRepository) insertIntoBox(ctx context.Context, vote Vote) error {
+	bucket, err := rand.Int(rand.Reader, big.NewInt(ballotBoxBuckets))
+	if err != nil {
+		return fmt.Errorf("failed to pick ballot box bucket: %w", err)
+	}
+
+	votes := bson.M{"$ifNull": bson.A{"$votes", bson.A{}}}
+	position := bson.M{"$toInt": bson.M{"$floor": bson.M{"$multiply": bson.A{
+		bson.M{"$rand": bson.M{}},
+		bson.M{"$add": bson.A{bson.M{"$size": votes}, 1}},
+	}}}}
+
+	filter := bson.M{"_id": fmt.Sprintf("%s:%d", vote.BallotID, bucket.Int64())}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"ballot_id": bson.M{"$literal": vote.BallotID},
+			"votes": bson.M{"$let": bson.M{
+				"vars": bson.M{"votes": votes, "position": position},
+				"in": bson.M{"$concatArrays": bson.A{
+					bson.M{"$slice": bson.A{"$$votes", "$$position"}},
+					bson.A{bson.M{"$literal": vote}},
+					bson.M{"$slice": bson.A{"$$votes", bson.M{"$subtract": bson.A{"$$position", bson.M{"$size": "$$votes"}}}}},
+				}},
+			}},
+		}}},
+	}
+
+	_, err = r.box.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *MongoDBBallotBoxRepository) HasParticipated(ctx context.Context, ballotID, voterID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"ballot_id": ballotID, "voter_id": voterID}
+
+	count, err := r.participations.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("failed to check participation: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *MongoDBBallotBoxRepository) HasReceipt(ctx context.Context, ballotID, receipt string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"ballot_id": ballotID, "votes.receipt": receipt}
+
+	count, err := r.box.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("failed to check receipt: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *MongoDBBallotBoxRepository) ListVotes(ctx context.Context, ballotID string) ([]Vote, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.box.Find(ctx, bson.M{"ballot_id": ballotID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list votes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var buckets []ballotBoxBucket
+	if err = cursor.All(ctx, &buckets); err != nil {
+		return nil, fmt.Errorf("failed to decode votes: %w", err)
+	}
+
+	var votes []Vote
+	for _, bucket := range buckets {
+		votes = append(votes, bucket.Votes...)
+	}
+
+	return votes, nil
+}
+
+func (r *MongoDBBallotBoxRepository) CountVotes(ctx context.Context, ballotID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	count, err := r.participations.CountDocuments(ctx, bson.M{"ballot_id": ballotID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count votes: %w", err)
+	}
+
+	return count, nil
+}
+
+// coarseTime truncates a timestamp to the UTC day so stored times cannot be
+// used to line up participations with votes.
+func coarseTime(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// randomID returns an identifier with no embedded timestamp or counter, unlike
+// Mongo ObjectIDs.
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
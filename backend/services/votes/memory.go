@@ -0,0 +1,209 @@
+package votes
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryVoteRepository keeps votes on open ballots in memory, for tests and
+// for running the server without a database. It is safe for concurrent use.
+type MemoryVoteRepository struct {
+	mu    sync.RWMutex
+	votes []Vote
+}
+
+func NewMemoryVoteRepository() *MemoryVoteRepository {
+	return &MemoryVoteRepository{}
+}
+
+func (r *MemoryVoteRepository) CreateVote(ctx context.Context, vote Vote) (*Vote, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.votes {
+		if existing.BallotID == vote.BallotID && existing.VoterID == vote.VoterID {
+			return nil, ErrAlreadyVoted
+		}
+	}
+
+	vote.CreatedAt = time.Now()
+
+	if vote.ID == "" {
+		vote.ID = primitive.NewObjectID().Hex()
+	}
+
+	r.votes = append(r.votes, cloneVote(vote))
+	return &vote, nil
+}
+
+func (r *MemoryVoteRepository) HasVoted(ctx context.Context, ballotID, voterID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, vote := range r.votes {
+		if vote.BallotID == ballotID && vote.VoterID == voterID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryVoteRepository) HasReceipt(ctx context.Context, ballotID, receipt string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return hasReceipt(r.votes, ballotID, receipt), nil
+}
+
+// ListVotes returns the ballot's votes in the order they were cast.
+func (r *MemoryVoteRepository) ListVotes(ctx context.Context, ballotID string) ([]Vote, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return votesOnBallot(r.votes, ballotID), nil
+}
+
+func (r *MemoryVoteRepository) CountVotes(ctx context.Context, ballotID string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return int64(len(votesOnBallot(r.votes, ballotID))), nil
+}
+
+// MemoryBallotBoxRepository keeps secret ballots in memory. As with the Mongo
+// implementation, participations and votes are held apart and each vote is
+// inserted at a random position, so the box's order reveals nothing about
+// when a vote was cast.
+type MemoryBallotBoxRepository struct {
+	mu             sync.RWMutex
+	participations []Participation
+	box            []Vote
+}
+
+func NewMemoryBallotBoxRepository() *MemoryBallotBoxRepository {
+	return &MemoryBallotBoxRepository{}
+}
+
+func (r *MemoryBallotBoxRepository) CastSecretVote(ctx context.Context, participation Participation, vote Vote) (*Vote, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.participations {
+		if existing.BallotID == participation.BallotID && existing.VoterID == participation.VoterID {
+			return nil, ErrAlreadyVoted
+		}
+	}
+
+	castOn := coarseTime(time.Now())
+
+	participationID, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	participation.ID = participationID
+	participation.CreatedAt = castOn
+
+	voteID, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	vote.ID = voteID
+	vote.VoterID = ""
+	vote.CreatedAt = castOn
+
+	position, err := rand.Int(rand.Reader, big.NewInt(int64(len(r.box)+1)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to pick ballot box position: %w", err)
+	}
+	i := int(position.Int64())
+
+	r.participations = append(r.participations, participation)
+	r.box = append(r.box, Vote{})
+	copy(r.box[i+1:], r.box[i:])
+	r.box[i] = cloneVote(vote)
+
+	return &vote, nil
+}
+
+func (r *MemoryBallotBoxRepository) HasParticipated(ctx context.Context, ballotID, voterID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, participation := range r.participations {
+		if participation.BallotID == ballotID && participation.VoterID == voterID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryBallotBoxRepository) HasReceipt(ctx context.Context, ballotID, receipt string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return hasReceipt(r.box, ballotID, receipt), nil
+}
+
+func (r *MemoryBallotBoxRepository) ListVotes(ctx context.Context, ballotID string) ([]Vote, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return votesOnBallot(r.box, ballotID), nil
+}
+
+func (r *MemoryBallotBoxRepository) CountVotes(ctx context.Context, ballotID string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, participation := range r.participations {
+		if participation.BallotID == ballotID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func hasReceipt(votes []Vote, ballotID, receipt string) bool {
+	for _, vote := range votes {
+		if vote.BallotID == ballotID && vote.Receipt == receipt {
+			return true
+		}
+	}
+	return false
+}
+
+func votesOnBallot(votes []Vote, ballotID string) []Vote {
+	var matched []Vote
+	for _, vote := range votes {
+		if vote.BallotID == ballotID {
+			matched = append(matched, cloneVote(vote))
+		}
+	}
+	return matched
+}
+
+// cloneVote copies a vote's selections so stored votes can't be changed
+// through the copies handed out.
+func cloneVote(vote Vote) Vote {
+	selections := make([]Selection, len(vote.Selections))
+	for i, selection := range vote.Selections {
+		selection.OptionIDs = append(selection.OptionIDs[:0:0], selection.OptionIDs...)
+		if selection.Scores != nil {
+			scores := make(map[string]int, len(selection.Scores))
+			for option, score := range selection.Scores {
+				scores[option] = score
+			}
+			selection.Scores = scores
+		}
+		selections[i] = selection
+	}
+	vote.Selections = selections
+	return vote
+}
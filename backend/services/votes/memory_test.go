@@ -0,0 +1,14 @@
+package votes_test
+
+import (
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/repotest"
+	"github.com/bpalazzi512/easy-ballot/backend/services/votes"
+)
+
+func TestMemoryBallotBoxRepository(t *testing.T) {
+	repotest.TestBallotBoxRepository(t, func(t *testing.T) votes.BallotBoxRepository {
+		return votes.NewMemoryBallotBoxRepository()
+	})
+}
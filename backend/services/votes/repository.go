@@ -0,0 +1,104 @@
+package votes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoDBVoteRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoDBVoteRepository(collection *mongo.Collection) *MongoDBVoteRepository {
+	return &MongoDBVoteRepository{
+		collection: collection,
+	}
+}
+
+func (r *MongoDBVoteRepository) CreateVote(ctx context.Context, vote Vote) (*Vote, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	vote.CreatedAt = time.Now()
+
+	if vote.ID == "" {
+		vote.ID = primitive.NewObjectID().Hex()
+	}
+
+	_, err := r.collection.InsertOne(ctx, vote)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrAlreadyVoted
+		}
+		return nil, fmt.Errorf("failed to create vote: %w", err)
+	}
+
+	return &vote, nil
+}
+
+func (r *MongoDBVoteRepository) HasVoted(ctx context.Context, ballotID, voterID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"ballot_id": ballotID, "voter_id": voterID}
+
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("failed to check vote: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *MongoDBVoteRepository) HasReceipt(ctx context.Context, ballotID, receipt string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"ballot_id": ballotID, "receipt": receipt}
+
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("failed to check receipt: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *MongoDBVoteRepository) ListVotes(ctx context.Context, ballotID string) ([]Vote, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"ballot_id": ballotID}
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list votes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var votes []Vote
+	if err = cursor.All(ctx, &votes); err != nil {
+		return nil, fmt.Errorf("failed to decode votes: %w", err)
+	}
+
+	return votes, nil
+}
+
+func (r *MongoDBVoteRepository) CountVotes(ctx context.Context, ballotID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"ballot_id": ballotID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count votes: %w", err)
+	}
+
+	return count, nil
+}
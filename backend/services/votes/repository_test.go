@@ -0,0 +1,17 @@
+package votes_test
+
+import (
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/repotest"
+	"github.com/bpalazzi512/easy-ballot/backend/services/votes"
+)
+
+func TestMongoDBBallotBoxRepository(t *testing.T) {
+	client := repotest.MongoClient(t)
+
+	repotest.TestBallotBoxRepository(t, func(t *testing.T) votes.BallotBoxRepository {
+		database := repotest.MongoDatabase(t, client)
+		return votes.NewMongoDBBallotBoxRepository(database.Collection("participations"), database.Collection("ballot_box"))
+	})
+}
@@ -0,0 +1,268 @@
+package votes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/rolls"
+	"github.com/bpalazzi512/easy-ballot/backend/tally"
+	"github.com/bpalazzi512/easy-ballot/backend/validation"
+)
+
+type VoteService struct {
+	repository       VoteRepository
+	ballotBox        BallotBoxRepository
+	ballotRepository ballots.BallotRepository
+	rollService      *rolls.RollService
+}
+
+func NewVoteService(repository VoteRepository, ballotBox BallotBoxRepository, ballotRepository ballots.BallotRepository, rollService *rolls.RollService) *VoteService {
+	return &VoteService{
+		repository:       repository,
+		ballotBox:        ballotBox,
+		ballotRepository: ballotRepository,
+		rollService:      rollService,
+	}
+}
+
+func (s *VoteService) CastVote(ctx context.Context, ballotID, voterID string, vote CastVoteRequest) (*Vote, error) {
+	if strings.TrimSpace(ballotID) == "" {
+		return nil, apperr.Validation("id", "is required")
+	}
+	if strings.TrimSpace(voterID) == "" {
+		return nil, apperr.Validation("voter_id", "is required")
+	}
+
+	ballot, err := s.ballotRepository.GetBallotByID(ctx, ballotID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ballot.IsOpen(time.Now()) {
+		return nil, ErrBallotNotOpen
+	}
+
+	eligible, err := s.rollService.IsEligible(ctx, ballot, voterID)
+	if err != nil {
+		return nil, err
+	}
+	if !eligible {
+		return nil, ErrNotEligible
+	}
+
+	if err := validateSelections(*ballot, vote.Selections); err != nil {
+		return nil, err
+	}
+
+	newVote := Vote{
+		BallotID:   ballot.ID,
+		Selections: vote.Selections,
+	}
+	if err := sealReceipt(&newVote); err != nil {
+		return nil, err
+	}
+
+	// Secret ballots keep who voted apart from what they voted for
+	if ballot.Secret {
+		return s.ballotBox.CastSecretVote(ctx, Participation{
+			BallotID: ballot.ID,
+			VoterID:  voterID,
+		}, newVote)
+	}
+
+	// The unique ballot/voter index is the source of truth for one vote per
+	// voter; a duplicate insert surfaces as ErrAlreadyVoted.
+	newVote.VoterID = voterID
+	return s.repository.CreateVote(ctx, newVote)
+}
+
+func (s *VoteService) HasVoted(ctx context.Context, ballotID, voterID string) (bool, error) {
+	ballot, err := s.ballotRepository.GetBallotByID(ctx, ballotID)
+	if err != nil {
+		return false, err
+	}
+
+	if ballot.Secret {
+		return s.ballotBox.HasParticipated(ctx, ballot.ID, voterID)
+	}
+	return s.repository.HasVoted(ctx, ballot.ID, voterID)
+}
+
+func (s *VoteService) CountVotes(ctx context.Context, ballotID string) (int64, error) {
+	ballot, err := s.ballotRepository.GetBallotByID(ctx, ballotID)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.countVotes(ctx, ballot)
+}
+
+// Turnout reports how many voters on the ballot's roll have voted.
+func (s *VoteService) Turnout(ctx context.Context, ballotID string) (*rolls.Turnout, error) {
+	ballot, err := s.ballotRepository.GetBallotByID(ctx, ballotID)
+	if err != nil {
+		return nil, err
+	}
+
+	voted, err := s.countVotes(ctx, ballot)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.rollService.Turnout(ctx, ballot, voted)
+}
+
+// Results tallies every question on a closed ballot.
+func (s *VoteService) Results(ctx context.Context, ballotID string) (*BallotResults, error) {
+	if strings.TrimSpace(ballotID) == "" {
+		return nil, apperr.Validation("id", "is required")
+	}
+
+	ballot, err := s.ballotRepository.GetBallotByID(ctx, ballotID)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().Before(ballot.ClosesAt) {
+		return nil, ErrResultsNotAvailable
+	}
+
+	votes, err := s.listVotes(ctx, ballot)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &BallotResults{
+		BallotID:   ballot.ID,
+		TotalVotes: len(votes),
+		Questions:  make([]tally.Result, 0, len(ballot.Questions)),
+	}
+
+	for _, question := range ballot.Questions {
+		var responses []tally.Ballot
+		for _, vote := range votes {
+			for _, selection := range vote.Selections {
+				if selection.QuestionID == question.ID {
+					responses = append(responses, selection.TallyBallot())
+				}
+			}
+		}
+
+		result, err := tally.Tally(question.TallyQuestion(), responses)
+		if err != nil {
+			return nil, fmt.Errorf("failed to tally question %s: %w", question.ID, err)
+		}
+
+		results.Questions = append(results.Questions, *result)
+	}
+
+	return results, nil
+}
+
+// Receipts publishes the receipt of every vote on a closed ballot, sorted so
+// their order says nothing about when votes were cast.
+func (s *VoteService) Receipts(ctx context.Context, ballotID string) (*BallotReceipts, error) {
+	ballot, err := s.ballotRepository.GetBallotByID(ctx, ballotID)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().Before(ballot.ClosesAt) {
+		return nil, ErrReceiptsNotPublished
+	}
+
+	votes, err := s.listVotes(ctx, ballot)
+	if err != nil {
+		return nil, err
+	}
+
+	receipts := &BallotReceipts{
+		BallotID: ballot.ID,
+		Receipts: make([]string, 0, len(votes)),
+	}
+	for _, vote := range votes {
+		// Votes cast before receipts were introduced have nothing to publish
+		if vote.Receipt == "" {
+			continue
+		}
+		if !verifyReceipt(vote) {
+			receipts.Mismatched++
+		}
+		receipts.Receipts = append(receipts.Receipts, vote.Receipt)
+	}
+	sort.Strings(receipts.Receipts)
+
+	return receipts, nil
+}
+
+// CheckReceipt reports whether a receipt belongs to a vote stored on the
+// ballot, without revealing anything about the vote itself.
+func (s *VoteService) CheckReceipt(ctx context.Context, ballotID, receipt string) (*ReceiptCheck, error) {
+	ballot, err := s.ballotRepository.GetBallotByID(ctx, ballotID)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt = strings.ToLower(strings.TrimSpace(receipt))
+
+	var included bool
+	if ballot.Secret {
+		included, err = s.ballotBox.HasReceipt(ctx, ballot.ID, receipt)
+	} else {
+		included, err = s.repository.HasReceipt(ctx, ballot.ID, receipt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReceiptCheck{
+		BallotID: ballot.ID,
+		Receipt:  receipt,
+		Included: included,
+	}, nil
+}
+
+func (s *VoteService) countVotes(ctx context.Context, ballot *ballots.Ballot) (int64, error) {
+	if ballot.Secret {
+		return s.ballotBox.CountVotes(ctx, ballot.ID)
+	}
+	return s.repository.CountVotes(ctx, ballot.ID)
+}
+
+func (s *VoteService) listVotes(ctx context.Context, ballot *ballots.Ballot) ([]Vote, error) {
+	if ballot.Secret {
+		return s.ballotBox.ListVotes(ctx, ballot.ID)
+	}
+	return s.repository.ListVotes(ctx, ballot.ID)
+}
+
+func validateSelections(ballot ballots.Ballot, selections []Selection) error {
+	var v validation.Validator
+	v.Check(len(selections) > 0, "selections", "must have at least one selection")
+
+	answered := make(map[string]bool)
+	for i, selection := range selections {
+		field := fmt.Sprintf("selections[%d]", i)
+		question, ok := ballot.Question(selection.QuestionID)
+		if !ok {
+			v.Add(field+".question_id", fmt.Sprintf("does not match a question on this ballot: %s", selection.QuestionID))
+			continue
+		}
+		if answered[selection.QuestionID] {
+			v.Add(field+".question_id", fmt.Sprintf("answers question %s more than once", selection.QuestionID))
+			continue
+		}
+		answered[selection.QuestionID] = true
+
+		if err := question.TallyQuestion().ValidateBallot(selection.TallyBallot()); err != nil {
+			v.Add(field, err.Error())
+		}
+	}
+
+	return v.Err()
+}
@@ -0,0 +1,202 @@
+package votes_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/services/ballots"
+	"github.com/bpalazzi512/easy-ballot/backend/services/memberships"
+	"github.com/bpalazzi512/easy-ballot/backend/services/rolls"
+	"github.com/bpalazzi512/easy-ballot/backend/services/users"
+	"github.com/bpalazzi512/easy-ballot/backend/services/votes"
+)
+
+type fixture struct {
+	service *votes.VoteService
+	ballots *ballots.BallotService
+	// ballotID is a ballot with one question whose options are "a" and "b"
+	ballotID string
+}
+
+// newFixture builds a vote service over in-memory storage with a ballot that
+// every member may vote on: "alice", "bob" and "carol". "outsider" is not a
+// member. The ballot is open unless scheduled.
+func newFixture(t *testing.T, secret, scheduled bool) fixture {
+	t.Helper()
+	ctx := context.Background()
+
+	ballotRepository := ballots.NewMemoryBallotRepository()
+	membershipRepository := memberships.NewMemoryMembershipRepository()
+	for _, userID := range []string{"alice", "bob", "carol"} {
+		if _, err := membershipRepository.CreateMembership(ctx, memberships.Membership{OrganizationID: "organization", UserID: userID, Role: users.RoleVoter}); err != nil {
+			t.Fatalf("CreateMembership: %v", err)
+		}
+	}
+	// Members who join after the ballot opens are left off its roll
+	time.Sleep(2 * time.Millisecond)
+
+	opensAt := time.Now()
+	if scheduled {
+		opensAt = opensAt.Add(time.Hour)
+	}
+	ballot, err := ballotRepository.CreateBallot(ctx, ballots.Ballot{
+		OrganizationID: "organization",
+		Title:          "Committee chair",
+		OpensAt:        opensAt,
+		ClosesAt:       opensAt.Add(time.Hour),
+		Secret:         secret,
+		Questions: []ballots.Question{{
+			ID:            "q1",
+			Prompt:        "Who should chair the committee?",
+			Options:       []ballots.Option{{ID: "a", Label: "Ada"}, {ID: "b", Label: "Grace"}},
+			MaxSelections: 1,
+		}},
+		Eligibility: ballots.Eligibility{Mode: ballots.EligibilityAllMembers},
+	})
+	if err != nil {
+		t.Fatalf("CreateBallot: %v", err)
+	}
+
+	rollService := rolls.NewRollService(rolls.NewMemoryRollRepository(), ballotRepository, membershipRepository)
+	f := fixture{
+		service:  votes.NewVoteService(votes.NewMemoryVoteRepository(), votes.NewMemoryBallotBoxRepository(), ballotRepository, rollService),
+		ballotID: ballot.ID,
+	}
+	f.ballots = ballots.NewBallotService(ballotRepository, f.service)
+	return f
+}
+
+// choose votes for a single option on the ballot's question.
+func choose(optionID string) votes.CastVoteRequest {
+	return votes.CastVoteRequest{Selections: []votes.Selection{{QuestionID: "q1", OptionIDs: []string{optionID}}}}
+}
+
+func TestCastVote(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		secret    bool
+		scheduled bool
+		// votedFirst is whether the voter has already voted
+		votedFirst bool
+		voterID    string
+		vote       votes.CastVoteRequest
+		err        error
+	}{
+		{name: "Public", voterID: "alice", vote: choose("a")},
+		{name: "Secret", secret: true, voterID: "alice", vote: choose("a")},
+		{name: "NotOpen", scheduled: true, voterID: "alice", vote: choose("a"), err: votes.ErrBallotNotOpen},
+		{name: "NotEligible", voterID: "outsider", vote: choose("a"), err: votes.ErrNotEligible},
+		{name: "AlreadyVoted", votedFirst: true, voterID: "alice", vote: choose("b"), err: votes.ErrAlreadyVoted},
+		{name: "AlreadyVotedSecret", secret: true, votedFirst: true, voterID: "alice", vote: choose("b"), err: votes.ErrAlreadyVoted},
+		{name: "NoSelections", voterID: "alice", err: apperr.ErrValidation},
+		{name: "UnknownOption", voterID: "alice", vote: choose("c"), err: apperr.ErrValidation},
+		{name: "UnknownQuestion", voterID: "alice", vote: votes.CastVoteRequest{Selections: []votes.Selection{{QuestionID: "q2", OptionIDs: []string{"a"}}}}, err: apperr.ErrValidation},
+		{name: "TooManyOptions", voterID: "alice", vote: votes.CastVoteRequest{Selections: []votes.Selection{{QuestionID: "q1", OptionIDs: []string{"a", "b"}}}}, err: apperr.ErrValidation},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newFixture(t, tc.secret, tc.scheduled)
+
+			var voted int64
+			if tc.votedFirst {
+				if _, err := f.service.CastVote(ctx, f.ballotID, tc.voterID, choose("a")); err != nil {
+					t.Fatalf("CastVote: %v", err)
+				}
+				voted++
+			}
+
+			vote, err := f.service.CastVote(ctx, f.ballotID, tc.voterID, tc.vote)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("CastVote returned %v, want %v", err, tc.err)
+			}
+			if err == nil {
+				voted++
+				if vote.Receipt == "" {
+					t.Error("CastVote returned a vote without a receipt")
+				}
+				// Secret votes can't be traced back to their voter
+				if tc.secret != (vote.VoterID == "") {
+					t.Errorf("CastVote stored voter %q on a ballot that is secret %t", vote.VoterID, tc.secret)
+				}
+			}
+
+			if count, err := f.service.CountVotes(ctx, f.ballotID); err != nil || count != voted {
+				t.Errorf("CountVotes returned %d and error %v, want %d", count, err, voted)
+			}
+			if hasVoted, err := f.service.HasVoted(ctx, f.ballotID, tc.voterID); err != nil || hasVoted != (voted > 0) {
+				t.Errorf("HasVoted returned %t and error %v, want %t", hasVoted, err, voted > 0)
+			}
+		})
+	}
+}
+
+func TestResults(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		secret bool
+	}{
+		{name: "Public"},
+		{name: "Secret", secret: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newFixture(t, tc.secret, false)
+
+			var receipt string
+			for voterID, optionID := range map[string]string{"alice": "a", "bob": "a", "carol": "b"} {
+				vote, err := f.service.CastVote(ctx, f.ballotID, voterID, choose(optionID))
+				if err != nil {
+					t.Fatalf("CastVote: %v", err)
+				}
+				receipt = vote.Receipt
+			}
+
+			// Nothing is published while the ballot is open
+			if _, err := f.service.Results(ctx, f.ballotID); !errors.Is(err, votes.ErrResultsNotAvailable) {
+				t.Errorf("Results before closing returned %v, want %v", err, votes.ErrResultsNotAvailable)
+			}
+			if _, err := f.service.Receipts(ctx, f.ballotID); !errors.Is(err, votes.ErrReceiptsNotPublished) {
+				t.Errorf("Receipts before closing returned %v, want %v", err, votes.ErrReceiptsNotPublished)
+			}
+
+			if _, err := f.ballots.CloseBallot(ctx, f.ballotID); err != nil {
+				t.Fatalf("CloseBallot: %v", err)
+			}
+
+			results, err := f.service.Results(ctx, f.ballotID)
+			if err != nil {
+				t.Fatalf("Results: %v", err)
+			}
+			if results.TotalVotes != 3 || len(results.Questions) != 1 {
+				t.Fatalf("Results returned %d votes on %d questions, want 3 votes on 1", results.TotalVotes, len(results.Questions))
+			}
+			if winners := results.Questions[0].Winners; len(winners) != 1 || winners[0] != "a" {
+				t.Errorf("Results returned winners %v, want [a]", winners)
+			}
+
+			receipts, err := f.service.Receipts(ctx, f.ballotID)
+			if err != nil {
+				t.Fatalf("Receipts: %v", err)
+			}
+			if len(receipts.Receipts) != 3 || receipts.Mismatched != 0 {
+				t.Errorf("Receipts returned %d receipts, %d mismatched, want 3 and none", len(receipts.Receipts), receipts.Mismatched)
+			}
+
+			for _, check := range []struct {
+				receipt  string
+				included bool
+			}{
+				{receipt: receipt, included: true},
+				{receipt: "unknown", included: false},
+			} {
+				result, err := f.service.CheckReceipt(ctx, f.ballotID, check.receipt)
+				if err != nil || result.Included != check.included {
+					t.Errorf("CheckReceipt(%q) returned %+v and error %v, want included %t", check.receipt, result, err, check.included)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package votes
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/sqldb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SQLVoteRepository stores the votes on open ballots in the votes table of a
+// SQL database.
+type SQLVoteRepository struct {
+	db *sqldb.DB
+}
+
+func NewSQLVoteRepository(db *sqldb.DB) *SQLVoteRepository {
+	return &SQLVoteRepository{
+		db: db,
+	}
+}
+
+func (r *SQLVoteRepository) CreateVote(ctx context.Context, vote Vote) (*Vote, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	vote.CreatedAt = time.Now()
+
+	if vote.ID == "" {
+		vote.ID = primitive.NewObjectID().Hex()
+	}
+
+	selections, err := json.Marshal(vote.Selections)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vote: %w", err)
+	}
+
+	q := r.db.Query()
+	values := q.Values(vote.ID, vote.BallotID, vote.VoterID, string(selections), vote.Receipt, vote.ReceiptNonce, vote.CreatedAt)
+	_, err = r.db.ExecContext(ctx, "INSERT INTO votes (id, ballot_id, voter_id, selections, receipt, receipt_nonce, created_at) VALUES "+values, q.Args...)
+	if err != nil {
+		if r.db.IsUniqueViolation(err) {
+			return nil, ErrAlreadyVoted
+		}
+		return nil, fmt.Errorf("failed to create vote: %w", err)
+	}
+
+	return &vote, nil
+}
+
+func (r *SQLVoteRepository) HasVoted(ctx context.Context, ballotID, voterID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	var voted bool
+	query := "SELECT EXISTS (SELECT 1 FROM votes WHERE ballot_id = " + q.Arg(ballotID) + " AND voter_id = " + q.Arg(voterID) + ")"
+	if err := r.db.QueryRowContext(ctx, query, q.Args...).Scan(&voted); err != nil {
+		return false, fmt.Errorf("failed to check vote: %w", err)
+	}
+
+	return voted, nil
+}
+
+func (r *SQLVoteRepository) HasReceipt(ctx context.Context, ballotID, receipt string) (bool, error) {
+	return sqlHasReceipt(ctx, r.db, "votes", ballotID, receipt)
+}
+
+func (r *SQLVoteRepository) ListVotes(ctx context.Context, ballotID string) ([]Vote, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	query := "SELECT id, ballot_id, voter_id, selections, receipt, receipt_nonce, created_at FROM votes WHERE ballot_id = " + q.Arg(ballotID) + " ORDER BY created_at, id"
+	rows, err := r.db.QueryContext(ctx, query, q.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list votes: %w", err)
+	}
+	defer rows.Close()
+
+	var votes []Vote
+	for rows.Next() {
+		var vote Vote
+		var selections []byte
+		if err := rows.Scan(&vote.ID, &vote.BallotID, &vote.VoterID, &selections, &vote.Receipt, &vote.ReceiptNonce, &vote.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to decode votes: %w", err)
+		}
+		if err := json.Unmarshal(selections, &vote.Selections); err != nil {
+			return nil, fmt.Errorf("failed to decode votes: %w", err)
+		}
+		votes = append(votes, vote)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list votes: %w", err)
+	}
+
+	return votes, nil
+}
+
+func (r *SQLVoteRepository) CountVotes(ctx context.Context, ballotID string) (int64, error) {
+	return sqlCount(ctx, r.db, "votes", ballotID)
+}
+
+// SQLBallotBoxRepository keeps secret ballots in two tables, laid out like
+// the Mongo collections. The participations table knows who voted; the
+// ballot_box table holds the votes with random IDs, day-granular timestamps
+// and no voter ID, spread across bucket rows per ballot. A vote is spliced
+// into a random bucket at a random position, in the same transaction as its
+// participation, so a voter is never recorded without their vote. System
+// columns such as PostgreSQL's xmin show which participation wrote a bucket
+// last, but not which of the bucket's votes it added, and the participation
+// itself only carries a random ID and the day it was cast.
+type SQLBallotBoxRepository struct {
+	db *sqldb.DB
+}
+
+func NewSQLBallotBoxRepository(db *sqldb.DB) *SQLBallotBoxRepository {
+	return &SQLBallotBoxRepository{
+		db: db,
+	}
+}
+
+// sqlBoxedVote is a vote as stored in a ballot_box bucket. Unlike Vote's JSON
+// form it keeps the receipt nonce; the ballot ID is the bucket's.
+type sqlBoxedVote struct {
+	ID           string      `json:"id"`
+	Selections   []Selection `json:"selections"`
+	Receipt      string      `json:"receipt"`
+	ReceiptNonce string      `json:"receipt_nonce"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+func (r *SQLBallotBoxRepository) CastSecretVote(ctx context.Context, participation Participation, vote Vote) (*Vote, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	castOn := coarseTime(time.Now())
+
+	participationID, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	participation.ID = participationID
+	participation.CreatedAt = castOn
+
+	voteID, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	vote.ID = voteID
+	vote.VoterID = ""
+	vote.CreatedAt = castOn
+
+	// The unique ballot/voter constraint on participations enforces one vote
+	// per voter. The participation and the vote commit together, so a failure
+	// anywhere leaves neither behind.
+	err = r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		q := r.db.Query()
+		values := q.Values(participation.ID, participation.BallotID, participation.VoterID, participation.CreatedAt)
+		if _, err := tx.ExecContext(ctx, "INSERT INTO participations (id, ballot_id, voter_id, created_at) VALUES "+values, q.Args...); err != nil {
+			if r.db.IsUniqueViolation(err) {
+				return ErrAlreadyVoted
+			}
+			return fmt.Errorf("failed to record participation: %w", err)
+		}
+		if err := r.insertIntoBox(ctx, tx, vote); err != nil {
+			return fmt.Errorf("failed to store vote: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &vote, nil
+}
+
+// insertIntoBox splices the vote into a random bucket at a random position.
+// The bucket row stays locked from reading it to writing it back, so
+// concurrent votes cannot fall back to insertion order.
+func (r *SQLBallotBoxRepository) insertIntoBox(ctx context.Context, tx *sql.Tx, vote Vote) error {
+	bucket, err := rand.Int(rand.Reader, big.NewInt(ballotBoxBuckets))
+	if err != nil {
+		return fmt.Errorf("failed to pick ballot box bucket: %w", err)
+	}
+	bucketID := fmt.Sprintf("%s:%x", vote.BallotID, bucket.Int64())
+
+	boxed, err := json.Marshal(sqlBoxedVote{
+		ID:           vote.ID,
+		Selections:   vote.Selections,
+		Receipt:      vote.Receipt,
+		ReceiptNonce: vote.ReceiptNonce,
+		CreatedAt:    vote.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	q := r.db.Query()
+	values := q.Values(bucketID, vote.BallotID, "[]")
+	if _, err := tx.ExecContext(ctx, "INSERT INTO ballot_box (id, ballot_id, votes) VALUES "+values+" ON CONFLICT (id) DO NOTHING", q.Args...); err != nil {
+		return err
+	}
+
+	// A SQLite transaction already holds the database's write lock
+	q = r.db.Query()
+	query := "SELECT votes FROM ballot_box WHERE id = " + q.Arg(bucketID)
+	if r.db.Dialect == sqldb.Postgres {
+		query += " FOR UPDATE"
+	}
+	var stored []byte
+	if err := tx.QueryRowContext(ctx, query, q.Args...).Scan(&stored); err != nil {
+		return err
+	}
+	var votes []json.RawMessage
+	if err := json.Unmarshal(stored, &votes); err != nil {
+		return err
+	}
+
+	position, err := rand.Int(rand.Reader, big.NewInt(int64(len(votes)+1)))
+	if err != nil {
+		return fmt.Errorf("failed to pick ballot box position: %w", err)
+	}
+	i := int(position.Int64())
+	votes = append(votes, nil)
+	copy(votes[i+1:], votes[i:])
+	votes[i] = boxed
+
+	spliced, err := json.Marshal(votes)
+	if err != nil {
+		return err
+	}
+	q = r.db.Query()
+	_, err = tx.ExecContext(ctx, "UPDATE ballot_box SET votes = "+q.Arg(string(spliced))+" WHERE id = "+q.Arg(bucketID), q.Args...)
+	return err
+}
+
+func (r *SQLBallotBoxRepository) HasParticipated(ctx context.Context, ballotID, voterID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	var participated bool
+	query := "SELECT EXISTS (SELECT 1 FROM participations WHERE ballot_id = " + q.Arg(ballotID) + " AND voter_id = " + q.Arg(voterID) + ")"
+	if err := r.db.QueryRowContext(ctx, query, q.Args...).Scan(&participated); err != nil {
+		return false, fmt.Errorf("failed to check participation: %w", err)
+	}
+
+	return participated, nil
+}
+
+func (r *SQLBallotBoxRepository) HasReceipt(ctx context.Context, ballotID, receipt string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	votes := "json_each(ballot_box.votes) AS vote"
+	if r.db.Dialect == sqldb.Postgres {
+		votes = "jsonb_array_elements(ballot_box.votes) AS vote (value)"
+	}
+
+	q := r.db.Query()
+	var found bool
+	query := "SELECT EXISTS (SELECT 1 FROM ballot_box, " + votes + " WHERE ballot_box.ballot_id = " + q.Arg(ballotID) + " AND vote.value ->> 'receipt' = " + q.Arg(receipt) + ")"
+	if err := r.db.QueryRowContext(ctx, query, q.Args...).Scan(&found); err != nil {
+		return false, fmt.Errorf("failed to check receipt: %w", err)
+	}
+
+	return found, nil
+}
+
+func (r *SQLBallotBoxRepository) ListVotes(ctx context.Context, ballotID string) ([]Vote, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := r.db.Query()
+	query := "SELECT votes FROM ballot_box WHERE ballot_id = " + q.Arg(ballotID) + " ORDER BY id"
+	rows, err := r.db.QueryContext(ctx, query, q.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list votes: %w", err)
+	}
+	defer rows.Close()
+
+	var votes []Vote
+	for rows.Next() {
+		var stored []byte
+		if err := rows.Scan(&stored); err != nil {
+			return nil, fmt.Errorf("failed to decode votes: %w", err)
+		}
+		var bucket []sqlBoxedVote
+		if err := json.Unmarshal(stored, &bucket); err != nil {
+			return nil, fmt.Errorf("failed to decode votes: %w", err)
+		}
+		for _, boxed := range bucket {
+			votes = append(votes, Vote{
+				ID:           boxed.ID,
+				BallotID:     ballotID,
+				Selections:   boxed.Selections,
+				Receipt:      boxed.Receipt,
+				ReceiptNonce: boxed.ReceiptNonce,
+				CreatedAt:    boxed.CreatedAt,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list votes: %w", err)
+	}
+
+	return votes, nil
+}
+
+// CountVotes counts participations, which commit together with their votes.
+func (r *SQLBallotBoxRepository) CountVotes(ctx context.Context, ballotID string) (int64, error) {
+	return sqlCount(ctx, r.db, "participations", ballotID)
+}
+
+func sqlHasReceipt(ctx context.Context, db *sqldb.DB, table, ballotID, receipt string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := db.Query()
+	var found bool
+	query := "SELECT EXISTS (SELECT 1 FROM " + table + " WHERE ballot_id = " + q.Arg(ballotID) + " AND receipt = " + q.Arg(receipt) + ")"
+	if err := db.QueryRowContext(ctx, query, q.Args...).Scan(&found); err != nil {
+		return false, fmt.Errorf("failed to check receipt: %w", err)
+	}
+
+	return found, nil
+}
+
+func sqlCount(ctx context.Context, db *sqldb.DB, table, ballotID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := db.Query()
+	var count int64
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table+" WHERE ballot_id = "+q.Arg(ballotID), q.Args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count votes: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,77 @@
+package votes_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/repotest"
+	"github.com/bpalazzi512/easy-ballot/backend/services/votes"
+)
+
+func TestPostgresBallotBoxRepository(t *testing.T) {
+	serverURL := repotest.PostgresURL(t)
+
+	repotest.TestBallotBoxRepository(t, func(t *testing.T) votes.BallotBoxRepository {
+		return votes.NewSQLBallotBoxRepository(repotest.PostgresDB(t, serverURL))
+	})
+
+	t.Run("Tables", func(t *testing.T) {
+		db := repotest.PostgresDB(t, serverURL)
+		repotest.TestBallotBoxTables(t, db, votes.NewSQLBallotBoxRepository(db))
+	})
+}
+
+func TestSQLiteBallotBoxRepository(t *testing.T) {
+	repotest.TestBallotBoxRepository(t, func(t *testing.T) votes.BallotBoxRepository {
+		return votes.NewSQLBallotBoxRepository(repotest.SQLiteDB(t))
+	})
+
+	t.Run("Tables", func(t *testing.T) {
+		db := repotest.SQLiteDB(t)
+		repotest.TestBallotBoxTables(t, db, votes.NewSQLBallotBoxRepository(db))
+	})
+
+	t.Run("FailedVote", func(t *testing.T) {
+		ctx := context.Background()
+		db := repotest.SQLiteDB(t)
+		repo := votes.NewSQLBallotBoxRepository(db)
+
+		// Fail the vote after its participation and bucket have been written
+		if _, err := db.ExecContext(ctx, "CREATE TRIGGER fail_vote BEFORE UPDATE ON ballot_box BEGIN SELECT RAISE(FAIL, 'ballot box unavailable'); END"); err != nil {
+			t.Fatalf("failed to create trigger: %v", err)
+		}
+
+		participation := votes.Participation{BallotID: "ballot", VoterID: "ada"}
+		vote := votes.Vote{
+			BallotID:   "ballot",
+			VoterID:    "ada",
+			Selections: []votes.Selection{{QuestionID: "chair", OptionIDs: []string{"grace"}}},
+			Receipt:    "receipt",
+		}
+		if _, err := repo.CastSecretVote(ctx, participation, vote); err == nil {
+			t.Fatal("CastSecretVote succeeded, want an error")
+		}
+
+		participated, err := repo.HasParticipated(ctx, "ballot", "ada")
+		if err != nil || participated {
+			t.Errorf("HasParticipated returned %v, %v, want false", participated, err)
+		}
+		if count, err := repo.CountVotes(ctx, "ballot"); err != nil || count != 0 {
+			t.Errorf("CountVotes returned %d, %v, want 0", count, err)
+		}
+		if stored, err := repo.ListVotes(ctx, "ballot"); err != nil || len(stored) != 0 {
+			t.Errorf("ListVotes returned %v, %v, want no votes", stored, err)
+		}
+
+		// The voter can try again once the box is back
+		if _, err := db.ExecContext(ctx, "DROP TRIGGER fail_vote"); err != nil {
+			t.Fatalf("failed to drop trigger: %v", err)
+		}
+		if _, err := repo.CastSecretVote(ctx, participation, vote); err != nil {
+			t.Fatalf("CastSecretVote: %v", err)
+		}
+		if count, err := repo.CountVotes(ctx, "ballot"); err != nil || count != 1 {
+			t.Errorf("CountVotes returned %d, %v, want 1", count, err)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package votes
+
+import (
+	"context"
+	"time"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/tally"
+)
+
+var (
+	ErrAlreadyVoted         = apperr.Conflict("voter has already voted on this ballot")
+	ErrBallotNotOpen        = apperr.Forbidden("ballot is not open for voting")
+	ErrNotEligible          = apperr.Forbidden("voter is not on this ballot's voter roll")
+	ErrResultsNotAvailable  = apperr.Forbidden("results are available once the ballot has closed")
+	ErrReceiptsNotPublished = apperr.Forbidden("receipts are published once the ballot has closed")
+)
+
+type Selection struct {
+	QuestionID string         `json:"question_id" bson:"question_id"`
+	OptionIDs  []string       `json:"option_ids,omitempty" bson:"option_ids,omitempty"`
+	Scores     map[string]int `json:"scores,omitempty" bson:"scores,omitempty"`
+}
+
+// TallyBallot converts the selection into the tally engine's ballot form.
+func (s Selection) TallyBallot() tally.Ballot {
+	return tally.Ballot{
+		Choices: s.OptionIDs,
+		Scores:  s.Scores,
+	}
+}
+
+type Vote struct {
+	ID         string      `json:"id" bson:"_id,omitempty"`
+	BallotID   string      `json:"ballot_id" bson:"ballot_id"`
+	VoterID    string      `json:"voter_id,omitempty" bson:"voter_id,omitempty"`
+	Selections []Selection `json:"selections" bson:"selections"`
+	// Receipt is a hash commitment to the vote that its voter can later look
+	// up to confirm the vote was counted.
+	Receipt      string    `json:"receipt,omitempty" bson:"receipt,omitempty"`
+	ReceiptNonce string    `json:"-" bson:"receipt_nonce,omitempty"`
+	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
+}
+
+// Participation records that a voter has voted on a secret ballot without
+// recording what they voted for.
+type Participation struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	BallotID  string    `json:"ballot_id" bson:"ballot_id"`
+	VoterID   string    `json:"voter_id" bson:"voter_id"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+type BallotResults struct {
+	BallotID   string         `json:"ballot_id"`
+	TotalVotes int            `json:"total_votes"`
+	Questions  []tally.Result `json:"questions"`
+}
+
+// BallotReceipts is the published list of every receipt counted on a closed
+// ballot. Mismatched counts stored votes that no longer match their receipt.
+type BallotReceipts struct {
+	BallotID   string   `json:"ballot_id"`
+	Receipts   []string `json:"receipts"`
+	Mismatched int      `json:"mismatched"`
+}
+
+type ReceiptCheck struct {
+	BallotID string `json:"ballot_id"`
+	Receipt  string `json:"receipt"`
+	Included bool   `json:"included"`
+}
+
+type CastVoteRequest struct {
+	Selections []Selection `json:"selections"`
+}
+
+type VoteRepository interface {
+	CreateVote(ctx context.Context, vote Vote) (*Vote, error)
+	HasVoted(ctx context.Context, ballotID, voterID string) (bool, error)
+	HasReceipt(ctx context.Context, ballotID, receipt string) (bool, error)
+	ListVotes(ctx context.Context, ballotID string) ([]Vote, error)
+	CountVotes(ctx context.Context, ballotID string) (int64, error)
+}
+
+// BallotBoxRepository stores votes on secret ballots. Participation and the
+// anonymized votes live apart and share no identifiers, precise timestamps or
+// insertion order, so neither can be joined back to the other.
+type BallotBoxRepository interface {
+	// CastSecretVote records the participation and the anonymized vote,
+	// returning ErrAlreadyVoted if the voter has already participated.
+	CastSecretVote(ctx context.Context, participation Participation, vote Vote) (*Vote, error)
+	HasParticipated(ctx context.Context, ballotID, voterID string) (bool, error)
+	HasReceipt(ctx context.Context, ballotID, receipt string) (bool, error)
+	ListVotes(ctx context.Context, ballotID string) ([]Vote, error)
+	CountVotes(ctx context.Context, ballotID string) (int64, error)
+}
@@ -0,0 +1,219 @@
+// Package sqldb holds what the SQL repositories share: the connection along
+// with the dialect its queries are written in, and helpers to build and run
+// them.
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Dialect is the flavor of SQL a database speaks.
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// sqliteOptions configure every SQLite connection. WAL lets readers carry on
+// while a write is in progress, and transactions take the write lock as they
+// begin so two of them never deadlock upgrading to it. Times are written in a
+// format that sorts as text, which is how SQLite compares them.
+const sqliteOptions = "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate&_time_format=sqlite"
+
+// DB is a SQL database and its dialect.
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+}
+
+// Open connects to a database and checks that it is reachable. For SQLite
+// the data source is the path of the database file, which is created if it
+// does not exist.
+func Open(ctx context.Context, dialect Dialect, dataSourceName string) (*DB, error) {
+	var driver string
+	switch dialect {
+	case Postgres:
+		driver = "pgx"
+	case SQLite:
+		driver = "sqlite"
+		dataSourceName = "file:" + dataSourceName + "?" + sqliteOptions
+	default:
+		return nil, fmt.Errorf("unsupported SQL dialect %s", dialect)
+	}
+
+	db, err := sql.Open(driver, dataSourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %w", dialect, err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping %s database: %w", dialect, err)
+	}
+
+	return &DB{DB: db, Dialect: dialect}, nil
+}
+
+// Query starts building a query in the database's dialect.
+func (db *DB) Query() *Query {
+	return &Query{dialect: db.Dialect}
+}
+
+// WithTx runs fn in a transaction, committing it if fn succeeds and rolling
+// it back otherwise.
+func (db *DB) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// IsUniqueViolation reports whether err was caused by a unique constraint,
+// the SQL counterpart of mongo.IsDuplicateKeyError.
+func (db *DB) IsUniqueViolation(err error) bool {
+	switch db.Dialect {
+	case SQLite:
+		var sqliteErr *sqlite.Error
+		return errors.As(err, &sqliteErr) &&
+			(sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY)
+	default:
+		var pgErr *pgconn.PgError
+		return errors.As(err, &pgErr) && pgErr.Code == "23505"
+	}
+}
+
+// Query collects the arguments of a query as their placeholders are written
+// into it.
+type Query struct {
+	dialect Dialect
+	Args    []any
+}
+
+// Arg adds an argument and returns its placeholder. Times are passed in UTC
+// so that SQLite, which stores them as text, compares them correctly.
+func (q *Query) Arg(value any) string {
+	switch v := value.(type) {
+	case time.Time:
+		value = v.UTC()
+	case *time.Time:
+		if v != nil {
+			value = v.UTC()
+		}
+	}
+	q.Args = append(q.Args, value)
+	return "$" + strconv.Itoa(len(q.Args))
+}
+
+// Values adds the arguments and returns their placeholders as a
+// parenthesized list, for an INSERT.
+func (q *Query) Values(values ...any) string {
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		placeholders[i] = q.Arg(value)
+	}
+	return "(" + strings.Join(placeholders, ", ") + ")"
+}
+
+// In returns the condition that column is one of values.
+func (q *Query) In(column string, values []string) string {
+	if len(values) == 0 {
+		return "FALSE"
+	}
+
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		placeholders[i] = q.Arg(value)
+	}
+	return column + " IN (" + strings.Join(placeholders, ", ") + ")"
+}
+
+// HasPrefix returns the condition that column starts with prefix, which is
+// matched literally.
+func (q *Query) HasPrefix(column, prefix string) string {
+	return column + " LIKE " + q.Arg(escapeLike(prefix)+"%") + ` ESCAPE '\'`
+}
+
+// Limit returns the LIMIT and OFFSET clauses, leaving out each one that is
+// not positive.
+func (q *Query) Limit(limit, offset int) string {
+	var clause string
+	if limit > 0 {
+		clause += " LIMIT " + q.Arg(limit)
+	} else if offset > 0 && q.dialect == SQLite {
+		// SQLite only accepts OFFSET after a LIMIT, where -1 is unlimited
+		clause += " LIMIT -1"
+	}
+	if offset > 0 {
+		clause += " OFFSET " + q.Arg(offset)
+	}
+	return clause
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern written with ESCAPE '\'.
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+}
+
+// MatchesAnyWord returns a condition that holds when any word of search
+// appears in the text. PostgreSQL uses full text search with stemming as
+// MongoDB's text indexes do, and text must match the expression of the
+// table's search index for the index to be used. SQLite matches whole words
+// regardless of case, as the in-memory repositories do.
+func (q *Query) MatchesAnyWord(text, search string) string {
+	var conditions []string
+	for _, word := range strings.Fields(search) {
+		switch q.dialect {
+		case SQLite:
+			pattern := "% " + escapeLike(strings.ToLower(word)) + " %"
+			conditions = append(conditions, fmt.Sprintf(`' ' || lower(%s) || ' ' LIKE %s ESCAPE '\'`, text, q.Arg(pattern)))
+		default:
+			conditions = append(conditions, fmt.Sprintf("to_tsvector('english', %s) @@ plainto_tsquery('english', %s)", text, q.Arg(word)))
+		}
+	}
+	if len(conditions) == 0 {
+		return "TRUE"
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// Where joins conditions into a WHERE clause, or returns "" if there are
+// none.
+func Where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// Querier is a *sql.DB or *sql.Tx.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Scanner is a *sql.Row or *sql.Rows.
+type Scanner interface {
+	Scan(dest ...any) error
+}
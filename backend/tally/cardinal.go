@@ -0,0 +1,37 @@
+package tally
+
+// countChoices implements plurality and approval voting: every chosen option
+// receives one vote and the options with the most votes fill the seats.
+func countChoices(question Question, ballots []Ballot) *Result {
+	tallies := zeroTallies(question.Options)
+	for _, ballot := range ballots {
+		for _, optionID := range ballot.Choices {
+			tallies[optionID]++
+		}
+	}
+
+	return &Result{
+		Winners: topN(rankOptions(question.Options, tallies), question.Seats, ballots),
+		Tallies: tallies,
+	}
+}
+
+// countScores implements score (range) voting. Options a voter left unscored
+// count as the minimum score, and the highest totals fill the seats.
+func countScores(question Question, ballots []Ballot) *Result {
+	tallies := zeroTallies(question.Options)
+	for _, ballot := range ballots {
+		for _, optionID := range question.Options {
+			score, ok := ballot.Scores[optionID]
+			if !ok {
+				score = question.MinScore
+			}
+			tallies[optionID] += float64(score)
+		}
+	}
+
+	return &Result{
+		Winners: topN(rankOptions(question.Options, tallies), question.Seats, ballots),
+		Tallies: tallies,
+	}
+}
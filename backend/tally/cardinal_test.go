@@ -0,0 +1,139 @@
+package tally_test
+
+import (
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/tally"
+)
+
+func TestChoices(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  tally.Method
+		seats   int
+		ballots []tally.Ballot
+		tallies map[string]float64
+		winners []string
+	}{
+		{
+			name:   "Plurality",
+			method: tally.Plurality,
+			seats:  1,
+			ballots: join(
+				ranked(2, "ada"),
+				ranked(3, "alan"),
+			),
+			tallies: votes("grace", 0, "ada", 2, "alan", 3),
+			winners: []string{"alan"},
+		},
+		{
+			// grace and ada tie on two approvals; grace is listed first
+			name:   "ApprovalTie",
+			method: tally.Approval,
+			seats:  1,
+			ballots: join(
+				ranked(1, "ada", "grace"),
+				ranked(1, "grace", "alan"),
+				ranked(1, "ada"),
+			),
+			tallies: votes("grace", 2, "ada", 2, "alan", 1),
+			winners: []string{"grace"},
+		},
+		{
+			name:   "ApprovalSeats",
+			method: tally.Approval,
+			seats:  2,
+			ballots: join(
+				ranked(1, "ada", "alan"),
+				ranked(1, "alan"),
+				ranked(1, "ada", "grace", "alan"),
+			),
+			tallies: votes("grace", 1, "ada", 2, "alan", 3),
+			winners: []string{"alan", "ada"},
+		},
+		{
+			name:    "NoBallots",
+			method:  tally.Approval,
+			seats:   1,
+			tallies: votes("grace", 0, "ada", 0, "alan", 0),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			question := tally.Question{
+				ID:            "chair",
+				Method:        tc.method,
+				Options:       []string{"grace", "ada", "alan"},
+				Seats:         tc.seats,
+				MaxSelections: 3,
+			}
+
+			result, err := tally.Tally(question, tc.ballots)
+			if err != nil {
+				t.Fatalf("Tally: %v", err)
+			}
+			checkResult(t, result, len(tc.ballots), tc.winners)
+			if !sameVotes(result.Tallies, tc.tallies) {
+				t.Errorf("got tallies %v, want %v", result.Tallies, tc.tallies)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		seats   int
+		ballots []tally.Ballot
+		tallies map[string]float64
+		winners []string
+	}{
+		{
+			// Unscored options count as the minimum of 1, leaving grace and
+			// ada tied on 9; grace is listed first
+			name:  "Tie",
+			seats: 1,
+			ballots: []tally.Ballot{
+				{Scores: map[string]int{"ada": 5, "grace": 3}},
+				{Scores: map[string]int{"grace": 5, "ada": 3}},
+				{Scores: map[string]int{"alan": 5}},
+			},
+			tallies: votes("grace", 9, "ada", 9, "alan", 7),
+			winners: []string{"grace"},
+		},
+		{
+			name:  "Seats",
+			seats: 2,
+			ballots: []tally.Ballot{
+				{Scores: map[string]int{"ada": 5, "grace": 1, "alan": 4}},
+				{Scores: map[string]int{"ada": 2, "grace": 2, "alan": 5}},
+			},
+			tallies: votes("grace", 3, "ada", 7, "alan", 9),
+			winners: []string{"alan", "ada"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			question := tally.Question{
+				ID:            "chair",
+				Method:        tally.Score,
+				Options:       []string{"grace", "ada", "alan"},
+				Seats:         tc.seats,
+				MaxSelections: 3,
+				MinScore:      1,
+				MaxScore:      5,
+			}
+
+			result, err := tally.Tally(question, tc.ballots)
+			if err != nil {
+				t.Fatalf("Tally: %v", err)
+			}
+			checkResult(t, result, len(tc.ballots), tc.winners)
+			if !sameVotes(result.Tallies, tc.tallies) {
+				t.Errorf("got tallies %v, want %v", result.Tallies, tc.tallies)
+			}
+		})
+	}
+}
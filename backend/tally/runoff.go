@@ -0,0 +1,133 @@
+package tally
+
+// instantRunoff repeatedly eliminates the option with the fewest first
+// preferences and transfers its ballots to their next continuing preference
+// until one option holds a majority of the ballots still in play.
+func instantRunoff(question Question, ballots []Ballot) *Result {
+	result := &Result{}
+
+	continuing := make(map[string]bool, len(question.Options))
+	for _, optionID := range question.Options {
+		continuing[optionID] = true
+	}
+
+	var history []map[string]float64
+	var transfers map[string]float64
+
+	for number := 1; ; number++ {
+		tallies := make(map[string]float64, len(continuing))
+		for optionID := range continuing {
+			tallies[optionID] = 0
+		}
+
+		var exhausted float64
+		for _, ballot := range ballots {
+			if choice, ok := topChoice(ballot, continuing); ok {
+				tallies[choice]++
+			} else {
+				exhausted++
+			}
+		}
+
+		round := Round{
+			Number:    number,
+			Tallies:   tallies,
+			Transfers: transfers,
+			Exhausted: exhausted,
+		}
+		history = append(history, tallies)
+
+		active := float64(len(ballots)) - exhausted
+		if active == 0 {
+			result.Rounds = append(result.Rounds, round)
+			return result
+		}
+
+		for optionID, votes := range tallies {
+			if votes > active/2 || len(continuing) == 1 {
+				round.Elected = []string{optionID}
+				result.Winners = round.Elected
+				result.Rounds = append(result.Rounds, round)
+				return result
+			}
+		}
+
+		loser, tieBreak := lowestOption(question.Options, continuing, history)
+		round.Eliminated = []string{loser}
+		round.TieBreak = tieBreak
+		result.Rounds = append(result.Rounds, round)
+
+		delete(continuing, loser)
+		transfers = make(map[string]float64)
+		for _, ballot := range ballots {
+			if choice, ok := topChoice(ballot, continuing); ok && firstChoiceWas(ballot, loser, continuing) {
+				transfers[choice]++
+			}
+		}
+	}
+}
+
+// topChoice returns the highest-ranked option on the ballot that is still
+// continuing.
+func topChoice(ballot Ballot, continuing map[string]bool) (string, bool) {
+	for _, optionID := range ballot.Choices {
+		if continuing[optionID] {
+			return optionID, true
+		}
+	}
+	return "", false
+}
+
+// firstChoiceWas reports whether the ballot counted for eliminated before it
+// was removed from the continuing set.
+func firstChoiceWas(ballot Ballot, eliminated string, continuing map[string]bool) bool {
+	for _, optionID := range ballot.Choices {
+		if optionID == eliminated {
+			return true
+		}
+		if continuing[optionID] {
+			return false
+		}
+	}
+	return false
+}
+
+// lowestOption picks the continuing option to eliminate. Ties are broken by
+// looking back through earlier rounds for the first one in which the tied
+// options differ, and finally by eliminating the option listed last on the
+// ballot. The second return value reports whether a tie had to be broken.
+func lowestOption(options []string, continuing map[string]bool, history []map[string]float64) (string, bool) {
+	current := history[len(history)-1]
+
+	var candidates []string
+	for _, optionID := range options {
+		if !continuing[optionID] {
+			continue
+		}
+		switch {
+		case len(candidates) == 0 || current[optionID] < current[candidates[0]]:
+			candidates = []string{optionID}
+		case current[optionID] == current[candidates[0]]:
+			candidates = append(candidates, optionID)
+		}
+	}
+
+	if len(candidates) == 1 {
+		return candidates[0], false
+	}
+
+	for i := len(history) - 2; i >= 0 && len(candidates) > 1; i-- {
+		var lowest []string
+		for _, optionID := range candidates {
+			switch {
+			case len(lowest) == 0 || history[i][optionID] < history[i][lowest[0]]:
+				lowest = []string{optionID}
+			case history[i][optionID] == history[i][lowest[0]]:
+				lowest = append(lowest, optionID)
+			}
+		}
+		candidates = lowest
+	}
+
+	return candidates[len(candidates)-1], true
+}
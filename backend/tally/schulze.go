@@ -0,0 +1,95 @@
+package tally
+
+import "sort"
+
+// schulze implements the Schulze Condorcet method. Ranked options are
+// preferred over unranked ones and unranked options are tied with each other,
+// so partial rankings are counted consistently. Options are ordered by how
+// many others they beat on strongest-path strength, and the top of that
+// ordering fills the seats.
+func schulze(question Question, ballots []Ballot) *Result {
+	options := question.Options
+	n := len(options)
+
+	// preferences[i][j] is the number of voters who prefer option i to option j.
+	preferences := make([][]float64, n)
+	for i := range preferences {
+		preferences[i] = make([]float64, n)
+	}
+
+	for _, ballot := range ballots {
+		rank := make(map[string]int, len(ballot.Choices))
+		for position, optionID := range ballot.Choices {
+			rank[optionID] = position
+		}
+
+		for i, a := range options {
+			rankA, rankedA := rank[a]
+			if !rankedA {
+				continue
+			}
+			for j, b := range options {
+				if i == j {
+					continue
+				}
+				if rankB, rankedB := rank[b]; !rankedB || rankA < rankB {
+					preferences[i][j]++
+				}
+			}
+		}
+	}
+
+	// strength[i][j] is the width of the strongest path from option i to j.
+	strength := make([][]float64, n)
+	for i := range strength {
+		strength[i] = make([]float64, n)
+		for j := range strength[i] {
+			if i != j && preferences[i][j] > preferences[j][i] {
+				strength[i][j] = preferences[i][j]
+			}
+		}
+	}
+
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			if i == k {
+				continue
+			}
+			for j := 0; j < n; j++ {
+				if j == i || j == k {
+					continue
+				}
+				if width := min(strength[i][k], strength[k][j]); width > strength[i][j] {
+					strength[i][j] = width
+				}
+			}
+		}
+	}
+
+	wins := make(map[string]float64, n)
+	pairwise := make(map[string]map[string]float64, n)
+	for i, a := range options {
+		wins[a] = 0
+		pairwise[a] = make(map[string]float64, n-1)
+		for j, b := range options {
+			if i == j {
+				continue
+			}
+			pairwise[a][b] = preferences[i][j]
+			if strength[i][j] > strength[j][i] {
+				wins[a]++
+			}
+		}
+	}
+
+	ranked := append([]string(nil), options...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return wins[ranked[i]] > wins[ranked[j]]
+	})
+
+	return &Result{
+		Winners:  topN(ranked, question.Seats, ballots),
+		Tallies:  wins,
+		Pairwise: pairwise,
+	}
+}
@@ -0,0 +1,100 @@
+package tally_test
+
+import (
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/tally"
+)
+
+func TestSchulze(t *testing.T) {
+	tests := []struct {
+		name     string
+		seats    int
+		ballots  []tally.Ballot
+		pairwise map[string]map[string]float64
+		wins     map[string]float64
+		winners  []string
+	}{
+		{
+			// ada beats grace 6-3, grace beats alan 7-2 and alan beats ada
+			// 5-4. The weakest link, alan over ada, loses the cycle.
+			name:  "CondorcetCycle",
+			seats: 2,
+			ballots: join(
+				ranked(4, "ada", "grace", "alan"),
+				ranked(3, "grace", "alan", "ada"),
+				ranked(2, "alan", "ada", "grace"),
+			),
+			pairwise: map[string]map[string]float64{
+				"ada":   votes("grace", 6, "alan", 4),
+				"grace": votes("ada", 3, "alan", 7),
+				"alan":  votes("ada", 5, "grace", 2),
+			},
+			wins:    votes("ada", 2, "grace", 1, "alan", 0),
+			winners: []string{"ada", "grace"},
+		},
+		{
+			// Every link of the cycle is 2-1, so nobody beats anybody and
+			// ballot order decides
+			name:  "EvenCycle",
+			seats: 1,
+			ballots: join(
+				ranked(1, "ada", "grace", "alan"),
+				ranked(1, "grace", "alan", "ada"),
+				ranked(1, "alan", "ada", "grace"),
+			),
+			pairwise: map[string]map[string]float64{
+				"ada":   votes("grace", 2, "alan", 1),
+				"grace": votes("ada", 1, "alan", 2),
+				"alan":  votes("ada", 2, "grace", 1),
+			},
+			wins:    votes("ada", 0, "grace", 0, "alan", 0),
+			winners: []string{"ada"},
+		},
+		{
+			// Ranked options beat unranked ones, which tie with each other
+			name:  "PartialRankings",
+			seats: 1,
+			ballots: join(
+				ranked(2, "alan"),
+				ranked(1, "grace", "ada"),
+			),
+			pairwise: map[string]map[string]float64{
+				"ada":   votes("grace", 0, "alan", 1),
+				"grace": votes("ada", 1, "alan", 1),
+				"alan":  votes("ada", 2, "grace", 2),
+			},
+			wins:    votes("ada", 0, "grace", 1, "alan", 2),
+			winners: []string{"alan"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			question := tally.Question{
+				ID:            "chair",
+				Method:        tally.Schulze,
+				Options:       []string{"ada", "grace", "alan"},
+				Seats:         tc.seats,
+				MaxSelections: 3,
+			}
+
+			result, err := tally.Tally(question, tc.ballots)
+			if err != nil {
+				t.Fatalf("Tally: %v", err)
+			}
+			checkResult(t, result, len(tc.ballots), tc.winners)
+			if !sameVotes(result.Tallies, tc.wins) {
+				t.Errorf("got wins %v, want %v", result.Tallies, tc.wins)
+			}
+			if len(result.Pairwise) != len(tc.pairwise) {
+				t.Errorf("got pairwise preferences %v, want %v", result.Pairwise, tc.pairwise)
+			}
+			for optionID, preferences := range tc.pairwise {
+				if !sameVotes(result.Pairwise[optionID], preferences) {
+					t.Errorf("got pairwise preferences of %s %v, want %v", optionID, result.Pairwise[optionID], preferences)
+				}
+			}
+		})
+	}
+}
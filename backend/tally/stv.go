@@ -0,0 +1,135 @@
+package tally
+
+import "math"
+
+// singleTransferableVote fills multiple seats using the Droop quota. Options
+// reaching the quota are elected and their surplus is transferred at a
+// fractional weight (weighted inclusive Gregory method); otherwise the option
+// with the fewest votes is eliminated and its ballots move on at their current
+// weight. Once the remaining options can exactly fill the remaining seats they
+// are all elected.
+func singleTransferableVote(question Question, ballots []Ballot) *Result {
+	quota := math.Floor(float64(len(ballots))/float64(question.Seats+1)) + 1
+	result := &Result{Quota: quota}
+
+	if len(ballots) == 0 {
+		return result
+	}
+
+	continuing := make(map[string]bool, len(question.Options))
+	for _, optionID := range question.Options {
+		continuing[optionID] = true
+	}
+
+	weights := make([]float64, len(ballots))
+	for i := range weights {
+		weights[i] = 1
+	}
+
+	var history []map[string]float64
+	var previous map[string]float64
+
+	for number := 1; len(result.Winners) < question.Seats; number++ {
+		tallies := make(map[string]float64, len(continuing))
+		for optionID := range continuing {
+			tallies[optionID] = 0
+		}
+
+		holders := make(map[string][]int, len(continuing))
+		var exhausted float64
+		for i, ballot := range ballots {
+			if choice, ok := topChoice(ballot, continuing); ok {
+				tallies[choice] += weights[i]
+				holders[choice] = append(holders[choice], i)
+			} else {
+				exhausted += weights[i]
+			}
+		}
+
+		round := Round{
+			Number:    number,
+			Tallies:   roundTallies(tallies),
+			Transfers: transfersSince(previous, tallies),
+			Exhausted: roundVotes(exhausted),
+		}
+		history = append(history, tallies)
+		previous = tallies
+
+		remaining := question.Seats - len(result.Winners)
+		if len(continuing) <= remaining {
+			round.Elected = inBallotOrder(question.Options, continuing)
+			result.Winners = append(result.Winners, round.Elected...)
+			result.Rounds = append(result.Rounds, round)
+			break
+		}
+
+		var elected []string
+		for _, optionID := range rankOptions(question.Options, tallies) {
+			if continuing[optionID] && tallies[optionID] >= quota && len(elected) < remaining {
+				elected = append(elected, optionID)
+			}
+		}
+
+		if len(elected) > 0 {
+			for _, optionID := range elected {
+				surplus := tallies[optionID] - quota
+				ratio := surplus / tallies[optionID]
+				for _, i := range holders[optionID] {
+					weights[i] *= ratio
+				}
+				delete(continuing, optionID)
+			}
+			round.Elected = elected
+			result.Winners = append(result.Winners, elected...)
+			result.Rounds = append(result.Rounds, round)
+			continue
+		}
+
+		loser, tieBreak := lowestOption(question.Options, continuing, history)
+		round.Eliminated = []string{loser}
+		round.TieBreak = tieBreak
+		result.Rounds = append(result.Rounds, round)
+		delete(continuing, loser)
+	}
+
+	return result
+}
+
+// transfersSince reports how many votes each option gained since the
+// previous round.
+func transfersSince(previous, current map[string]float64) map[string]float64 {
+	if previous == nil {
+		return nil
+	}
+
+	transfers := make(map[string]float64)
+	for optionID, votes := range current {
+		if gained := votes - previous[optionID]; gained > 0 {
+			transfers[optionID] = roundVotes(gained)
+		}
+	}
+	return transfers
+}
+
+func inBallotOrder(options []string, set map[string]bool) []string {
+	var ordered []string
+	for _, optionID := range options {
+		if set[optionID] {
+			ordered = append(ordered, optionID)
+		}
+	}
+	return ordered
+}
+
+func roundTallies(tallies map[string]float64) map[string]float64 {
+	rounded := make(map[string]float64, len(tallies))
+	for optionID, votes := range tallies {
+		rounded[optionID] = roundVotes(votes)
+	}
+	return rounded
+}
+
+// roundVotes trims fractional transfer weights for reporting.
+func roundVotes(votes float64) float64 {
+	return math.Round(votes*10000) / 10000
+}
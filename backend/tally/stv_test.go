@@ -0,0 +1,81 @@
+package tally_test
+
+import (
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/tally"
+)
+
+func TestSingleTransferableVote(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []string
+		seats   int
+		ballots []tally.Ballot
+		quota   float64
+		winners []string
+		rounds  []tally.Round
+	}{
+		{
+			// ada's surplus of 4 over the quota of 5 moves on at 4/9 of a vote
+			name:    "SurplusTransfer",
+			options: []string{"ada", "grace", "alan"},
+			seats:   2,
+			ballots: join(
+				ranked(8, "ada", "grace"),
+				ranked(1, "ada"),
+				ranked(3, "alan"),
+			),
+			quota:   5,
+			winners: []string{"ada", "grace"},
+			rounds: []tally.Round{
+				{Number: 1, Tallies: votes("ada", 9, "grace", 0, "alan", 3), Elected: []string{"ada"}},
+				{Number: 2, Tallies: votes("grace", 3.5556, "alan", 3), Transfers: votes("grace", 3.5556), Exhausted: 0.4444, Eliminated: []string{"alan"}},
+				{Number: 3, Tallies: votes("grace", 3.5556), Exhausted: 3.4444, Elected: []string{"grace"}},
+			},
+		},
+		{
+			// Nobody reaches the quota of 4 until barbara is eliminated, and
+			// grace is elected with no surplus to transfer
+			name:    "EliminationBeforeElection",
+			options: []string{"ada", "grace", "alan", "barbara"},
+			seats:   2,
+			ballots: join(
+				ranked(3, "ada"),
+				ranked(3, "grace"),
+				ranked(2, "alan", "ada"),
+				ranked(1, "barbara", "grace"),
+			),
+			quota:   4,
+			winners: []string{"grace", "ada"},
+			rounds: []tally.Round{
+				{Number: 1, Tallies: votes("ada", 3, "grace", 3, "alan", 2, "barbara", 1), Eliminated: []string{"barbara"}},
+				{Number: 2, Tallies: votes("ada", 3, "grace", 4, "alan", 2), Transfers: votes("grace", 1), Elected: []string{"grace"}},
+				{Number: 3, Tallies: votes("ada", 3, "alan", 2), Exhausted: 0, Eliminated: []string{"alan"}},
+				{Number: 4, Tallies: votes("ada", 5), Transfers: votes("ada", 2), Elected: []string{"ada"}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			question := tally.Question{
+				ID:            "board",
+				Method:        tally.STV,
+				Options:       tc.options,
+				Seats:         tc.seats,
+				MaxSelections: len(tc.options),
+			}
+
+			result, err := tally.Tally(question, tc.ballots)
+			if err != nil {
+				t.Fatalf("Tally: %v", err)
+			}
+			if result.Quota != tc.quota {
+				t.Errorf("got quota %v, want %v", result.Quota, tc.quota)
+			}
+			checkResult(t, result, len(tc.ballots), tc.winners)
+			checkRounds(t, result.Rounds, tc.rounds)
+		})
+	}
+}
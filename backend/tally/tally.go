@@ -0,0 +1,261 @@
+// Package tally counts recorded votes for a single ballot question. It has no
+// storage dependencies so every method can be exercised with plain values.
+package tally
+
+import (
+	"fmt"
+	"sort"
+)
+
+type Method string
+
+const (
+	Plurality     Method = "plurality"
+	Approval      Method = "approval"
+	Score         Method = "score"
+	InstantRunoff Method = "instant_runoff"
+	Schulze       Method = "schulze"
+	STV           Method = "stv"
+)
+
+// Methods lists every supported counting method.
+var Methods = []Method{Plurality, Approval, Score, InstantRunoff, Schulze, STV}
+
+func (m Method) IsValid() bool {
+	for _, method := range Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRanked reports whether ballots for the method list options in preference
+// order.
+func (m Method) IsRanked() bool {
+	return m == InstantRunoff || m == Schulze || m == STV
+}
+
+// Question describes what is being counted. Options must be listed in ballot
+// order; that order is the final tie-breaker for every method.
+type Question struct {
+	ID      string
+	Method  Method
+	Options []string
+	// Seats is the number of winners to elect.
+	Seats int
+	// MaxSelections caps how many options a ballot may choose, approve or rank.
+	MaxSelections int
+	// MinScore and MaxScore bound the scores accepted by score voting.
+	MinScore int
+	MaxScore int
+	// FullRanking requires ranked ballots to rank every option.
+	FullRanking bool
+}
+
+// Ballot is one voter's answer to a question. Choices holds the chosen option
+// IDs, in preference order for ranked methods; Scores is only used by score
+// voting.
+type Ballot struct {
+	Choices []string
+	Scores  map[string]int
+}
+
+type Round struct {
+	Number     int                `json:"round"`
+	Tallies    map[string]float64 `json:"tallies"`
+	Elected    []string           `json:"elected,omitempty"`
+	Eliminated []string           `json:"eliminated,omitempty"`
+	Transfers  map[string]float64 `json:"transfers,omitempty"`
+	Exhausted  float64            `json:"exhausted"`
+	TieBreak   bool               `json:"tie_break,omitempty"`
+}
+
+type Result struct {
+	QuestionID   string                        `json:"question_id"`
+	Method       Method                        `json:"method"`
+	TotalBallots int                           `json:"total_ballots"`
+	Seats        int                           `json:"seats"`
+	Winners      []string                      `json:"winners"`
+	Tallies      map[string]float64            `json:"tallies,omitempty"`
+	Quota        float64                       `json:"quota,omitempty"`
+	Rounds       []Round                       `json:"rounds,omitempty"`
+	Pairwise     map[string]map[string]float64 `json:"pairwise,omitempty"`
+}
+
+// Validate checks that the question is internally consistent for its method.
+func (q Question) Validate() error {
+	if !q.Method.IsValid() {
+		return fmt.Errorf("unsupported voting method %s", q.Method)
+	}
+	if len(q.Options) < 2 {
+		return fmt.Errorf("at least two options are required")
+	}
+	if q.Seats < 1 || q.Seats >= len(q.Options) {
+		return fmt.Errorf("seats must be between 1 and %d", len(q.Options)-1)
+	}
+	if q.MaxSelections < 1 || q.MaxSelections > len(q.Options) {
+		return fmt.Errorf("max selections must be between 1 and %d", len(q.Options))
+	}
+	if q.Method == InstantRunoff && q.Seats != 1 {
+		return fmt.Errorf("instant runoff elects a single winner; use stv for multiple seats")
+	}
+	if q.Method == Plurality && q.MaxSelections < q.Seats {
+		return fmt.Errorf("max selections must be at least the number of seats")
+	}
+	if q.Method == Score && q.MaxScore <= q.MinScore {
+		return fmt.Errorf("max score must be greater than min score")
+	}
+	if q.FullRanking && !q.Method.IsRanked() {
+		return fmt.Errorf("full ranking only applies to ranked methods")
+	}
+	if q.FullRanking && q.MaxSelections != len(q.Options) {
+		return fmt.Errorf("max selections must allow every option to be ranked")
+	}
+
+	seen := make(map[string]bool, len(q.Options))
+	for _, optionID := range q.Options {
+		if seen[optionID] {
+			return fmt.Errorf("duplicate option %s", optionID)
+		}
+		seen[optionID] = true
+	}
+
+	return nil
+}
+
+// ValidateBallot checks a single ballot against the rules of the question's
+// method.
+func (q Question) ValidateBallot(ballot Ballot) error {
+	if q.Method == Score {
+		return q.validateScores(ballot)
+	}
+
+	if len(ballot.Scores) > 0 {
+		return fmt.Errorf("scores are only accepted for score voting")
+	}
+	if len(ballot.Choices) == 0 {
+		return fmt.Errorf("at least one option is required")
+	}
+	if len(ballot.Choices) > q.MaxSelections {
+		return fmt.Errorf("at most %d options may be %s", q.MaxSelections, q.selectionVerb())
+	}
+	if q.FullRanking && len(ballot.Choices) != len(q.Options) {
+		return fmt.Errorf("all %d options must be ranked", len(q.Options))
+	}
+
+	seen := make(map[string]bool, len(ballot.Choices))
+	for _, optionID := range ballot.Choices {
+		if indexOf(q.Options, optionID) < 0 {
+			return fmt.Errorf("unknown option %s", optionID)
+		}
+		if seen[optionID] {
+			return fmt.Errorf("option %s %s more than once", optionID, q.selectionVerb())
+		}
+		seen[optionID] = true
+	}
+
+	return nil
+}
+
+func (q Question) validateScores(ballot Ballot) error {
+	if len(ballot.Choices) > 0 {
+		return fmt.Errorf("score voting ballots must use scores, not options")
+	}
+	if len(ballot.Scores) == 0 {
+		return fmt.Errorf("at least one option must be scored")
+	}
+
+	for optionID, score := range ballot.Scores {
+		if indexOf(q.Options, optionID) < 0 {
+			return fmt.Errorf("unknown option %s", optionID)
+		}
+		if score < q.MinScore || score > q.MaxScore {
+			return fmt.Errorf("score for option %s must be between %d and %d", optionID, q.MinScore, q.MaxScore)
+		}
+	}
+
+	return nil
+}
+
+func (q Question) selectionVerb() string {
+	switch {
+	case q.Method == Approval:
+		return "approved"
+	case q.Method.IsRanked():
+		return "ranked"
+	default:
+		return "selected"
+	}
+}
+
+// Tally counts the ballots cast for a question using the question's method.
+func Tally(question Question, ballots []Ballot) (*Result, error) {
+	if err := question.Validate(); err != nil {
+		return nil, fmt.Errorf("question %s: %w", question.ID, err)
+	}
+
+	for i, ballot := range ballots {
+		if err := question.ValidateBallot(ballot); err != nil {
+			return nil, fmt.Errorf("ballot %d: %w", i+1, err)
+		}
+	}
+
+	var result *Result
+	switch question.Method {
+	case Plurality, Approval:
+		result = countChoices(question, ballots)
+	case Score:
+		result = countScores(question, ballots)
+	case InstantRunoff:
+		result = instantRunoff(question, ballots)
+	case Schulze:
+		result = schulze(question, ballots)
+	case STV:
+		result = singleTransferableVote(question, ballots)
+	}
+
+	result.QuestionID = question.ID
+	result.Method = question.Method
+	result.TotalBallots = len(ballots)
+	result.Seats = question.Seats
+	return result, nil
+}
+
+func zeroTallies(options []string) map[string]float64 {
+	tallies := make(map[string]float64, len(options))
+	for _, optionID := range options {
+		tallies[optionID] = 0
+	}
+	return tallies
+}
+
+// rankOptions orders options by descending tally, keeping ballot order for
+// options with equal tallies.
+func rankOptions(options []string, tallies map[string]float64) []string {
+	ranked := append([]string(nil), options...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return tallies[ranked[i]] > tallies[ranked[j]]
+	})
+	return ranked
+}
+
+// topN returns the first n ranked options, or none if nobody voted.
+func topN(ranked []string, n int, ballots []Ballot) []string {
+	if len(ballots) == 0 {
+		return nil
+	}
+	if n > len(ranked) {
+		n = len(ranked)
+	}
+	return ranked[:n]
+}
+
+func indexOf(options []string, optionID string) int {
+	for i, option := range options {
+		if option == optionID {
+			return i
+		}
+	}
+	return -1
+}
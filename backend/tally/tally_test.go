@@ -0,0 +1,207 @@
+package tally_test
+
+import (
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/tally"
+)
+
+func TestInstantRunoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []string
+		ballots []tally.Ballot
+		winners []string
+		rounds  []tally.Round
+	}{
+		{
+			name:    "MajorityInFirstRound",
+			options: []string{"ada", "grace", "alan"},
+			ballots: join(
+				ranked(3, "ada", "grace"),
+				ranked(1, "grace"),
+				ranked(1, "alan", "grace"),
+			),
+			winners: []string{"ada"},
+			rounds: []tally.Round{
+				{Number: 1, Tallies: votes("ada", 3, "grace", 1, "alan", 1), Elected: []string{"ada"}},
+			},
+		},
+		{
+			name:    "Transfers",
+			options: []string{"ada", "grace", "alan", "barbara"},
+			ballots: join(
+				ranked(5, "ada"),
+				ranked(4, "grace", "alan"),
+				ranked(3, "alan", "grace"),
+				ranked(2, "barbara", "alan"),
+			),
+			winners: []string{"alan"},
+			rounds: []tally.Round{
+				{Number: 1, Tallies: votes("ada", 5, "grace", 4, "alan", 3, "barbara", 2), Eliminated: []string{"barbara"}},
+				{Number: 2, Tallies: votes("ada", 5, "grace", 4, "alan", 5), Transfers: votes("alan", 2), Eliminated: []string{"grace"}},
+				{Number: 3, Tallies: votes("ada", 5, "alan", 9), Transfers: votes("alan", 4), Elected: []string{"alan"}},
+			},
+		},
+		{
+			// Exhausted ballots no longer count towards the majority
+			name:    "ExhaustedBallots",
+			options: []string{"ada", "grace", "alan"},
+			ballots: join(
+				ranked(4, "ada"),
+				ranked(3, "grace"),
+				ranked(2, "alan"),
+			),
+			winners: []string{"ada"},
+			rounds: []tally.Round{
+				{Number: 1, Tallies: votes("ada", 4, "grace", 3, "alan", 2), Eliminated: []string{"alan"}},
+				{Number: 2, Tallies: votes("ada", 4, "grace", 3), Exhausted: 2, Elected: []string{"ada"}},
+			},
+		},
+		{
+			// grace and alan tie in round 2; alan had fewer votes in round 1
+			name:    "TieBrokenByEarlierRound",
+			options: []string{"ada", "grace", "alan", "barbara"},
+			ballots: join(
+				ranked(5, "ada"),
+				ranked(3, "grace"),
+				ranked(2, "alan"),
+				ranked(1, "barbara", "alan"),
+			),
+			winners: []string{"ada"},
+			rounds: []tally.Round{
+				{Number: 1, Tallies: votes("ada", 5, "grace", 3, "alan", 2, "barbara", 1), Eliminated: []string{"barbara"}},
+				{Number: 2, Tallies: votes("ada", 5, "grace", 3, "alan", 3), Transfers: votes("alan", 1), Eliminated: []string{"alan"}, TieBreak: true},
+				{Number: 3, Tallies: votes("ada", 5, "grace", 3), Exhausted: 3, Elected: []string{"ada"}},
+			},
+		},
+		{
+			// With no earlier round to look back to, the option listed last goes
+			name:    "TieBrokenByBallotOrder",
+			options: []string{"ada", "grace", "alan"},
+			ballots: join(
+				ranked(2, "ada"),
+				ranked(1, "grace"),
+				ranked(1, "alan"),
+			),
+			winners: []string{"ada"},
+			rounds: []tally.Round{
+				{Number: 1, Tallies: votes("ada", 2, "grace", 1, "alan", 1), Eliminated: []string{"alan"}, TieBreak: true},
+				{Number: 2, Tallies: votes("ada", 2, "grace", 1), Exhausted: 1, Elected: []string{"ada"}},
+			},
+		},
+		{
+			name:    "NoBallots",
+			options: []string{"ada", "grace"},
+			rounds: []tally.Round{
+				{Number: 1, Tallies: votes("ada", 0, "grace", 0)},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			question := tally.Question{
+				ID:            "chair",
+				Method:        tally.InstantRunoff,
+				Options:       tc.options,
+				Seats:         1,
+				MaxSelections: len(tc.options),
+			}
+
+			result, err := tally.Tally(question, tc.ballots)
+			if err != nil {
+				t.Fatalf("Tally: %v", err)
+			}
+			checkResult(t, result, len(tc.ballots), tc.winners)
+			checkRounds(t, result.Rounds, tc.rounds)
+		})
+	}
+}
+
+func checkResult(t *testing.T, result *tally.Result, ballots int, winners []string) {
+	t.Helper()
+
+	if result.TotalBallots != ballots {
+		t.Errorf("got %d ballots, want %d", result.TotalBallots, ballots)
+	}
+	if !sameOptions(result.Winners, winners) {
+		t.Errorf("got winners %v, want %v", result.Winners, winners)
+	}
+}
+
+func checkRounds(t *testing.T, got, want []tally.Round) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d rounds %+v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Number != w.Number || !sameVotes(g.Tallies, w.Tallies) || !sameVotes(g.Transfers, w.Transfers) || g.Exhausted != w.Exhausted {
+			t.Errorf("round %d: got tallies %v, transfers %v and %v exhausted, want %v, %v and %v",
+				w.Number, g.Tallies, g.Transfers, g.Exhausted, w.Tallies, w.Transfers, w.Exhausted)
+		}
+		if !sameOptions(g.Elected, w.Elected) || !sameOptions(g.Eliminated, w.Eliminated) || g.TieBreak != w.TieBreak {
+			t.Errorf("round %d: got elected %v, eliminated %v and tie break %v, want %v, %v and %v",
+				w.Number, g.Elected, g.Eliminated, g.TieBreak, w.Elected, w.Eliminated, w.TieBreak)
+		}
+	}
+}
+
+// ranked returns n ballots ranking the options in the order given.
+func ranked(n int, choices ...string) []tally.Ballot {
+	ballots := make([]tally.Ballot, n)
+	for i := range ballots {
+		ballots[i] = tally.Ballot{Choices: choices}
+	}
+	return ballots
+}
+
+func join(groups ...[]tally.Ballot) []tally.Ballot {
+	var ballots []tally.Ballot
+	for _, group := range groups {
+		ballots = append(ballots, group...)
+	}
+	return ballots
+}
+
+// votes builds a tally from option and count pairs. Counts may be ints or
+// float64s.
+func votes(pairs ...any) map[string]float64 {
+	tallies := make(map[string]float64, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		switch count := pairs[i+1].(type) {
+		case int:
+			tallies[pairs[i].(string)] = float64(count)
+		case float64:
+			tallies[pairs[i].(string)] = count
+		}
+	}
+	return tallies
+}
+
+// sameVotes compares tallies, treating nil and empty as equal.
+func sameVotes(got, want map[string]float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for optionID, count := range want {
+		if votes, ok := got[optionID]; !ok || votes != count {
+			return false
+		}
+	}
+	return true
+}
+
+func sameOptions(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
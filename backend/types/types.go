@@ -1,7 +1,11 @@
 package types
 
 type APIResponse struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
+	Success    bool                `json:"success"`
+	Code       string              `json:"code,omitempty"`
+	Message    string              `json:"message,omitempty"`
+	Errors     map[string][]string `json:"errors,omitempty"`
+	Data       interface{}         `json:"data,omitempty"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+	Total      *int64              `json:"total,omitempty"`
 }
\ No newline at end of file
@@ -0,0 +1,129 @@
+// Package validation checks the fields of a request and collects every
+// problem it finds, so clients can show them all at once.
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+)
+
+// Validator collects messages for the fields of a request that are invalid.
+// Fields are named by their JSON path, such as "questions[0].prompt". The
+// zero value is ready to use.
+//
+// Checks other than Required pass empty values, so optional fields are only
+// checked when they are supplied.
+type Validator struct {
+	fields map[string][]string
+}
+
+// Add records a problem with a field. Messages are relative to the field,
+// such as "is required".
+func (v *Validator) Add(field, message string) {
+	if v.fields == nil {
+		v.fields = make(map[string][]string)
+	}
+	v.fields[field] = append(v.fields[field], message)
+}
+
+// Check adds the message unless ok is true.
+func (v *Validator) Check(ok bool, field, message string) {
+	if !ok {
+		v.Add(field, message)
+	}
+}
+
+// Valid reports whether no problems have been recorded.
+func (v *Validator) Valid() bool {
+	return len(v.fields) == 0
+}
+
+// Required rejects values that are empty or only whitespace. It reports
+// whether the value was present, so callers can skip checks that only make
+// sense for one.
+func (v *Validator) Required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.Add(field, "is required")
+		return false
+	}
+	return true
+}
+
+// Length bounds the number of characters in a value. A bound of zero is not
+// checked.
+func (v *Validator) Length(field, value string, min, max int) {
+	if value == "" {
+		return
+	}
+
+	length := utf8.RuneCountInString(value)
+	if min > 0 && length < min {
+		v.Add(field, fmt.Sprintf("must be at least %d characters long", min))
+	}
+	if max > 0 && length > max {
+		v.Add(field, fmt.Sprintf("must be at most %d characters long", max))
+	}
+}
+
+// MaxBytes bounds the encoded size of a value, for limits such as bcrypt's
+// that count bytes rather than characters.
+func (v *Validator) MaxBytes(field, value string, max int) {
+	if len(value) > max {
+		v.Add(field, fmt.Sprintf("must be at most %d bytes long", max))
+	}
+}
+
+// Email requires a single RFC 5322 address without a display name, such as
+// "jane@example.com".
+func (v *Validator) Email(field, value string) {
+	if value == "" {
+		return
+	}
+
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Name != "" || address.Address != value {
+		v.Add(field, "must be a valid email address")
+	}
+}
+
+// URL requires an absolute http or https URL.
+func (v *Validator) URL(field, value string) {
+	if value == "" {
+		return
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		v.Add(field, "must be a valid http or https URL")
+	}
+}
+
+// OneOf requires the value to be one of allowed.
+func OneOf[T ~string](v *Validator, field string, value T, allowed ...T) {
+	if value == "" {
+		return
+	}
+
+	names := make([]string, len(allowed))
+	for i, option := range allowed {
+		if value == option {
+			return
+		}
+		names[i] = string(option)
+	}
+	v.Add(field, "must be one of "+strings.Join(names, ", "))
+}
+
+// Err returns an *apperr.ValidationError holding every recorded problem, or
+// nil if there were none.
+func (v *Validator) Err() error {
+	if v.Valid() {
+		return nil
+	}
+	return &apperr.ValidationError{Fields: v.fields}
+}
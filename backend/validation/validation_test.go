@@ -0,0 +1,87 @@
+package validation_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bpalazzi512/easy-ballot/backend/apperr"
+	"github.com/bpalazzi512/easy-ballot/backend/validation"
+)
+
+type role string
+
+func TestValidator(t *testing.T) {
+	var v validation.Validator
+	if !v.Valid() || v.Err() != nil {
+		t.Fatalf("zero Validator returned error %v, want none", v.Err())
+	}
+
+	// Every problem with every field is collected
+	v.Required("first_name", "  ")
+	v.Required("last_name", "Lovelace")
+	v.Email("email", "Ada <ada@example.com>")
+	v.Length("password", "short", 8, 0)
+	v.MaxBytes("password", "short", 4)
+	v.URL("profile_picture", "ftp://example.com/ada.png")
+	v.Check(false, "questions[0].options", "must have at least two options")
+	v.Add("questions[0].options", "duplicates option ID a")
+	validation.OneOf(&v, "role", role("superuser"), "owner", "voter")
+
+	want := map[string][]string{
+		"first_name":           {"is required"},
+		"email":                {"must be a valid email address"},
+		"password":             {"must be at least 8 characters long", "must be at most 4 bytes long"},
+		"profile_picture":      {"must be a valid http or https URL"},
+		"questions[0].options": {"must have at least two options", "duplicates option ID a"},
+		"role":                 {"must be one of owner, voter"},
+	}
+
+	if v.Valid() {
+		t.Fatal("Valid returned true after problems were added")
+	}
+	err := v.Err()
+	if !errors.Is(err, apperr.ErrValidation) {
+		t.Fatalf("Err returned %v, want a validation error", err)
+	}
+	if fields := apperr.Fields(err); !reflect.DeepEqual(fields, want) {
+		t.Errorf("Err returned fields %v, want %v", fields, want)
+	}
+	for field := range want {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("error %q does not mention %s", err.Error(), field)
+		}
+	}
+}
+
+func TestValidatorChecks(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		check func(v *validation.Validator)
+		valid bool
+	}{
+		{"Required", func(v *validation.Validator) { v.Required("f", "Ada") }, true},
+		{"RequiredEmpty", func(v *validation.Validator) { v.Required("f", "") }, false},
+		{"LengthInRange", func(v *validation.Validator) { v.Length("f", "Ada", 1, 3) }, true},
+		{"LengthCountsCharacters", func(v *validation.Validator) { v.Length("f", "Zoë", 0, 3) }, true},
+		{"LengthTooLong", func(v *validation.Validator) { v.Length("f", "Lovelace", 0, 3) }, false},
+		{"LengthEmpty", func(v *validation.Validator) { v.Length("f", "", 5, 10) }, true},
+		{"MaxBytesCountsBytes", func(v *validation.Validator) { v.MaxBytes("f", "Zoë", 3) }, false},
+		{"Email", func(v *validation.Validator) { v.Email("f", "ada@example.com") }, true},
+		{"EmailWithoutDomain", func(v *validation.Validator) { v.Email("f", "ada") }, false},
+		{"EmailEmpty", func(v *validation.Validator) { v.Email("f", "") }, true},
+		{"URL", func(v *validation.Validator) { v.URL("f", "https://example.com/ada.png") }, true},
+		{"URLRelative", func(v *validation.Validator) { v.URL("f", "/ada.png") }, false},
+		{"OneOf", func(v *validation.Validator) { validation.OneOf(v, "f", role("voter"), "owner", "voter") }, true},
+		{"OneOfEmpty", func(v *validation.Validator) { validation.OneOf(v, "f", role(""), "owner") }, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var v validation.Validator
+			tc.check(&v)
+			if v.Valid() != tc.valid {
+				t.Errorf("got problems %v, want valid %v", apperr.Fields(v.Err()), tc.valid)
+			}
+		})
+	}
+}